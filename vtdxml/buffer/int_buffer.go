@@ -66,7 +66,7 @@ func (b *FastIntBuffer) IntAt(index int) (int32, error) {
 	if err != nil {
 		return 0, err
 	}
-	if index < 0 || index >= len(bufferSlice) {
+	if index < 0 || index >= b.size || offset >= len(bufferSlice) {
 		return 0, erroring.NewInvalidArgumentError("index", erroring.IndexOutOfRange, nil)
 	}
 	v := bufferSlice[offset]
@@ -81,7 +81,7 @@ func (b *FastIntBuffer) ModifyEntry(index int, value int32) error {
 	}
 
 	offset := index & b.r
-	if index < 0 || index >= len(bufferSlice) {
+	if index < 0 || index >= b.size || offset >= len(bufferSlice) {
 		return erroring.NewInvalidArgumentError("index", erroring.IndexOutOfRange, nil)
 	}
 	bufferSlice[offset] = value
@@ -113,9 +113,8 @@ func (b *FastIntBuffer) ToIntArray() ([]int32, error) {
 			return nil, err
 		}
 		// load-in buffer page into int32 slice
-		for j := range buffer {
-			v := buffer[j]
-			intArray = append(intArray, v)
+		for j := 0; j < len(buffer) && j < size; j++ {
+			intArray = append(intArray, buffer[j])
 		}
 		// subtract buffer size with read page size
 		size -= b.pageSize
@@ -125,14 +124,16 @@ func (b *FastIntBuffer) ToIntArray() ([]int32, error) {
 
 func (b *FastIntBuffer) Append(value int32) error {
 	if b.size < b.capacity {
-		pageNum := b.size >> b.exp
-		bufferSlice, err := b.get(pageNum)
-		if err != nil {
-			return err
+		// pages are kept on truncation, so overwrite stale entries
+		// before growing the page
+		pageNum, offset := b.size>>b.exp, b.size&b.r
+		if offset < len(b.buffer[pageNum]) {
+			b.buffer[pageNum][offset] = value
+		} else {
+			b.buffer[pageNum] = append(b.buffer[pageNum], value)
 		}
-		bufferSlice = append(bufferSlice, value)
 		b.size++
-		return b.set(pageNum, bufferSlice)
+		return nil
 	} else {
 		b.size++
 		b.capacity += b.pageSize
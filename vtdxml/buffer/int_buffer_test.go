@@ -52,6 +52,21 @@ func Test_FastIntBuffer_IntAt_Success(t *testing.T) {
 	}
 }
 
+func Test_FastIntBuffer_IntAt_MultiplePages_Success(t *testing.T) {
+	buffer, err := NewFastIntBuffer(WithFastIntBufferPageSize(2))
+	assert.Nil(t, err)
+	for i := 0; i < 10; i++ {
+		assert.Nil(t, buffer.Append(int32(i)))
+	}
+	for i := 0; i < 10; i++ {
+		value, err := buffer.IntAt(i)
+		assert.Nil(t, err)
+		assert.Equal(t, int32(i), value)
+	}
+	_, err = buffer.IntAt(10)
+	assert.EqualError(t, err, "invalid argument index: array index out of range")
+}
+
 func Test_FastIntBuffer_IntAt_InvalidArgument(t *testing.T) {
 	buffer := getInitializedFastIntBuffer(t)
 
@@ -149,6 +164,21 @@ func Test_FastIntBuffer_Clear_Success(t *testing.T) {
 	assert.Equal(t, 0, buffer.GetSize())
 }
 
+func Test_FastIntBuffer_Append_AfterClear_Success(t *testing.T) {
+	buffer, err := NewFastIntBuffer(WithFastIntBufferPageSize(2))
+	assert.Nil(t, err)
+	for i := 0; i < 10; i++ {
+		assert.Nil(t, buffer.Append(int32(i)))
+	}
+	buffer.Clear()
+	for i := 0; i < 6; i++ {
+		assert.Nil(t, buffer.Append(int32(i*10)))
+	}
+	values, err := buffer.ToIntArray()
+	assert.Nil(t, err)
+	assert.Equal(t, []int32{0, 10, 20, 30, 40, 50}, values)
+}
+
 func getInitializedFastIntBuffer(t *testing.T) *FastIntBuffer {
 	buffer, err := NewFastIntBuffer(WithFastIntBufferPageSize(defaultIntSize))
 	assert.Nil(t, err)
@@ -52,6 +52,21 @@ func Test_FastLongBuffer_LongAt_Success(t *testing.T) {
 	}
 }
 
+func Test_FastLongBuffer_LongAt_MultiplePages_Success(t *testing.T) {
+	buffer, err := NewFastLongBuffer(WithFastLongBufferPageSize(2))
+	assert.Nil(t, err)
+	for i := 0; i < 10; i++ {
+		assert.Nil(t, buffer.Append(int64(i)))
+	}
+	for i := 0; i < 10; i++ {
+		value, err := buffer.LongAt(i)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(i), value)
+	}
+	_, err = buffer.LongAt(10)
+	assert.EqualError(t, err, "invalid argument index: array index out of range")
+}
+
 func Test_FastLongBuffer_LongAt_InvalidArgument(t *testing.T) {
 	buffer := getInitializedFastLongBuffer(t)
 
@@ -149,6 +164,21 @@ func Test_FastLongBuffer_Clear_Success(t *testing.T) {
 	assert.Equal(t, 0, buffer.GetSize())
 }
 
+func Test_FastLongBuffer_Append_AfterClear_Success(t *testing.T) {
+	buffer, err := NewFastLongBuffer(WithFastLongBufferPageSize(2))
+	assert.Nil(t, err)
+	for i := 0; i < 10; i++ {
+		assert.Nil(t, buffer.Append(int64(i)))
+	}
+	buffer.Clear()
+	for i := 0; i < 6; i++ {
+		assert.Nil(t, buffer.Append(int64(i*10)))
+	}
+	values, err := buffer.ToLongArray()
+	assert.Nil(t, err)
+	assert.Equal(t, []int64{0, 10, 20, 30, 40, 50}, values)
+}
+
 func Test_FastLongBuffer_Lower32At_Success(t *testing.T) {
 	buffer := getInitializedFastLongBuffer(t)
 
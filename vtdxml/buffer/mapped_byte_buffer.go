@@ -0,0 +1,54 @@
+package buffer
+
+import (
+	"os"
+
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/erroring"
+)
+
+// MappedByteBuffer is a read-only byte buffer backed by a memory-mapped file.
+// Slices returned by the buffer point into the mapping and must not be used
+// after Close.
+type MappedByteBuffer struct {
+	UniByteBuffer
+}
+
+// NewMappedByteBuffer function maps the whole file read-only into memory
+func NewMappedByteBuffer(path string) (*MappedByteBuffer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() == 0 {
+		return nil, erroring.NewInvalidArgumentError("path", "file cannot be empty", nil)
+	}
+	if int64(int(info.Size())) != info.Size() {
+		return nil, erroring.NewInvalidArgumentError("path", "file too large to map", nil)
+	}
+	data, err := mapFile(f, int(info.Size()))
+	if err != nil {
+		return nil, err
+	}
+	return &MappedByteBuffer{
+		UniByteBuffer: UniByteBuffer{
+			buffer: data,
+		},
+	}, nil
+}
+
+// Close function unmaps the file. Closing an already closed buffer is a
+// no-op.
+func (b *MappedByteBuffer) Close() error {
+	if b.buffer == nil {
+		return nil
+	}
+	data := b.buffer
+	b.buffer = nil
+	return unmapFile(data)
+}
@@ -0,0 +1,44 @@
+//go:build linux
+// +build linux
+
+package buffer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NewMappedByteBuffer_Success(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "doc.xml")
+	assert.Nil(t, ioutil.WriteFile(path, []byte("<a>text</a>"), 0o600))
+
+	buffer, err := NewMappedByteBuffer(path)
+	assert.Nil(t, err)
+	assert.Equal(t, 11, buffer.GetSize())
+	b, err := buffer.ByteAt(3)
+	assert.Nil(t, err)
+	assert.Equal(t, byte('t'), b)
+	assert.Equal(t, "<a>text</a>", string(buffer.GetBytes()))
+
+	assert.Nil(t, buffer.Close())
+	assert.Nil(t, buffer.Close())
+	_, err = buffer.ByteAt(3)
+	assert.EqualError(t, err, "invalid argument index: array index out of range")
+}
+
+func Test_NewMappedByteBuffer_Failed(t *testing.T) {
+	dir := t.TempDir()
+	buffer, err := NewMappedByteBuffer(filepath.Join(dir, "missing.xml"))
+	assert.Nil(t, buffer)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	path := filepath.Join(dir, "empty.xml")
+	assert.Nil(t, ioutil.WriteFile(path, nil, 0o600))
+	buffer, err = NewMappedByteBuffer(path)
+	assert.Nil(t, buffer)
+	assert.EqualError(t, err, "invalid argument path: file cannot be empty")
+}
@@ -0,0 +1,24 @@
+//go:build linux
+// +build linux
+
+package buffer
+
+import (
+	"os"
+	"syscall"
+)
+
+func mapFile(f *os.File, size int) ([]byte, error) {
+	data, err := syscall.Mmap(int(f.Fd()), 0, size, syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, os.NewSyscallError("mmap", err)
+	}
+	return data, nil
+}
+
+func unmapFile(data []byte) error {
+	if err := syscall.Munmap(data); err != nil {
+		return os.NewSyscallError("munmap", err)
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+//go:build !linux
+// +build !linux
+
+package buffer
+
+import (
+	"os"
+
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/erroring"
+)
+
+func mapFile(f *os.File, size int) ([]byte, error) {
+	return nil, erroring.NewInternalError("memory-mapped files are not supported on this platform", nil)
+}
+
+func unmapFile(data []byte) error {
+	return nil
+}
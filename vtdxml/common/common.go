@@ -28,6 +28,8 @@ const (
 	MaskTokenDepth      uint64 = 0x0FF0000000000000
 )
 
+// FormatEncoding is the character encoding of the parsed document. Values
+// are persisted in the index, new encodings are added at the end.
 type FormatEncoding int
 
 const (
@@ -61,3 +63,25 @@ const (
 	FormatWin1257
 	FormatWin1258
 )
+
+var encodingNames = [...]string{
+	"ASCII", "ISO-8859-1", "ISO-8859-2", "ISO-8859-3", "ISO-8859-4", "ISO-8859-5", "ISO-8859-6", "ISO-8859-7",
+	"ISO-8859-8", "ISO-8859-9", "ISO-8859-10", "ISO-8859-11", "ISO-8859-12", "ISO-8859-13", "ISO-8859-14",
+	"ISO-8859-15", "ISO-8859-16", "UTF-16BE", "UTF-16LE", "UTF-8", "WINDOWS-1250", "WINDOWS-1251",
+	"WINDOWS-1252", "WINDOWS-1253", "WINDOWS-1254", "WINDOWS-1255", "WINDOWS-1256", "WINDOWS-1257",
+	"WINDOWS-1258",
+}
+
+// IsUtf16 function checks whether the encoding stores characters in 16-bit
+// code units, every other encoding is read one byte at a time
+func (e FormatEncoding) IsUtf16() bool {
+	return e == FormatUtf16BE || e == FormatUtf16LE
+}
+
+// String function returns canonical name of the encoding
+func (e FormatEncoding) String() string {
+	if e < 0 || int(e) >= len(encodingNames) {
+		return "UNKNOWN"
+	}
+	return encodingNames[e]
+}
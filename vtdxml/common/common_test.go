@@ -0,0 +1,34 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_FormatEncoding_Values(t *testing.T) {
+	// values are persisted in the index and must not change
+	testCases := []struct {
+		encoding      FormatEncoding
+		expectedValue int
+		expectedName  string
+		expectedUtf16 bool
+	}{
+		{encoding: FormatAscii, expectedValue: 0, expectedName: "ASCII"},
+		{encoding: FormatIso88591, expectedValue: 1, expectedName: "ISO-8859-1"},
+		{encoding: FormatIso885916, expectedValue: 16, expectedName: "ISO-8859-16"},
+		{encoding: FormatUtf16BE, expectedValue: 17, expectedName: "UTF-16BE", expectedUtf16: true},
+		{encoding: FormatUtf16LE, expectedValue: 18, expectedName: "UTF-16LE", expectedUtf16: true},
+		{encoding: FormatUtf8, expectedValue: 19, expectedName: "UTF-8"},
+		{encoding: FormatWin1250, expectedValue: 20, expectedName: "WINDOWS-1250"},
+		{encoding: FormatWin1258, expectedValue: 28, expectedName: "WINDOWS-1258"},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.expectedName, func(t *testing.T) {
+			assert.Equal(t, tc.expectedValue, int(tc.encoding))
+			assert.Equal(t, tc.expectedName, tc.encoding.String())
+			assert.Equal(t, tc.expectedUtf16, tc.encoding.IsUtf16())
+		})
+	}
+}
@@ -1,5 +1,9 @@
 package common
 
+// restrictedCharRanges are ranges of characters XML 1.1 allows in character
+// references only
+var restrictedCharRanges = [][2]uint32{{0x1, 0x8}, {0xB, 0xC}, {0xE, 0x1F}, {0x7F, 0x84}, {0x86, 0x9F}}
+
 type XmlChar struct {
 	validCharSlice     []uint32
 	spaceCharSlice     []uint32
@@ -7,6 +11,7 @@ type XmlChar struct {
 	nameStartCharSlice []uint32
 	contentCharSlice   []uint32
 	specialCharSlice   []uint32
+	xml11              bool
 }
 
 func NewXmlChar() *XmlChar {
@@ -68,6 +73,19 @@ func NewXmlChar() *XmlChar {
 	return x
 }
 
+// NewXmlChar11 function creates character tables of XML 1.1 documents.
+// Restricted characters, i.e. C0 controls other than white space, DEL and C1
+// controls other than NEL, are valid in character references only. NEL and
+// LSEP end lines the same as LF, so they are space characters. Name tables
+// are the ones XML 1.0 fifth edition adopted from XML 1.1 and are shared
+// with the XML 1.0 tables.
+func NewXmlChar11() *XmlChar {
+	x := NewXmlChar()
+	x.xml11 = true
+	x.spaceCharSlice = append(x.spaceCharSlice, 0x85, 0x2028)
+	return x
+}
+
 func (x *XmlChar) IsNameStartChar(ch uint32) bool {
 	return x.exists(x.nameStartCharSlice, ch)
 }
@@ -77,15 +95,35 @@ func (x *XmlChar) IsSpaceChar(ch uint32) bool {
 }
 
 func (x *XmlChar) IsValidChar(ch uint32) bool {
-	return x.exists(x.validCharSlice, ch)
+	return !x.isRestrictedChar(ch) && x.exists(x.validCharSlice, ch)
 }
 
 func (x *XmlChar) IsNameChar(ch uint32) bool {
 	return x.exists(x.nameCharSlice, ch)
 }
 
+// IsReferenceChar function returns true if the character may be referred to
+// by a character reference, which in XML 1.1 includes restricted characters
+func (x *XmlChar) IsReferenceChar(ch uint32) bool {
+	return x.exists(x.validCharSlice, ch)
+}
+
 func (x *XmlChar) IsContentChar(ch uint32) bool {
-	return !x.exists(x.specialCharSlice, ch) && x.exists(x.validCharSlice, ch)
+	return !x.exists(x.specialCharSlice, ch) && x.IsValidChar(ch)
+}
+
+// isRestrictedChar function returns true if the character is XML 1.1
+// restricted character
+func (x *XmlChar) isRestrictedChar(ch uint32) bool {
+	if !x.xml11 {
+		return false
+	}
+	for _, r := range restrictedCharRanges {
+		if ch >= r[0] && ch <= r[1] {
+			return true
+		}
+	}
+	return false
 }
 
 func (x *XmlChar) exists(arr []uint32, ch uint32) bool {
@@ -24,3 +24,24 @@ func Test_XmlChar_IsValidChar_Success(t *testing.T) {
 		assert.True(t, x.IsValidChar(ch), fmt.Sprintf("Invalid character %c", ch))
 	}
 }
+
+func Test_XmlChar_Xml11(t *testing.T) {
+	x10, x11 := NewXmlChar(), NewXmlChar11()
+	for _, ch := range []uint32{0x1, 0x1F, 0x7F, 0x84, 0x86, 0x9F} {
+		assert.True(t, x10.IsValidChar(ch), fmt.Sprintf("XML 1.0 character %#x", ch))
+		assert.False(t, x11.IsValidChar(ch), fmt.Sprintf("XML 1.1 character %#x", ch))
+		assert.False(t, x11.IsContentChar(ch), fmt.Sprintf("XML 1.1 content character %#x", ch))
+		assert.True(t, x11.IsReferenceChar(ch), fmt.Sprintf("XML 1.1 reference character %#x", ch))
+	}
+	for _, ch := range []uint32{0x85, 0x2028} {
+		assert.False(t, x10.IsSpaceChar(ch), fmt.Sprintf("XML 1.0 space character %#x", ch))
+		assert.True(t, x11.IsSpaceChar(ch), fmt.Sprintf("XML 1.1 space character %#x", ch))
+		assert.True(t, x11.IsContentChar(ch), fmt.Sprintf("XML 1.1 content character %#x", ch))
+	}
+	for _, ch := range charSlice {
+		assert.True(t, x11.IsValidChar(ch), fmt.Sprintf("Invalid character %c", ch))
+		assert.Equal(t, x10.IsNameChar(ch), x11.IsNameChar(ch))
+	}
+	assert.False(t, x11.IsReferenceChar(0))
+	assert.False(t, x11.IsReferenceChar(0xFFFE))
+}
@@ -0,0 +1,149 @@
+package dtd
+
+import (
+	"strings"
+)
+
+type AttributeType int
+
+const (
+	AttrCdata AttributeType = iota
+	AttrID
+	AttrIDRef
+	AttrIDRefs
+	AttrEntity
+	AttrEntities
+	AttrNmToken
+	AttrNmTokens
+	AttrNotation
+	AttrEnumeration
+)
+
+var attributeTypes = map[string]AttributeType{
+	"CDATA":    AttrCdata,
+	"ID":       AttrID,
+	"IDREF":    AttrIDRef,
+	"IDREFS":   AttrIDRefs,
+	"ENTITY":   AttrEntity,
+	"ENTITIES": AttrEntities,
+	"NMTOKEN":  AttrNmToken,
+	"NMTOKENS": AttrNmTokens,
+}
+
+type DefaultType int
+
+const (
+	DefaultValue DefaultType = iota
+	DefaultRequired
+	DefaultImplied
+	DefaultFixed
+)
+
+// AttributeDef is an attribute definition of an attribute list declaration.
+// Values lists allowed values of enumerated and NOTATION attributes, Value
+// holds the default or fixed value.
+type AttributeDef struct {
+	Name    string
+	Type    AttributeType
+	Values  []string
+	Default DefaultType
+	Value   string
+}
+
+// ParseAttlist function parses attribute definitions of an attribute list
+// declaration, i.e. content following the element name
+func ParseAttlist(content string) ([]*AttributeDef, error) {
+	s := &specScanner{spec: content}
+	var defs []*AttributeDef
+	for {
+		s.skipWs()
+		if s.pos == len(s.spec) {
+			return defs, nil
+		}
+		def := &AttributeDef{Name: s.name()}
+		if def.Name == "" {
+			return nil, s.error()
+		}
+		s.skipWs()
+		if err := s.attributeType(def); err != nil {
+			return nil, err
+		}
+		s.skipWs()
+		if err := s.defaultDecl(def); err != nil {
+			return nil, err
+		}
+		defs = append(defs, def)
+	}
+}
+
+// attributeType function reads attribute type, e.g. CDATA or (a|b)
+func (s *specScanner) attributeType(def *AttributeDef) error {
+	if s.skip('(') {
+		def.Type = AttrEnumeration
+		return s.enumeration(def)
+	}
+	keyword := s.name()
+	if keyword == "NOTATION" {
+		def.Type = AttrNotation
+		s.skipWs()
+		if !s.skip('(') {
+			return s.error()
+		}
+		return s.enumeration(def)
+	}
+	attrType, ok := attributeTypes[keyword]
+	if !ok {
+		return s.error()
+	}
+	def.Type = attrType
+	return nil
+}
+
+// enumeration function reads values following ( up to )
+func (s *specScanner) enumeration(def *AttributeDef) error {
+	for {
+		s.skipWs()
+		value := s.name()
+		if value == "" {
+			return s.error()
+		}
+		def.Values = append(def.Values, value)
+		s.skipWs()
+		if s.skip(')') {
+			return nil
+		}
+		if !s.skip('|') {
+			return s.error()
+		}
+	}
+}
+
+// defaultDecl function reads #REQUIRED, #IMPLIED or [#FIXED] "value"
+func (s *specScanner) defaultDecl(def *AttributeDef) error {
+	if s.skip('#') {
+		switch keyword := s.name(); keyword {
+		case "REQUIRED":
+			def.Default = DefaultRequired
+			return nil
+		case "IMPLIED":
+			def.Default = DefaultImplied
+			return nil
+		case "FIXED":
+			def.Default = DefaultFixed
+			s.skipWs()
+		default:
+			return s.error()
+		}
+	}
+	if s.pos == len(s.spec) || (s.spec[s.pos] != '"' && s.spec[s.pos] != '\'') {
+		return s.error()
+	}
+	quote := s.spec[s.pos]
+	end := strings.IndexByte(s.spec[s.pos+1:], quote)
+	if end < 0 {
+		return s.error()
+	}
+	def.Value = s.spec[s.pos+1 : s.pos+1+end]
+	s.pos += end + 2
+	return nil
+}
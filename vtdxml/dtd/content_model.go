@@ -0,0 +1,290 @@
+package dtd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/erroring"
+)
+
+type ContentType int
+
+const (
+	ContentEmpty ContentType = iota
+	ContentAny
+	ContentMixed
+	ContentChildren
+)
+
+// Particle is a node of element content model: either an element name or a
+// sequence or choice of particles, repeated as the occurrence indicator
+// (0, '?', '*' or '+') says
+type Particle struct {
+	Name       string
+	Choice     bool
+	Children   []*Particle
+	Occurrence byte
+}
+
+// ContentModel is the content specification of an element declaration.
+// Mixed content lists element names allowed among text, children content is
+// described by its root particle.
+type ContentModel struct {
+	Type     ContentType
+	Names    []string
+	Particle *Particle
+}
+
+// ParseContentModel function parses content specification of an element
+// declaration, e.g. EMPTY, (#PCDATA|b)* or (a,(b|c)+,d?)
+func ParseContentModel(spec string) (*ContentModel, error) {
+	spec = strings.TrimSpace(spec)
+	switch spec {
+	case "EMPTY":
+		return &ContentModel{Type: ContentEmpty}, nil
+	case "ANY":
+		return &ContentModel{Type: ContentAny}, nil
+	}
+
+	s := &specScanner{spec: spec}
+	s.skipWs()
+	if !s.skip('(') {
+		return nil, s.error()
+	}
+	s.skipWs()
+	if strings.HasPrefix(s.spec[s.pos:], "#PCDATA") {
+		s.pos += len("#PCDATA")
+		return s.mixed()
+	}
+	particle, err := s.group()
+	if err != nil {
+		return nil, err
+	}
+	particle.Occurrence = s.occurrence()
+	if s.skipWs(); s.pos != len(s.spec) {
+		return nil, s.error()
+	}
+	return &ContentModel{Type: ContentChildren, Particle: particle}, nil
+}
+
+// Match function returns true if the sequence of child element names
+// conforms to the children content model. For other content types only the
+// names are checked.
+func (m *ContentModel) Match(names []string) bool {
+	switch m.Type {
+	case ContentEmpty:
+		return len(names) == 0
+	case ContentAny:
+		return true
+	case ContentMixed:
+		for _, name := range names {
+			if !m.allows(name) {
+				return false
+			}
+		}
+		return true
+	}
+	starts := make([]bool, len(names)+1)
+	starts[0] = true
+	return m.Particle.match(names, starts)[len(names)]
+}
+
+// allows function returns true if the element may appear in mixed content
+func (m *ContentModel) allows(name string) bool {
+	for _, n := range m.Names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// match function returns positions in names the particle may end at when
+// started at any of the start positions
+func (p *Particle) match(names []string, starts []bool) []bool {
+	switch p.Occurrence {
+	case '?':
+		return union(starts, p.matchOnce(names, starts))
+	case '*':
+		return p.repeat(names, starts)
+	case '+':
+		return p.repeat(names, p.matchOnce(names, starts))
+	default:
+		return p.matchOnce(names, starts)
+	}
+}
+
+// repeat function matches the particle any number of times
+func (p *Particle) repeat(names []string, starts []bool) []bool {
+	ends := append([]bool(nil), starts...)
+	frontier := starts
+	for {
+		next := p.matchOnce(names, frontier)
+		grown := false
+		for i, ok := range next {
+			if ok && !ends[i] {
+				ends[i], grown = true, true
+			} else {
+				next[i] = false
+			}
+		}
+		if !grown {
+			return ends
+		}
+		frontier = next
+	}
+}
+
+// matchOnce function matches the particle ignoring its occurrence indicator
+func (p *Particle) matchOnce(names []string, starts []bool) []bool {
+	if p.Name != "" {
+		ends := make([]bool, len(starts))
+		for i := 0; i < len(names); i++ {
+			if starts[i] && names[i] == p.Name {
+				ends[i+1] = true
+			}
+		}
+		return ends
+	}
+	if p.Choice {
+		ends := make([]bool, len(starts))
+		for _, child := range p.Children {
+			ends = union(ends, child.match(names, starts))
+		}
+		return ends
+	}
+	ends := starts
+	for _, child := range p.Children {
+		ends = child.match(names, ends)
+	}
+	return ends
+}
+
+func union(a, b []bool) []bool {
+	res := make([]bool, len(a))
+	for i := range a {
+		res[i] = a[i] || b[i]
+	}
+	return res
+}
+
+// specScanner reads content specification
+type specScanner struct {
+	spec string
+	pos  int
+}
+
+// mixed function reads mixed content following #PCDATA
+func (s *specScanner) mixed() (*ContentModel, error) {
+	m := &ContentModel{Type: ContentMixed}
+	for {
+		s.skipWs()
+		if s.skip(')') {
+			break
+		}
+		if !s.skip('|') {
+			return nil, s.error()
+		}
+		s.skipWs()
+		name := s.name()
+		if name == "" {
+			return nil, s.error()
+		}
+		m.Names = append(m.Names, name)
+	}
+	// names are allowed only with the * indicator
+	if !s.skip('*') && len(m.Names) > 0 {
+		return nil, s.error()
+	}
+	if s.skipWs(); s.pos != len(s.spec) {
+		return nil, s.error()
+	}
+	return m, nil
+}
+
+// group function reads a sequence or choice following (
+func (s *specScanner) group() (*Particle, error) {
+	group := &Particle{}
+	var separator byte
+	for {
+		s.skipWs()
+		child, err := s.particle()
+		if err != nil {
+			return nil, err
+		}
+		group.Children = append(group.Children, child)
+		s.skipWs()
+		if s.skip(')') {
+			break
+		}
+		if s.pos == len(s.spec) || (s.spec[s.pos] != ',' && s.spec[s.pos] != '|') {
+			return nil, s.error()
+		}
+		// separators cannot be mixed within a group
+		if separator != 0 && s.spec[s.pos] != separator {
+			return nil, s.error()
+		}
+		separator = s.spec[s.pos]
+		s.pos++
+	}
+	group.Choice = separator == '|'
+	return group, nil
+}
+
+// particle function reads name or nested group with occurrence indicator
+func (s *specScanner) particle() (*Particle, error) {
+	var p *Particle
+	if s.skip('(') {
+		group, err := s.group()
+		if err != nil {
+			return nil, err
+		}
+		p = group
+	} else {
+		name := s.name()
+		if name == "" {
+			return nil, s.error()
+		}
+		p = &Particle{Name: name}
+	}
+	p.Occurrence = s.occurrence()
+	return p, nil
+}
+
+func (s *specScanner) occurrence() byte {
+	if s.pos < len(s.spec) {
+		switch ch := s.spec[s.pos]; ch {
+		case '?', '*', '+':
+			s.pos++
+			return ch
+		}
+	}
+	return 0
+}
+
+// name function reads name, or returns empty string if there is none
+func (s *specScanner) name() string {
+	start := s.pos
+	for s.pos < len(s.spec) && !strings.ContainsRune(" \t\r\n()|,?*+\"'#", rune(s.spec[s.pos])) {
+		s.pos++
+	}
+	return s.spec[start:s.pos]
+}
+
+func (s *specScanner) skip(ch byte) bool {
+	if s.pos < len(s.spec) && s.spec[s.pos] == ch {
+		s.pos++
+		return true
+	}
+	return false
+}
+
+func (s *specScanner) skipWs() {
+	for s.pos < len(s.spec) && strings.ContainsRune(" \t\r\n", rune(s.spec[s.pos])) {
+		s.pos++
+	}
+}
+
+func (s *specScanner) error() error {
+	return erroring.NewParseError(fmt.Sprintf("invalid declaration %q at position %d", s.spec, s.pos), "", nil)
+}
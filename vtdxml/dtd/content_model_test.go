@@ -0,0 +1,102 @@
+package dtd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ContentModel_Match(t *testing.T) {
+	testCases := []struct {
+		spec    string
+		names   []string
+		matches bool
+	}{
+		{spec: "EMPTY", names: nil, matches: true},
+		{spec: "EMPTY", names: []string{"a"}, matches: false},
+		{spec: "ANY", names: []string{"a", "b"}, matches: true},
+		{spec: "(#PCDATA)", names: nil, matches: true},
+		{spec: "(#PCDATA)", names: []string{"a"}, matches: false},
+		{spec: "(#PCDATA | a | b)*", names: []string{"b", "a", "b"}, matches: true},
+		{spec: "(#PCDATA|a)*", names: []string{"c"}, matches: false},
+		{spec: "(a, b)", names: []string{"a", "b"}, matches: true},
+		{spec: "(a, b)", names: []string{"b", "a"}, matches: false},
+		{spec: "(a, b)", names: []string{"a"}, matches: false},
+		{spec: "(a, b?)", names: []string{"a"}, matches: true},
+		{spec: "(a | b)+", names: []string{"b", "a", "a"}, matches: true},
+		{spec: "(a | b)+", names: nil, matches: false},
+		{spec: "(a, (b | c)*, d)", names: []string{"a", "d"}, matches: true},
+		{spec: "(a, (b | c)*, d)", names: []string{"a", "c", "b", "c", "d"}, matches: true},
+		{spec: "(a, (b | c)*, d)", names: []string{"a", "c", "d", "d"}, matches: false},
+		{spec: "(a*, a)", names: []string{"a", "a", "a"}, matches: true},
+		{spec: "((a, b)*, c?)*", names: []string{"a", "b", "c", "c", "a", "b"}, matches: true},
+		{spec: "((a, b)*, c?)*", names: []string{"a", "c"}, matches: false},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.spec, func(t *testing.T) {
+			m, err := ParseContentModel(tc.spec)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.matches, m.Match(tc.names))
+		})
+	}
+}
+
+func Test_ParseContentModel_Failed(t *testing.T) {
+	testCases := []string{
+		"",
+		"EMPTY ANY",
+		"a",
+		"(a",
+		"(a, b | c)",
+		"(a,)",
+		"(#PCDATA | a)",
+		"(#PCDATA)+",
+		"(a) b",
+	}
+	for _, spec := range testCases {
+		spec := spec
+		t.Run(spec, func(t *testing.T) {
+			m, err := ParseContentModel(spec)
+			assert.Nil(t, m)
+			assert.NotNil(t, err)
+		})
+	}
+}
+
+func Test_ParseAttlist_Success(t *testing.T) {
+	defs, err := ParseAttlist(`id ID #REQUIRED
+		kind (book | article) "book"
+		lang NMTOKEN #IMPLIED
+		version CDATA #FIXED '1.0'
+		format NOTATION (png|gif) #IMPLIED`)
+	assert.Nil(t, err)
+	assert.Equal(t, []*AttributeDef{
+		{Name: "id", Type: AttrID, Default: DefaultRequired},
+		{Name: "kind", Type: AttrEnumeration, Values: []string{"book", "article"}, Value: "book"},
+		{Name: "lang", Type: AttrNmToken, Default: DefaultImplied},
+		{Name: "version", Type: AttrCdata, Default: DefaultFixed, Value: "1.0"},
+		{Name: "format", Type: AttrNotation, Values: []string{"png", "gif"}, Default: DefaultImplied},
+	}, defs)
+}
+
+func Test_ParseAttlist_Failed(t *testing.T) {
+	testCases := []string{
+		"id",
+		"id NUMBER #IMPLIED",
+		"id ID",
+		"id ID #OPTIONAL",
+		"kind (a | b #IMPLIED",
+		"kind () #IMPLIED",
+		`note CDATA "unterminated`,
+		"format NOTATION png #IMPLIED",
+	}
+	for _, content := range testCases {
+		content := content
+		t.Run(content, func(t *testing.T) {
+			defs, err := ParseAttlist(content)
+			assert.Nil(t, defs)
+			assert.NotNil(t, err)
+		})
+	}
+}
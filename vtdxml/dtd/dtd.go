@@ -0,0 +1,93 @@
+package dtd
+
+type DeclarationType int
+
+const (
+	DeclarationElement DeclarationType = iota
+	DeclarationAttlist
+	DeclarationEntity
+	DeclarationNotation
+)
+
+var declarationTypeNames = map[DeclarationType]string{
+	DeclarationElement:  "ELEMENT",
+	DeclarationAttlist:  "ATTLIST",
+	DeclarationEntity:   "ENTITY",
+	DeclarationNotation: "NOTATION",
+}
+
+// String function returns the keyword of the declaration type
+func (t DeclarationType) String() string {
+	if name, ok := declarationTypeNames[t]; ok {
+		return name
+	}
+	return "UNKNOWN"
+}
+
+// Declaration is a markup declaration of the DTD. Content holds the text
+// following the declared name up to the closing >, with surrounding
+// whitespace trimmed. Offset and Length locate the whole declaration, from
+// <! to >, in the units of VTD token offsets.
+type Declaration struct {
+	Type           DeclarationType
+	Name           string
+	Content        string
+	Offset, Length int
+}
+
+// Dtd holds the document type declaration: the declared document element
+// name, the external identifier and the markup declarations of the
+// internal subset in document order.
+type Dtd struct {
+	Name               string
+	PublicID, SystemID string
+	Declarations       []Declaration
+	entities           map[string]*Entity
+	parameterEntities  map[string]*Entity
+}
+
+// New function creates an empty DTD declaring the document element name
+func New(name string) *Dtd {
+	return &Dtd{
+		Name:              name,
+		entities:          make(map[string]*Entity),
+		parameterEntities: make(map[string]*Entity),
+	}
+}
+
+// AddDeclaration function appends markup declaration to the DTD
+func (d *Dtd) AddDeclaration(decl Declaration) {
+	d.Declarations = append(d.Declarations, decl)
+}
+
+// AddEntity function binds the entity to its name. As in XML 1.0, the first
+// declaration of a name is binding and later ones are ignored, in that case
+// false is returned.
+func (d *Dtd) AddEntity(e *Entity) bool {
+	entities := d.entities
+	if e.Parameter {
+		entities = d.parameterEntities
+	}
+	if _, ok := entities[e.Name]; ok {
+		return false
+	}
+	entities[e.Name] = e
+	return true
+}
+
+// GetEntity function returns general entity declared with the name
+func (d *Dtd) GetEntity(name string) (*Entity, bool) {
+	e, ok := d.entities[name]
+	return e, ok
+}
+
+// GetParameterEntity function returns parameter entity declared with the name
+func (d *Dtd) GetParameterEntity(name string) (*Entity, bool) {
+	e, ok := d.parameterEntities[name]
+	return e, ok
+}
+
+// GetEntityCount function returns the number of general entities declared
+func (d *Dtd) GetEntityCount() int {
+	return len(d.entities)
+}
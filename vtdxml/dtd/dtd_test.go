@@ -0,0 +1,83 @@
+package dtd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Dtd_AddEntity(t *testing.T) {
+	d := New("a")
+	assert.True(t, d.AddEntity(&Entity{Name: "e", Value: "first"}))
+	assert.False(t, d.AddEntity(&Entity{Name: "e", Value: "second"}))
+	assert.True(t, d.AddEntity(&Entity{Name: "e", Parameter: true, Value: "parameter"}))
+
+	e, ok := d.GetEntity("e")
+	assert.True(t, ok)
+	assert.Equal(t, "first", e.Value)
+	e, ok = d.GetParameterEntity("e")
+	assert.True(t, ok)
+	assert.Equal(t, "parameter", e.Value)
+	assert.Equal(t, 1, d.GetEntityCount())
+}
+
+func Test_Dtd_ReplacementText_Success(t *testing.T) {
+	d := New("a")
+	d.AddEntity(&Entity{Name: "company", Value: "Acme &amp; Co"})
+	d.AddEntity(&Entity{Name: "copy", Value: "&#xA9; &company;"})
+	d.AddEntity(&Entity{Name: "escaped", Value: "&#38;"})
+	d.AddEntity(&Entity{Name: "empty"})
+
+	testCases := []struct {
+		name         string
+		expectedText string
+	}{
+		{name: "company", expectedText: "Acme & Co"},
+		{name: "copy", expectedText: "© Acme & Co"},
+		{name: "escaped", expectedText: "&"},
+		{name: "empty", expectedText: ""},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			text, err := d.ReplacementText(tc.name)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectedText, text)
+		})
+	}
+}
+
+func Test_Dtd_ReplacementText_Failed(t *testing.T) {
+	d := New("a")
+	d.AddEntity(&Entity{Name: "self", Value: "a&self;"})
+	d.AddEntity(&Entity{Name: "undeclared", Value: "&missing;"})
+	d.AddEntity(&Entity{Name: "unterminated", Value: "&amp"})
+	d.AddEntity(&Entity{Name: "charRef", Value: "&#xZZ;"})
+	d.AddEntity(&Entity{Name: "external", SystemID: "e.xml"})
+	d.AddEntity(&Entity{Name: "unparsed", SystemID: "e.png", Notation: "png"})
+
+	testCases := []struct {
+		name           string
+		expectedErrMsg string
+	}{
+		{name: "self", expectedErrMsg: "unknown character encoding: recursive reference to entity self"},
+		{name: "undeclared", expectedErrMsg: "unknown character encoding: undeclared entity missing"},
+		{name: "unterminated", expectedErrMsg: "unknown character encoding: unterminated reference in entity unterminated"},
+		{name: "charRef", expectedErrMsg: "unknown character encoding: illegal character reference &#xZZ;"},
+		{name: "external", expectedErrMsg: "unknown character encoding: external entity external is not resolved"},
+		{name: "unparsed", expectedErrMsg: "unknown character encoding: reference to unparsed entity unparsed"},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			text, err := d.ReplacementText(tc.name)
+			assert.Equal(t, "", text)
+			assert.EqualError(t, err, tc.expectedErrMsg)
+		})
+	}
+}
+
+func Test_DeclarationType_String(t *testing.T) {
+	assert.Equal(t, "ATTLIST", DeclarationAttlist.String())
+	assert.Equal(t, "UNKNOWN", DeclarationType(42).String())
+}
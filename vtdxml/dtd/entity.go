@@ -0,0 +1,152 @@
+package dtd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/erroring"
+)
+
+var builtInEntities = map[string]rune{
+	"amp":  '&',
+	"apos": '\'',
+	"gt":   '>',
+	"lt":   '<',
+	"quot": '"',
+}
+
+// Entity is an entity declaration. Value of an internal entity is its
+// literal value with character references replaced, general entity
+// references are kept and resolved when the entity is expanded. External
+// entities have SystemID set, unparsed ones also name their Notation.
+type Entity struct {
+	Name               string
+	Parameter          bool
+	Value              string
+	PublicID, SystemID string
+	Notation           string
+}
+
+// IsExternal function returns true if the entity refers to an external resource
+func (e *Entity) IsExternal() bool {
+	return e.SystemID != ""
+}
+
+// IsUnparsed function returns true if the entity is an unparsed external entity
+func (e *Entity) IsUnparsed() bool {
+	return e.Notation != ""
+}
+
+// BuiltInEntity function returns the character predefined entity stands for
+func BuiltInEntity(name string) (rune, bool) {
+	ch, ok := builtInEntities[name]
+	return ch, ok
+}
+
+// ReplacementText function returns the text general entity expands to.
+// Character, built-in and nested general entity references are resolved.
+// External and unparsed entities are never expanded.
+func (d *Dtd) ReplacementText(name string) (string, error) {
+	return d.ExpandEntity(name, 0, 0)
+}
+
+// ExpandEntity function works like ReplacementText, but fails with
+// EntityExpansionLimitError as soon as the expanded text, together with the
+// number of bytes produced by earlier expansions, exceeds the limit. Limit
+// of zero disables the check.
+func (d *Dtd) ExpandEntity(name string, expanded, limit int) (string, error) {
+	x := expansion{dtd: d, expanded: expanded, limit: limit}
+	if err := x.expand(name); err != nil {
+		return "", err
+	}
+	return x.sb.String(), nil
+}
+
+// expansion holds the state of an entity being expanded. Stack holds
+// entities being expanded and detects recursive references.
+type expansion struct {
+	dtd             *Dtd
+	sb              strings.Builder
+	stack           []string
+	expanded, limit int
+}
+
+// expand function writes replacement text of the entity into the builder
+func (x *expansion) expand(name string) error {
+	e, ok := x.dtd.entities[name]
+	if !ok {
+		return erroring.NewEntityError(fmt.Sprintf("undeclared entity %s", name))
+	}
+	if e.IsUnparsed() {
+		return erroring.NewEntityError(fmt.Sprintf("reference to unparsed entity %s", name))
+	}
+	if e.IsExternal() {
+		return erroring.NewEntityError(fmt.Sprintf("external entity %s is not resolved", name))
+	}
+	for _, s := range x.stack {
+		if s == name {
+			return erroring.NewEntityError(fmt.Sprintf("recursive reference to entity %s", name))
+		}
+	}
+	x.stack = append(x.stack, name)
+	defer func() {
+		x.stack = x.stack[:len(x.stack)-1]
+	}()
+
+	text := e.Value
+	for {
+		amp := strings.IndexByte(text, '&')
+		if amp < 0 {
+			return x.write(text)
+		}
+		if err := x.write(text[:amp]); err != nil {
+			return err
+		}
+		semi := strings.IndexByte(text[amp:], ';')
+		if semi < 0 {
+			return erroring.NewEntityError(fmt.Sprintf("unterminated reference in entity %s", name))
+		}
+		ref := text[amp+1 : amp+semi]
+		text = text[amp+semi+1:]
+
+		if strings.HasPrefix(ref, "#") {
+			ch, err := characterReference(ref[1:])
+			if err != nil {
+				return err
+			}
+			if err := x.write(string(ch)); err != nil {
+				return err
+			}
+		} else if ch, ok := BuiltInEntity(ref); ok {
+			if err := x.write(string(ch)); err != nil {
+				return err
+			}
+		} else if err := x.expand(ref); err != nil {
+			return err
+		}
+	}
+}
+
+// write function appends text to the expansion checking the limit
+func (x *expansion) write(text string) error {
+	if x.limit > 0 && x.expanded+x.sb.Len()+len(text) > x.limit {
+		return erroring.NewEntityExpansionLimitError(x.limit)
+	}
+	x.sb.WriteString(text)
+	return nil
+}
+
+// characterReference function returns the character decimal or x-prefixed
+// hexadecimal character reference stands for
+func characterReference(ref string) (rune, error) {
+	digits, base := ref, 10
+	if strings.HasPrefix(ref, "x") {
+		digits, base = ref[1:], 16
+	}
+	value, err := strconv.ParseUint(digits, base, 32)
+	if err != nil || value > 0x10FFFF {
+		return 0, erroring.NewEntityError(fmt.Sprintf("illegal character reference &#%s;", ref))
+	}
+	return rune(value), nil
+}
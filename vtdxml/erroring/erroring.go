@@ -14,12 +14,18 @@ var NavigationErrorType = &NavigationError{}
 
 var EncodingErrorType = &EncodingError{}
 
+var UnsupportedEncodingErrorType = &UnsupportedEncodingError{}
+
 var DecodingErrorType = &DecodingError{}
 
 var EntityErrorType = &EntityError{}
 
 var EOFErrorType = &EOFError{}
 
+var SchemaErrorType = &SchemaError{}
+
+var IndexErrorType = &IndexError{}
+
 // baseError represents a generic error from the domain package that provides
 // 'error' functionality to the rest of the typed errors in the package.
 type baseError struct {
@@ -97,11 +103,18 @@ func NewInternalError(msg string, err error) *InternalError {
 }
 
 // ParseError represent a some sort of parsing error that may occur trying to
-// convert non-numeric string to a number.
+// convert non-numeric string to a number. Errors raised by the parser are
+// located in the document: Line and Column are 1-based, Column counts
+// characters, Offset is the byte offset and Snippet holds the characters
+// around it. State is the parser state the error occurred in.
 type ParseError struct {
 	baseError
-	Msg        string
-	LineNumber string
+	Msg          string
+	LineNumber   string
+	Line, Column int
+	Offset       int
+	State        fmt.Stringer
+	Snippet      string
 }
 
 // NewParseError constructs a new ParseError, wrapping the provided error.
@@ -152,6 +165,25 @@ func NewEncodingError(msg string) *EncodingError {
 	}
 }
 
+// UnsupportedEncodingError represents an encoding declared by the document
+// that the parser cannot read
+type UnsupportedEncodingError struct {
+	baseError
+	Encoding string
+}
+
+// NewUnsupportedEncodingError constructs a new UnsupportedEncodingError for
+// the declared encoding name.
+func NewUnsupportedEncodingError(encoding string) *UnsupportedEncodingError {
+	return &UnsupportedEncodingError{
+		baseError: newBaseError(
+			fmt.Sprintf("unsupported encoding: %s", encoding),
+			nil,
+		),
+		Encoding: encoding,
+	}
+}
+
 // DecodingError represents some sort of character decoding error that may occur
 // during decoding invalid character of a specific format
 type DecodingError struct {
@@ -221,3 +253,37 @@ func NewModifyError(msg string) *ModifyError {
 		Msg: msg,
 	}
 }
+
+// SchemaError represents an XML schema that cannot be loaded or compiled
+type SchemaError struct {
+	baseError
+	Msg string
+}
+
+// NewSchemaError constructs a new SchemaError, wrapping the provided error.
+func NewSchemaError(msg string, err error) *SchemaError {
+	return &SchemaError{
+		baseError: newBaseError(
+			fmt.Sprintf("a schema error occurred: %s", msg),
+			err,
+		),
+		Msg: msg,
+	}
+}
+
+// IndexError represents a persisted VTD index that cannot be loaded
+type IndexError struct {
+	baseError
+	Msg string
+}
+
+// NewIndexError constructs a new IndexError, wrapping the provided error.
+func NewIndexError(msg string, err error) *IndexError {
+	return &IndexError{
+		baseError: newBaseError(
+			fmt.Sprintf("an index error occurred: %s", msg),
+			err,
+		),
+		Msg: msg,
+	}
+}
@@ -0,0 +1,96 @@
+package erroring
+
+import (
+	"fmt"
+)
+
+var DepthLimitErrorType = &DepthLimitError{}
+
+var AttributeLimitErrorType = &AttributeLimitError{}
+
+var AttrValueLengthLimitErrorType = &AttrValueLengthLimitError{}
+
+var TextTokenLimitErrorType = &TextTokenLimitError{}
+
+var TokenLimitErrorType = &TokenLimitError{}
+
+var EntityExpansionLimitErrorType = &EntityExpansionLimitError{}
+
+// LimitError represents a parser limit exceeded by the document. It is
+// embedded by the error of each limit, so the limit can be told by type.
+type LimitError struct {
+	baseError
+	Limit int
+}
+
+func newLimitError(msg string, limit int) LimitError {
+	return LimitError{
+		baseError: newBaseError(
+			fmt.Sprintf("a limit was exceeded: %s", fmt.Sprintf(msg, limit)),
+			nil,
+		),
+		Limit: limit,
+	}
+}
+
+// DepthLimitError represents element nesting deeper than allowed
+type DepthLimitError struct {
+	LimitError
+}
+
+// NewDepthLimitError constructs a new DepthLimitError for the limit.
+func NewDepthLimitError(limit int) *DepthLimitError {
+	return &DepthLimitError{newLimitError("element depth exceeds the limit of %d", limit)}
+}
+
+// AttributeLimitError represents an element with more attributes than allowed
+type AttributeLimitError struct {
+	LimitError
+}
+
+// NewAttributeLimitError constructs a new AttributeLimitError for the limit.
+func NewAttributeLimitError(limit int) *AttributeLimitError {
+	return &AttributeLimitError{newLimitError("number of attributes exceeds the limit of %d", limit)}
+}
+
+// AttrValueLengthLimitError represents an attribute value longer than allowed
+type AttrValueLengthLimitError struct {
+	LimitError
+}
+
+// NewAttrValueLengthLimitError constructs a new AttrValueLengthLimitError for the limit.
+func NewAttrValueLengthLimitError(limit int) *AttrValueLengthLimitError {
+	return &AttrValueLengthLimitError{newLimitError("attribute value length exceeds the limit of %d", limit)}
+}
+
+// TextTokenLimitError represents a document with more text tokens than allowed
+type TextTokenLimitError struct {
+	LimitError
+}
+
+// NewTextTokenLimitError constructs a new TextTokenLimitError for the limit.
+func NewTextTokenLimitError(limit int) *TextTokenLimitError {
+	return &TextTokenLimitError{newLimitError("number of text tokens exceeds the limit of %d", limit)}
+}
+
+// TokenLimitError represents a document with more tokens than allowed
+type TokenLimitError struct {
+	LimitError
+}
+
+// NewTokenLimitError constructs a new TokenLimitError for the limit.
+func NewTokenLimitError(limit int) *TokenLimitError {
+	return &TokenLimitError{newLimitError("number of tokens exceeds the limit of %d", limit)}
+}
+
+// EntityExpansionLimitError represents general entity references expanding
+// to more text than allowed
+type EntityExpansionLimitError struct {
+	LimitError
+}
+
+// NewEntityExpansionLimitError constructs a new EntityExpansionLimitError for
+// the limit given in bytes.
+func NewEntityExpansionLimitError(limit int) *EntityExpansionLimitError {
+	return &EntityExpansionLimitError{newLimitError("entity expansion exceeds the limit of %d bytes", limit)}
+}
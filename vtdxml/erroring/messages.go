@@ -16,4 +16,14 @@ const (
 	AttrNsPrefixQnameTooLong   = "attribute namespace tag prefix or QNAME length too long"
 	NonDefaultNsEmpty          = "non-default namespace cannot be empty"
 	AttrValueTooLong           = "attribute value is too long"
+	DocumentNotParsed          = "document has not been parsed"
+	DocumentTooLarge           = "document exceeds maximum size"
+	CannotBeNegative           = "cannot be negative"
+	InvalidQName               = "name is not a valid QName"
+	NsPrefixNotDeclared        = "namespace prefix not declared"
+	NsPrefixReserved           = "reserved namespace prefix"
+	NsUrlReserved              = "reserved namespace name"
+	NsAttrNotUnique            = "attribute expanded name not unique"
+	NsColonInPiTarget          = "processing instruction target cannot contain colon"
+	ElementNotClosed           = "element not closed"
 )
@@ -1,8 +1,11 @@
 package navigation
 
 import (
+	"math"
+
 	"github.com/alexZaicev/go-vtd-xml/vtdxml/common"
 	"github.com/alexZaicev/go-vtd-xml/vtdxml/erroring"
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/reader"
 )
 
 type Direction int
@@ -24,6 +27,9 @@ func (n *VtdNav) GetCurrentIndex() (int32, error) {
 	case -1:
 		return 0, nil
 	case 0:
+		if n.l0Buffer != nil {
+			return n.l0Buffer.Upper32At(n.l0index)
+		}
 		return n.rootIndex, nil
 	}
 	idx := int(n.context[0])
@@ -42,12 +48,68 @@ func (n *VtdNav) GetTokenType(index int) (int32, error) {
 	return int32(res) & 0xF, nil
 }
 
+// GetTokenOffset function returns offset of the token. Offsets of documents
+// parsed in extended VTD mode may not fit int32, use GetTokenOffset64 for them.
 func (n *VtdNav) GetTokenOffset(index int) (int32, error) {
+	offset, err := n.tokenOffset(index)
+	if err != nil {
+		return 0, err
+	}
+	if offset > math.MaxInt32 {
+		return 0, erroring.NewInternalError("token offset does not fit int32", nil)
+	}
+	return int32(offset), nil
+}
+
+// GetTokenOffset64 function returns offset of the token in both standard and
+// extended VTD mode
+func (n *VtdNav) GetTokenOffset64(index int) (int64, error) {
+	offset, err := n.tokenOffset(index)
+	if err != nil {
+		return 0, err
+	}
+	return int64(offset), nil
+}
+
+// tokenOffset function returns offset of the token from the offset buffer in
+// extended VTD mode, from the VTD record otherwise
+func (n *VtdNav) tokenOffset(index int) (int, error) {
+	if n.offsetBuffer != nil {
+		val, err := n.offsetBuffer.LongAt(index)
+		if err != nil {
+			return 0, err
+		}
+		return int(val), nil
+	}
 	val, err := n.vtdBuffer.LongAt(index)
 	if err != nil {
 		return 0, err
 	}
-	return int32(uint64(val) & common.MaskTokenOffset), nil
+	return int(uint64(val) & common.MaskTokenOffset), nil
+}
+
+// GetTokenPosition function returns 1-based line and column numbers of the
+// token start. Columns count characters. Line starts are indexed on the first
+// call, so navigation over documents that never ask for positions costs
+// nothing extra.
+func (n *VtdNav) GetTokenPosition(index int) (int, int, error) {
+	offset, err := n.tokenOffset(index)
+	if err != nil {
+		return 0, 0, err
+	}
+	if n.encoding == common.FormatUtf16BE || n.encoding == common.FormatUtf16LE {
+		offset <<= 1
+	}
+	if offset < n.offset {
+		offset = n.offset
+	}
+	if n.lineIndex == nil && n.xml11 {
+		n.lineIndex = reader.NewXml11LineIndex(n.xmlBuffer.GetBytes(), n.offset, n.offset+n.length, n.encoding)
+	} else if n.lineIndex == nil {
+		n.lineIndex = reader.NewLineIndex(n.xmlBuffer.GetBytes(), n.offset, n.offset+n.length, n.encoding)
+	}
+	line, column := n.lineIndex.Position(offset)
+	return line, column, nil
 }
 
 func (n *VtdNav) GetTokenDepth(index int) (int32, error) {
@@ -107,8 +169,7 @@ func (n *VtdNav) GetTokenLength(index int) (int32, error) {
 				}
 				length += (uint64(val) & common.MaskTokenFullLength) >> 32
 
-				tokenOffsetNew, forErr = n.GetTokenOffset(i)
-				if forErr != nil {
+				if _, forErr = n.tokenOffset(i); forErr != nil {
 					return 0, forErr
 				}
 				tokenOffsetNew = int32((uint64(val) & common.MaskTokenFullLength) >> 32)
@@ -220,6 +220,65 @@ func Test_VtdNav_GetTokenOffset_Error(t *testing.T) {
 	}
 }
 
+// getExtendedNav function returns navigation object whose offset buffer
+// holds the offsets of VTD records, except for the first one
+func getExtendedNav(t *testing.T, firstOffset int64) *VtdNav {
+	nav := getNav(t)
+	offsetBuffer, err := buffer.NewFastLongBuffer()
+	assert.Nil(t, err)
+	for i := 0; i < nav.GetVtdBufferSize(); i++ {
+		offset, err := nav.GetTokenOffset(i)
+		assert.Nil(t, err)
+		if i == 0 {
+			assert.Nil(t, offsetBuffer.Append(firstOffset))
+			continue
+		}
+		assert.Nil(t, offsetBuffer.Append(int64(offset)))
+	}
+	assert.Nil(t, nav.SetOffsetBuffer(offsetBuffer))
+	return nav
+}
+
+func Test_VtdNav_GetTokenOffset_Extended(t *testing.T) {
+	nav := getExtendedNav(t, 1<<33)
+	assert.True(t, nav.IsExtended())
+
+	offset, err := nav.GetTokenOffset64(0)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1<<33), offset)
+	_, err = nav.GetTokenOffset(0)
+	assert.EqualError(t, err, "an internal error occurred: token offset does not fit int32")
+
+	offset, err = nav.GetTokenOffset64(39)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1203), offset)
+}
+
+func Test_VtdNav_SetOffsetBuffer_Error(t *testing.T) {
+	nav := getNav(t)
+	assert.EqualError(t, nav.SetOffsetBuffer(nil), "invalid argument offsetBuffer: cannot be nil")
+
+	offsetBuffer, err := buffer.NewFastLongBuffer()
+	assert.Nil(t, err)
+	assert.Nil(t, offsetBuffer.Append(0))
+	assert.EqualError(t, nav.SetOffsetBuffer(offsetBuffer), "invalid argument offsetBuffer: invalid slice length")
+	assert.False(t, nav.IsExtended())
+}
+
+func Test_VtdNav_SetDeepLcBuffers_Error(t *testing.T) {
+	nav := getNav(t)
+	lc, err := buffer.NewFastLongBuffer()
+	assert.Nil(t, err)
+	assert.EqualError(t, nav.SetDeepLcBuffers(nil, lc), "invalid argument l4Buffer: cannot be nil")
+	assert.EqualError(t, nav.SetDeepLcBuffers(lc, nil), "invalid argument l5Buffer: cannot be nil")
+}
+
+func Test_VtdNav_SetFragmentBuffer_Error(t *testing.T) {
+	nav := getNav(t)
+	assert.EqualError(t, nav.SetFragmentBuffer(nil), "invalid argument l0Buffer: cannot be nil")
+	assert.False(t, nav.IsFragment())
+}
+
 func Test_VtdNav_GetTokenDepth_Success(t *testing.T) {
 	nav := getNav(t)
 
@@ -412,7 +471,7 @@ func getNav(t *testing.T) *VtdNav {
 	assert.Nil(t, err)
 	assert.NotNil(t, l3Buffer)
 
-	nav, err := NewVtdNav(7, 0, int32(len(g)), 7,
+	nav, err := NewVtdNav(7, 0, len(g), 7,
 		common.FormatUtf8, true, g,
 		vtdBuffer, l1Buffer, l2Buffer, l3Buffer)
 
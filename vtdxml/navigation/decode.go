@@ -1,6 +1,13 @@
 package navigation
 
+import "github.com/alexZaicev/go-vtd-xml/vtdxml/reader"
+
+// decode function returns Unicode code point of the byte at the offset in
+// documents encoded with a single-byte code page
 func (n *VtdNav) decode(offset int) (uint32, error) {
-	// TODO implement
-	return 0, nil
+	b, err := n.xmlBuffer.ByteAt(offset)
+	if err != nil {
+		return 0, err
+	}
+	return reader.DecodeSingleByte(n.encoding, b)
 }
@@ -1,32 +1,89 @@
 package navigation
 
 import (
+	"strings"
+	"unicode/utf8"
+
 	"github.com/alexZaicev/go-vtd-xml/vtdxml/common"
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/dtd"
 	"github.com/alexZaicev/go-vtd-xml/vtdxml/erroring"
 )
 
-func (n *VtdNav) getCharResolved(offset int) (uint32, error) {
+// getCharResolved function returns character at the offset with built-in and
+// character entity references resolved. Upper 32 bits of the result hold the
+// number of units consumed
+func (n *VtdNav) getCharResolved(offset int) (uint64, error) {
 	ch, err := n.getChar(offset)
 	if err != nil {
 		return 0, err
 	}
-	if ch != '&' {
-		return uint32(ch), nil
+	if uint32(ch) != '&' {
+		return ch, nil
 	}
-	offset++
-	ch2, err := n.getCharUnit(offset)
+	return n.resolveEntity(offset + 1)
+}
+
+// resolveReference function resolves reference starting right after the &
+// character into its replacement text. Besides built-in and character
+// references, general entities declared in the DTD are expanded. Returns
+// the text and the length of the whole reference including & and ;
+// characters
+func (n *VtdNav) resolveReference(offset int) (string, int, error) {
+	ch, err := n.getCharUnit(offset)
 	if err != nil {
-		return 0, err
+		return "", 0, err
 	}
-	offset++
-	inc, val, err := n.resolveEntity(offset, ch2)
+	if ch != '#' {
+		name, inc, err := n.entityName(offset)
+		if err != nil {
+			return "", 0, err
+		}
+		if _, ok := dtd.BuiltInEntity(name); !ok {
+			if n.dtd == nil {
+				return "", 0, erroring.NewEntityError(erroring.IllegalBuiltInEntity)
+			}
+			text, err := n.dtd.ExpandEntity(name, 0, n.entityExpansionLimit)
+			if err != nil {
+				return "", 0, err
+			}
+			return text, inc, nil
+		}
+	}
+	ch64, err := n.resolveEntity(offset)
 	if err != nil {
-		return 0, err
+		return "", 0, err
+	}
+	return string(rune(uint32(ch64))), int(ch64 >> 32), nil
+}
+
+// entityName function reads name of the entity reference starting at the
+// offset. Returns the name and the length of the whole reference including &
+// and ; characters
+func (n *VtdNav) entityName(offset int) (string, int, error) {
+	var sb strings.Builder
+	// & character
+	inc := 1
+	for {
+		ch, err := n.getChar(offset)
+		if err != nil {
+			return "", 0, err
+		}
+		if uint32(ch) == ';' {
+			return sb.String(), inc + 1, nil
+		}
+		if !n.xmlChar.IsNameChar(uint32(ch)) {
+			return "", 0, erroring.NewEntityError("illegal entity character")
+		}
+		sb.WriteRune(rune(uint32(ch)))
+		offset += int(ch >> 32)
+		inc += int(ch >> 32)
 	}
-	return val | uint32(inc<<32), nil
 }
 
-func (n *VtdNav) resolveEntity(offset int, ch uint32) (uint64, uint32, error) {
+// resolveEntity function resolves entity reference starting right after the
+// & character. Upper 32 bits of the result hold the length of the whole
+// reference including & and ; characters
+func (n *VtdNav) resolveEntity(offset int) (uint64, error) {
 	checkSeq := func(os int, seq string) error {
 		for i, seqCh := range seq {
 			ch, err := n.getCharUnit(os + i)
@@ -39,23 +96,28 @@ func (n *VtdNav) resolveEntity(offset int, ch uint32) (uint64, uint32, error) {
 		}
 		return nil
 	}
-	var inc uint64
+	ch, err := n.getCharUnit(offset)
+	if err != nil {
+		return 0, err
+	}
 	switch ch {
 	case '#':
 		{
 			var value uint32
+			// & and # characters
+			inc := uint64(2)
+			offset++
 			ch2, err := n.getCharUnit(offset)
 			if err != nil {
-				return 0, 0, err
+				return 0, err
 			}
-			inc = 2
 			if ch2 == 'x' {
+				offset++
+				inc++
 				for {
-					offset++
-					inc++
 					ch2, err = n.getCharUnit(offset)
 					if err != nil {
-						return 0, 0, err
+						return 0, err
 					}
 					if ch2 >= '0' && ch2 <= '9' {
 						value = (value << 4) + (ch2 - '0')
@@ -66,8 +128,10 @@ func (n *VtdNav) resolveEntity(offset int, ch uint32) (uint64, uint32, error) {
 					} else if ch2 == ';' {
 						break
 					} else {
-						return 0, 0, erroring.NewEntityError("illegal char following &#x")
+						return 0, erroring.NewEntityError("illegal char following &#x")
 					}
+					offset++
+					inc++
 				}
 			} else {
 				for {
@@ -76,64 +140,66 @@ func (n *VtdNav) resolveEntity(offset int, ch uint32) (uint64, uint32, error) {
 					} else if ch2 == ';' {
 						break
 					} else {
-						return 0, 0, erroring.NewEntityError("illegal char following &#x")
+						return 0, erroring.NewEntityError("illegal char following &#")
 					}
+					offset++
+					inc++
 					ch2, err = n.getCharUnit(offset)
 					if err != nil {
-						return 0, 0, err
+						return 0, err
 					}
 				}
 			}
-			if !n.xmlChar.IsValidChar(value) {
-				return 0, 0, erroring.NewEntityError(erroring.InvalidChar)
+			if !n.xmlChar.IsReferenceChar(value) {
+				return 0, erroring.NewEntityError(erroring.InvalidChar)
 			}
-			return inc, value, nil
+			// ; character
+			inc++
+			return uint64(value) | inc<<32, nil
 		}
 	case 'a':
 		{
-			// checks that the sequence matcher &amp;
-			ch2, err := n.getCharUnit(offset)
+			ch2, err := n.getCharUnit(offset + 1)
 			if err != nil {
-				return 0, 0, err
+				return 0, err
 			}
 			if ch2 == 'm' {
 				// checks that the sequence matcher &amp;
-				if err := checkSeq(offset, "p;"); err != nil {
-					return 0, 0, err
+				if err := checkSeq(offset+2, "p;"); err != nil {
+					return 0, err
 				}
-				return 5, '&', nil
+				return '&' | 5<<32, nil
 			} else if ch2 == 'p' {
 				// checks that the sequence matcher &apos;
-				if err := checkSeq(offset, "os;"); err != nil {
-					return 0, 0, err
+				if err := checkSeq(offset+2, "os;"); err != nil {
+					return 0, err
 				}
-				return 6, '\'', nil
+				return '\'' | 6<<32, nil
 			} else {
-				return 0, 0, erroring.NewEntityError(erroring.IllegalBuiltInEntity)
+				return 0, erroring.NewEntityError(erroring.IllegalBuiltInEntity)
 			}
 		}
 	case 'q':
 		{
 			// checks that the sequence matcher &quot;
-			if err := checkSeq(offset, "uot;"); err != nil {
-				return 0, 0, err
+			if err := checkSeq(offset+1, "uot;"); err != nil {
+				return 0, err
 			}
-			return 6, '"', nil
+			return '"' | 6<<32, nil
 		}
 	case 'l', 'g':
 		{
 			// checks that the sequence matcher &gt; or &lt;
-			if err := checkSeq(offset, "t;"); err != nil {
-				return 0, 0, err
+			if err := checkSeq(offset+1, "t;"); err != nil {
+				return 0, err
 			}
-			val := '>'
 			if ch == 'l' {
-				val = '<'
+				return '<' | 4<<32, nil
 			}
-			return 4, uint32(val), nil
+			return '>' | 4<<32, nil
 		}
 	default:
-		return 0, 0, erroring.NewEntityError("illegal entity character")
+		return 0, erroring.NewEntityError("illegal entity character")
 	}
 }
 
@@ -148,8 +214,7 @@ func (n *VtdNav) getCharUnit(offset int) (uint32, error) {
 		return uint32(b & 0xFF), nil
 	}
 
-	if (n.encoding > common.FormatIso88591 && n.encoding < common.FormatIso885916) ||
-		(n.encoding >= common.FormatWin1250 && n.encoding <= common.FormatWin1258) {
+	if !n.encoding.IsUtf16() {
 		return n.decode(offset)
 	}
 
@@ -176,7 +241,38 @@ func (n *VtdNav) getCharUnit(offset int) (uint32, error) {
 	}
 }
 
+// getChar function returns character at the offset with line ends normalized
+// to LF. Upper 32 bits of the result hold the number of units consumed.
 func (n *VtdNav) getChar(offset int) (uint64, error) {
+	ch, err := n.getXml10Char(offset)
+	if err != nil {
+		return 0, err
+	}
+	if ch>>32 == 0 {
+		// callers advance by the length, zero would never end their loops
+		return 0, erroring.NewDecodingError("character decoded with zero length")
+	}
+	if !n.xml11 {
+		return ch, nil
+	}
+	switch uint32(ch) {
+	case 0x85, 0x2028:
+		return '\n' | ch>>32<<32, nil
+	case '\n':
+		// CR followed by NEL is a single line end
+		if unit, err := n.getCharUnit(offset); err != nil || unit != '\r' || ch>>32 != 1 {
+			return ch, nil
+		}
+		if next, err := n.getXml10Char(offset + 1); err == nil && uint32(next) == 0x85 {
+			return '\n' | (1+next>>32)<<32, nil
+		}
+	}
+	return ch, nil
+}
+
+// getXml10Char function returns character at the offset with XML 1.0 line
+// ends, CR LF and CR, normalized to LF
+func (n *VtdNav) getXml10Char(offset int) (uint64, error) {
 	b, err := n.xmlBuffer.ByteAt(offset)
 	if err != nil {
 		return 0, err
@@ -187,7 +283,8 @@ func (n *VtdNav) getChar(offset int) (uint64, error) {
 			if b == '\r' {
 				b2, ifErr := n.xmlBuffer.ByteAt(offset + 1)
 				if ifErr != nil {
-					return 0, nil
+					// CR ends the document
+					return uint64(int('\n') | (1 << 32)), nil
 				}
 				if b2 == '\n' {
 					return uint64(int(b2) | (2 << 32)), nil
@@ -203,7 +300,8 @@ func (n *VtdNav) getChar(offset int) (uint64, error) {
 			if b == '\r' {
 				b2, ifErr := n.xmlBuffer.ByteAt(offset + 1)
 				if ifErr != nil {
-					return 0, nil
+					// CR ends the document
+					return uint64(int('\n') | (1 << 32)), nil
 				}
 				if b2 == '\n' {
 					return uint64(int(b2) | (2 << 32)), nil
@@ -219,7 +317,8 @@ func (n *VtdNav) getChar(offset int) (uint64, error) {
 			if b == '\r' {
 				b2, ifErr := n.xmlBuffer.ByteAt(offset + 1)
 				if ifErr != nil {
-					return 0, nil
+					// CR ends the document
+					return uint64(int('\n') | (1 << 32)), nil
 				}
 				if b2 == '\n' {
 					return uint64(int(b2) | (2 << 32)), nil
@@ -227,6 +326,13 @@ func (n *VtdNav) getChar(offset int) (uint64, error) {
 					return uint64(int('\n') | (1 << 32)), nil
 				}
 			}
+			if b >= utf8.RuneSelf {
+				ch, size := utf8.DecodeRune(n.xmlBuffer.GetBytes()[offset:])
+				if ch == utf8.RuneError && size < 2 {
+					return 0, erroring.NewDecodingError("invalid UTF-8 sequence")
+				}
+				return uint64(ch) | uint64(size)<<32, nil
+			}
 
 			return uint64(int(b) | (1 << 32)), nil
 		}
@@ -240,19 +346,77 @@ func (n *VtdNav) getChar(offset int) (uint64, error) {
 }
 
 func (n *VtdNav) getChar4OtherEncodings(offset int) (uint64, error) {
-	if n.encoding > common.FormatWin1258 {
+	if n.encoding.IsUtf16() {
 		return 0, erroring.NewEncodingError("unknown encoding")
 	}
-	// TODO implement
-	return 0, nil
+	b, err := n.xmlBuffer.ByteAt(offset)
+	if err != nil {
+		return 0, err
+	}
+	if b == '\r' {
+		if b2, err := n.xmlBuffer.ByteAt(offset + 1); err == nil && b2 == '\n' {
+			return '\n' | 2<<32, nil
+		}
+		return '\n' | 1<<32, nil
+	}
+	ch, err := n.decode(offset)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(ch) | 1<<32, nil
 }
 
 func (n *VtdNav) getCharUtf16BE(offset int) (uint64, error) {
-	// TODO implement
-	return 0, nil
+	return n.getCharUtf16(offset, func(hi, lo byte) uint32 {
+		return uint32(hi)<<8 | uint32(lo)
+	})
 }
 
 func (n *VtdNav) getCharUtf16LE(offset int) (uint64, error) {
-	// TODO implement
-	return 0, nil
+	return n.getCharUtf16(offset, func(hi, lo byte) uint32 {
+		return uint32(lo)<<8 | uint32(hi)
+	})
+}
+
+// getCharUtf16 function decodes UTF-16 character at the offset given in
+// 16-bit units. Byte order is defined by the unit function that receives
+// bytes in document order. Upper 32 bits of the result hold the number of
+// units consumed
+func (n *VtdNav) getCharUtf16(offset int, unit func(b1, b2 byte) uint32) (uint64, error) {
+	unitAt := func(os int) (uint32, error) {
+		b1, err := n.xmlBuffer.ByteAt(os << 1)
+		if err != nil {
+			return 0, err
+		}
+		b2, err := n.xmlBuffer.ByteAt((os << 1) + 1)
+		if err != nil {
+			return 0, err
+		}
+		return unit(b1, b2), nil
+	}
+
+	ch, err := unitAt(offset)
+	if err != nil {
+		return 0, err
+	}
+	if ch < 0xD800 || ch > 0xDFFF {
+		if ch == '\r' {
+			if next, err := unitAt(offset + 1); err == nil && next == '\n' {
+				return '\n' | 2<<32, nil
+			}
+			return '\n' | 1<<32, nil
+		}
+		return uint64(ch) | 1<<32, nil
+	}
+	if ch > 0xDBFF {
+		return 0, erroring.NewDecodingError("invalid UTF-16 high surrogate")
+	}
+	low, err := unitAt(offset + 1)
+	if err != nil {
+		return 0, err
+	}
+	if low < 0xDC00 || low > 0xDFFF {
+		return 0, erroring.NewDecodingError("invalid UTF-16 low surrogate")
+	}
+	return uint64(((ch-0xD800)<<10)+(low-0xDC00)+0x10000) | 2<<32, nil
 }
@@ -0,0 +1,268 @@
+package navigation
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/buffer"
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/common"
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/erroring"
+)
+
+const (
+	// IndexVersion is the version of the persisted index format written by
+	// WriteIndex. It changes with every change of the layout, LoadIndex
+	// rejects indexes of other versions.
+	IndexVersion = 2
+
+	indexMagic    = "VTDX"
+	indexNsAware  = 1
+	indexExtended = 2
+	indexFragment = 4
+	indexXml11    = 8
+	indexNsUrls   = 16
+	indexFlags    = indexNsAware | indexExtended | indexFragment | indexXml11 | indexNsUrls
+	// indexMaxDepth is the maximum depth of VTD records, held in 8 bits
+	indexMaxDepth = 255
+	indexLcLevels = 3
+	// indexDeepLcLevels is the number of location cache levels written for
+	// navigation with level 4 and level 5 buffers
+	indexDeepLcLevels = 5
+	// indexChunkSize is the number of buffer entries read at once, so that a
+	// corrupted entry count does not cause a huge allocation
+	indexChunkSize = 4096
+)
+
+// indexByteOrder is the byte order of every number in the persisted index,
+// regardless of the platform writing or reading it
+var indexByteOrder = binary.LittleEndian
+
+// indexHeader starts the persisted index. Offset and Length locate the
+// document in the XML bytes, DocChecksum is CRC-32 (IEEE) of those bytes.
+type indexHeader struct {
+	Magic       [4]byte
+	Version     uint16
+	Flags       uint16
+	Encoding    uint16
+	LcLevels    uint16
+	RootIndex   int32
+	Depth       int32
+	Offset      int64
+	Length      int64
+	DocChecksum uint32
+}
+
+// WriteIndex function writes VTD and location cache buffers of the document
+// so that LoadIndex can restore navigation without parsing the document
+// again. The index holds a header, the VTD buffer and L1 to L3 buffers, or L1
+// to L5 buffers when navigation has them, followed by the L0 buffer of a
+// fragment, the namespace URL buffer of namespace aware parsing and the
+// offset buffer in extended VTD mode, each preceded by its
+// entry count, and a trailing CRC-32 of everything written before it. All
+// numbers are little-endian. DTD of the document is not written.
+func (n *VtdNav) WriteIndex(w io.Writer) error {
+	if w == nil {
+		return erroring.NewInvalidArgumentError("w", erroring.CannotBeNil, nil)
+	}
+	doc := n.xmlBuffer.GetBytes()
+	header := indexHeader{
+		Version:     IndexVersion,
+		Encoding:    uint16(n.encoding),
+		LcLevels:    indexLcLevels,
+		RootIndex:   n.rootIndex,
+		Depth:       n.depth - 1,
+		Offset:      int64(n.offset),
+		Length:      int64(n.length),
+		DocChecksum: crc32.ChecksumIEEE(doc[n.offset : n.offset+n.length]),
+	}
+	copy(header.Magic[:], indexMagic)
+	buffers := []buffer.LongBuffer{n.vtdBuffer, n.l1Buffer, n.l2Buffer, n.l3Buffer}
+	if n.l4Buffer != nil {
+		header.LcLevels = indexDeepLcLevels
+		buffers = append(buffers, n.l4Buffer, n.l5Buffer)
+	}
+	if n.nsAware {
+		header.Flags |= indexNsAware
+	}
+	if n.l0Buffer != nil {
+		header.Flags |= indexFragment
+		buffers = append(buffers, n.l0Buffer)
+	}
+	if n.nsUrlBuffer != nil {
+		header.Flags |= indexNsUrls
+		buffers = append(buffers, n.nsUrlBuffer)
+	}
+	if n.offsetBuffer != nil {
+		header.Flags |= indexExtended
+		buffers = append(buffers, n.offsetBuffer)
+	}
+	if n.xml11 {
+		header.Flags |= indexXml11
+	}
+
+	bw := bufio.NewWriter(w)
+	checksum := crc32.NewIEEE()
+	out := io.MultiWriter(bw, checksum)
+	if err := binary.Write(out, indexByteOrder, &header); err != nil {
+		return err
+	}
+	for _, b := range buffers {
+		values, err := b.ToLongArray()
+		if err != nil {
+			return err
+		}
+		if err := binary.Write(out, indexByteOrder, uint32(len(values))); err != nil {
+			return err
+		}
+		if err := binary.Write(out, indexByteOrder, values); err != nil {
+			return err
+		}
+	}
+	if err := binary.Write(bw, indexByteOrder, checksum.Sum32()); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// LoadIndex function restores navigation over the document from the index
+// written by WriteIndex. The index must have been written for the same
+// document, which is verified using the document checksum held by the
+// index. Navigation object is positioned at the root element.
+func LoadIndex(xml []byte, r io.Reader) (*VtdNav, error) {
+	if xml == nil {
+		return nil, erroring.NewInvalidArgumentError("xml", erroring.CannotBeNil, nil)
+	}
+	if r == nil {
+		return nil, erroring.NewInvalidArgumentError("r", erroring.CannotBeNil, nil)
+	}
+	ir := &indexReader{r: bufio.NewReader(r), checksum: crc32.NewIEEE()}
+
+	var header indexHeader
+	if err := ir.read(&header); err != nil {
+		return nil, err
+	}
+	if string(header.Magic[:]) != indexMagic {
+		return nil, erroring.NewIndexError("not a VTD index", nil)
+	}
+	if header.Version != IndexVersion {
+		return nil, erroring.NewIndexError(fmt.Sprintf("unsupported index version %d", header.Version), nil)
+	}
+	if (header.LcLevels != indexLcLevels && header.LcLevels != indexDeepLcLevels) || header.Offset < 0 || header.Length < 0 ||
+		header.Depth < -1 || header.Depth > indexMaxDepth || header.Flags&^indexFlags != 0 ||
+		common.FormatEncoding(header.Encoding).String() == "UNKNOWN" {
+		return nil, erroring.NewIndexError("invalid index header", nil)
+	}
+	if header.Offset+header.Length > int64(len(xml)) ||
+		crc32.ChecksumIEEE(xml[header.Offset:header.Offset+header.Length]) != header.DocChecksum {
+		return nil, erroring.NewIndexError("index does not belong to the document", nil)
+	}
+
+	// VTD buffer, location cache buffers and the optional L0, namespace URL
+	// and offset buffers
+	buffers := make([]buffer.LongBuffer, 1+int(header.LcLevels))
+	if header.Flags&indexFragment != 0 {
+		buffers = append(buffers, nil)
+	}
+	nsUrlIndex := len(buffers)
+	if header.Flags&indexNsUrls != 0 {
+		buffers = append(buffers, nil)
+	}
+	if header.Flags&indexExtended != 0 {
+		buffers = append(buffers, nil)
+	}
+	for i := range buffers {
+		b, err := ir.readBuffer()
+		if err != nil {
+			return nil, err
+		}
+		buffers[i] = b
+	}
+	sum := ir.checksum.Sum32()
+	var stored uint32
+	if err := binary.Read(ir.r, indexByteOrder, &stored); err != nil {
+		return nil, erroring.NewIndexError("index is truncated", err)
+	}
+	if stored != sum {
+		return nil, erroring.NewIndexError("index checksum mismatch", nil)
+	}
+
+	if header.RootIndex < 0 || int(header.RootIndex) >= buffers[0].GetSize() {
+		return nil, erroring.NewIndexError("invalid root index", nil)
+	}
+	nav, err := NewVtdNav(header.RootIndex, int(header.Offset), int(header.Length), header.Depth,
+		common.FormatEncoding(header.Encoding), header.Flags&indexNsAware != 0, xml,
+		buffers[0], buffers[1], buffers[2], buffers[3])
+	if err != nil {
+		return nil, err
+	}
+	if header.LcLevels == indexDeepLcLevels {
+		if err := nav.SetDeepLcBuffers(buffers[4], buffers[5]); err != nil {
+			return nil, err
+		}
+	}
+	if header.Flags&indexFragment != 0 {
+		if err := nav.SetFragmentBuffer(buffers[1+int(header.LcLevels)]); err != nil {
+			return nil, err
+		}
+	}
+	if header.Flags&indexNsUrls != 0 {
+		if err := nav.SetNsUrlBuffer(buffers[nsUrlIndex]); err != nil {
+			return nil, erroring.NewIndexError("invalid namespace URL buffer", err)
+		}
+	}
+	nav.SetXml11(header.Flags&indexXml11 != 0)
+	if header.Flags&indexExtended != 0 {
+		if err := nav.SetOffsetBuffer(buffers[len(buffers)-1]); err != nil {
+			return nil, erroring.NewIndexError("invalid offset buffer", err)
+		}
+	}
+	if _, err := nav.ToElement(Root); err != nil {
+		return nil, err
+	}
+	return nav, nil
+}
+
+// indexReader reads numbers of the persisted index and computes checksum of
+// the bytes read
+type indexReader struct {
+	r        *bufio.Reader
+	checksum hash.Hash32
+}
+
+func (ir *indexReader) read(data interface{}) error {
+	if err := binary.Read(io.TeeReader(ir.r, ir.checksum), indexByteOrder, data); err != nil {
+		return erroring.NewIndexError("index is truncated", err)
+	}
+	return nil
+}
+
+// readBuffer function reads entry count followed by the entries
+func (ir *indexReader) readBuffer() (buffer.LongBuffer, error) {
+	var count uint32
+	if err := ir.read(&count); err != nil {
+		return nil, err
+	}
+	b, err := buffer.NewFastLongBuffer()
+	if err != nil {
+		return nil, err
+	}
+	chunk := make([]int64, indexChunkSize)
+	for remaining := int(count); remaining > 0; remaining -= len(chunk) {
+		if remaining < len(chunk) {
+			chunk = chunk[:remaining]
+		}
+		if err := ir.read(chunk); err != nil {
+			return nil, err
+		}
+		for _, v := range chunk {
+			if err := b.Append(v); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return b, nil
+}
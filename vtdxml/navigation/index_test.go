@@ -0,0 +1,167 @@
+package navigation
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/erroring"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTestIndex(t *testing.T, nav *VtdNav) []byte {
+	var index bytes.Buffer
+	if !assert.Nil(t, nav.WriteIndex(&index)) {
+		t.FailNow()
+	}
+	return index.Bytes()
+}
+
+func Test_VtdNav_WriteIndex_LoadIndex_Success(t *testing.T) {
+	nav := getNav(t)
+	assert.Nil(t, nav.l1Buffer.Append(7<<32|-1&0xFFFFFFFF))
+	doc := nav.xmlBuffer.GetBytes()
+
+	loaded, err := LoadIndex(doc, bytes.NewReader(writeTestIndex(t, nav)))
+	assert.Nil(t, err)
+	if !assert.NotNil(t, loaded) {
+		t.FailNow()
+	}
+	assert.Equal(t, nav.rootIndex, loaded.rootIndex)
+	assert.Equal(t, nav.depth, loaded.depth)
+	assert.Equal(t, nav.offset, loaded.offset)
+	assert.Equal(t, nav.length, loaded.length)
+	assert.Equal(t, nav.encoding, loaded.encoding)
+	assert.Equal(t, nav.nsAware, loaded.nsAware)
+	for _, pair := range [][2]interface{ ToLongArray() ([]int64, error) }{
+		{nav.vtdBuffer, loaded.vtdBuffer},
+		{nav.l1Buffer, loaded.l1Buffer},
+		{nav.l2Buffer, loaded.l2Buffer},
+		{nav.l3Buffer, loaded.l3Buffer},
+	} {
+		expected, err := pair[0].ToLongArray()
+		assert.Nil(t, err)
+		actual, err := pair[1].ToLongArray()
+		assert.Nil(t, err)
+		assert.Equal(t, expected, actual)
+	}
+	index, err := loaded.GetCurrentIndex()
+	assert.Nil(t, err)
+	name, err := loaded.ToRawStringAtIndex(int(index))
+	assert.Nil(t, err)
+	assert.Equal(t, "SwInt:ExchangeRequest", name)
+
+	// written index does not depend on the navigation object it was loaded into
+	assert.Equal(t, writeTestIndex(t, nav), writeTestIndex(t, loaded))
+}
+
+func Test_VtdNav_WriteIndex_LoadIndex_Extended(t *testing.T) {
+	nav := getExtendedNav(t, 0)
+	doc := nav.xmlBuffer.GetBytes()
+
+	loaded, err := LoadIndex(doc, bytes.NewReader(writeTestIndex(t, nav)))
+	assert.Nil(t, err)
+	if !assert.NotNil(t, loaded) {
+		t.FailNow()
+	}
+	assert.True(t, loaded.IsExtended())
+	expected, err := nav.offsetBuffer.ToLongArray()
+	assert.Nil(t, err)
+	actual, err := loaded.offsetBuffer.ToLongArray()
+	assert.Nil(t, err)
+	assert.Equal(t, expected, actual)
+	assert.Equal(t, writeTestIndex(t, nav), writeTestIndex(t, loaded))
+}
+
+func Test_LoadIndex_Error(t *testing.T) {
+	nav := getNav(t)
+	doc := nav.xmlBuffer.GetBytes()
+	index := writeTestIndex(t, nav)
+
+	modified := func(fn func(b []byte) []byte) []byte {
+		return fn(append([]byte(nil), index...))
+	}
+	otherDoc := append([]byte(nil), doc...)
+	otherDoc[len(otherDoc)-2] = 'X'
+
+	testCases := []struct {
+		name           string
+		doc            []byte
+		index          []byte
+		expectedErrMsg string
+	}{
+		{
+			name:           "Not an index",
+			doc:            doc,
+			index:          modified(func(b []byte) []byte { b[0] = 'X'; return b }),
+			expectedErrMsg: "an index error occurred: not a VTD index",
+		},
+		{
+			name:           "Unsupported version",
+			doc:            doc,
+			index:          modified(func(b []byte) []byte { b[4] = 9; return b }),
+			expectedErrMsg: "an index error occurred: unsupported index version 9",
+		},
+		{
+			name:           "Previous version",
+			doc:            doc,
+			index:          modified(func(b []byte) []byte { b[4] = 1; return b }),
+			expectedErrMsg: "an index error occurred: unsupported index version 1",
+		},
+		{
+			name:           "Unknown flags",
+			doc:            doc,
+			index:          modified(func(b []byte) []byte { b[6] |= 0x20; return b }),
+			expectedErrMsg: "an index error occurred: invalid index header",
+		},
+		{
+			name:           "Too deep",
+			doc:            doc,
+			index:          modified(func(b []byte) []byte { b[16], b[17] = 0, 1; return b }),
+			expectedErrMsg: "an index error occurred: invalid index header",
+		},
+		{
+			name:           "Different document",
+			doc:            otherDoc,
+			index:          index,
+			expectedErrMsg: "an index error occurred: index does not belong to the document",
+		},
+		{
+			name:           "Shorter document",
+			doc:            doc[:100],
+			index:          index,
+			expectedErrMsg: "an index error occurred: index does not belong to the document",
+		},
+		{
+			name:           "Corrupted entry",
+			doc:            doc,
+			index:          modified(func(b []byte) []byte { b[50] ^= 0xFF; return b }),
+			expectedErrMsg: "an index error occurred: index checksum mismatch",
+		},
+		{
+			name:           "Truncated header",
+			doc:            doc,
+			index:          index[:10],
+			expectedErrMsg: "an index error occurred: index is truncated",
+		},
+		{
+			name:           "Truncated buffer",
+			doc:            doc,
+			index:          index[:len(index)-20],
+			expectedErrMsg: "an index error occurred: index is truncated",
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			loaded, err := LoadIndex(tc.doc, bytes.NewReader(tc.index))
+			assert.Nil(t, loaded)
+			assert.True(t, errors.As(err, &erroring.IndexErrorType))
+			assert.EqualError(t, err, tc.expectedErrMsg)
+		})
+	}
+
+	loaded, err := LoadIndex(nil, bytes.NewReader(index))
+	assert.Nil(t, loaded)
+	assert.True(t, errors.As(err, &erroring.InvalidArgumentErrorType))
+}
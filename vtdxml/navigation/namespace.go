@@ -0,0 +1,64 @@
+package navigation
+
+import (
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/buffer"
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/erroring"
+)
+
+const (
+	// NsUrlNone is the namespace URL index of tokens not in any namespace
+	NsUrlNone = -1
+	// NsUrlXml is the namespace URL index of tokens with the xml prefix,
+	// which is bound to XmlNamespace without being declared
+	NsUrlXml = -2
+
+	XmlNamespace = "http://www.w3.org/XML/1998/namespace"
+)
+
+// SetNsUrlBuffer function sets namespace URL buffer filled by namespace aware
+// parsing. It holds one entry per VTD record: index of the attribute value
+// token of the namespace declaration in scope for element and attribute
+// name tokens, NsUrlXml for names with the xml prefix and NsUrlNone for
+// every other token.
+func (n *VtdNav) SetNsUrlBuffer(nsUrlBuffer buffer.LongBuffer) error {
+	if nsUrlBuffer == nil {
+		return erroring.NewInvalidArgumentError("nsUrlBuffer", erroring.CannotBeNil, nil)
+	}
+	if nsUrlBuffer.GetSize() != n.vtdBuffer.GetSize() {
+		return erroring.NewInvalidArgumentError("nsUrlBuffer", erroring.InvalidSliceLength, nil)
+	}
+	n.nsUrlBuffer = nsUrlBuffer
+	return nil
+}
+
+// GetTokenNsUrlIndex function returns index of the attribute value token
+// holding namespace URL of the element or attribute name token, NsUrlXml if
+// the name has the xml prefix or NsUrlNone if it is not in any namespace.
+// Namespace declarations themselves and other tokens have NsUrlNone.
+func (n *VtdNav) GetTokenNsUrlIndex(index int) (int32, error) {
+	if n.nsUrlBuffer == nil {
+		return 0, erroring.NewInternalError("namespace URLs are resolved by namespace aware parsing only", nil)
+	}
+	val, err := n.nsUrlBuffer.LongAt(index)
+	if err != nil {
+		return 0, err
+	}
+	return int32(val), nil
+}
+
+// GetTokenNsUrl function returns namespace URL of the element or attribute
+// name token with entity and character references resolved, or empty string
+// if the token is not in any namespace
+func (n *VtdNav) GetTokenNsUrl(index int) (string, error) {
+	urlIndex, err := n.GetTokenNsUrlIndex(index)
+	if err != nil {
+		return "", err
+	}
+	switch urlIndex {
+	case NsUrlNone:
+		return "", nil
+	case NsUrlXml:
+		return XmlNamespace, nil
+	}
+	return n.ToStringAtIndex(int(urlIndex))
+}
@@ -21,6 +21,13 @@ func (n *VtdNav) ToElement(dir Direction) (bool, error) {
 }
 
 func (n *VtdNav) toRootElement() (bool, error) {
+	if n.l0Buffer != nil {
+		// first top-level element of the fragment
+		if n.l0Buffer.GetSize() == 0 {
+			return false, nil
+		}
+		n.l0index = 0
+	}
 	if n.context[0] != 0 {
 		n.context[0] = 0
 	}
@@ -52,19 +59,53 @@ func (n *VtdNav) toChildElement(dir Direction) (bool, error) {
 	switch n.context[0] {
 	case -1:
 		{
+			if n.l0Buffer != nil {
+				if n.l0Buffer.GetSize() == 0 {
+					return false, nil
+				}
+				if dir == FirstChild {
+					n.l0index = 0
+				} else {
+					n.l0index = n.l0Buffer.GetSize() - 1
+				}
+			}
 			n.context[0] = 0
 			return true, nil
 		}
 	case 0:
 		{
-			if n.l1Buffer.GetSize() == 0 {
-				return false, nil
+			if n.l0Buffer != nil {
+				// level 1 children of the current top-level element
+				lower, err := n.l0Buffer.Lower32At(n.l0index)
+				if err != nil {
+					return false, err
+				}
+				if lower == -1 {
+					return false, nil
+				}
+				n.l1lower = lower
+				n.l1upper = int32(n.l1Buffer.GetSize() - 1)
+				for i := n.l0index + 1; i < n.l0Buffer.GetSize(); i++ {
+					lower, err = n.l0Buffer.Lower32At(i)
+					if err != nil {
+						return false, err
+					}
+					if uint32(lower) != uint32(0xffffffff) {
+						n.l1upper = lower - 1
+						break
+					}
+				}
+			} else {
+				if n.l1Buffer.GetSize() == 0 {
+					return false, nil
+				}
+				n.l1lower, n.l1upper = 0, int32(n.l1Buffer.GetSize()-1)
 			}
 			n.context[0] = 1
 			if dir == FirstChild {
-				n.l1index = 0
+				n.l1index = int(n.l1lower)
 			} else {
-				n.l1index = n.l1Buffer.GetSize() - 1
+				n.l1index = int(n.l1upper)
 			}
 			val, err := n.l1Buffer.Upper32At(n.l1index)
 			if err != nil {
@@ -134,22 +175,102 @@ func (n *VtdNav) toChildElement(dir Direction) (bool, error) {
 			} else {
 				n.l3index = int(n.l3upper)
 			}
-			upper, err := n.l2Buffer.Upper32At(n.l2index)
+			upper, err := n.l3Buffer.Upper32At(n.l3index)
 			if err != nil {
-				return false, nil
+				return false, err
 			}
 			n.context[3] = upper
 			return true, nil
 		}
-	default:
-		if dir == FirstChild {
-			return n.toFirstChild()
-		} else {
-			return n.toLastChild()
+	case 3:
+		{
+			if n.l4Buffer == nil {
+				return n.toChildByScan(dir)
+			}
+			lower, err := n.l3Buffer.Lower32At(n.l3index)
+			if err != nil {
+				return false, err
+			}
+			n.l4lower = lower
+			if n.l4lower == -1 {
+				return false, nil
+			}
+			n.context[0] = 4
+			n.l4upper = int32(n.l4Buffer.GetSize() - 1)
+			for i := n.l3index + 1; i < n.l3Buffer.GetSize(); i++ {
+				lower, err = n.l3Buffer.Lower32At(i)
+				if err != nil {
+					return false, err
+				}
+				if uint32(lower) != uint32(0xffffffff) {
+					n.l4upper = lower - 1
+					break
+				}
+			}
+			if dir == FirstChild {
+				n.l4index = int(n.l4lower)
+			} else {
+				n.l4index = int(n.l4upper)
+			}
+			upper, err := n.l4Buffer.Upper32At(n.l4index)
+			if err != nil {
+				return false, err
+			}
+			n.context[4] = upper
+			return true, nil
 		}
+	case 4:
+		{
+			if n.l5Buffer == nil {
+				return n.toChildByScan(dir)
+			}
+			lower, err := n.l4Buffer.Lower32At(n.l4index)
+			if err != nil {
+				return false, err
+			}
+			n.l5lower = lower
+			if n.l5lower == -1 {
+				return false, nil
+			}
+			n.context[0] = 5
+			n.l5upper = int32(n.l5Buffer.GetSize() - 1)
+			for i := n.l4index + 1; i < n.l4Buffer.GetSize(); i++ {
+				lower, err = n.l4Buffer.Lower32At(i)
+				if err != nil {
+					return false, err
+				}
+				if uint32(lower) != uint32(0xffffffff) {
+					n.l5upper = lower - 1
+					break
+				}
+			}
+			if dir == FirstChild {
+				n.l5index = int(n.l5lower)
+			} else {
+				n.l5index = int(n.l5upper)
+			}
+			// level 5 entries hold the token index only
+			index, err := n.l5Buffer.Lower32At(n.l5index)
+			if err != nil {
+				return false, err
+			}
+			n.context[5] = index
+			return true, nil
+		}
+	default:
+		return n.toChildByScan(dir)
 	}
 }
 
+// toChildByScan function moves to the first or last child element by scanning
+// VTD records following the current element
+func (n *VtdNav) toChildByScan(dir Direction) (bool, error) {
+	if dir == FirstChild {
+		return n.toFirstChild()
+	}
+	return n.toLastChild()
+}
+
 func (n *VtdNav) toFirstChild() (bool, error) {
 	index := int(n.context[n.context[0]] + 1)
 	for index < n.vtdBuffer.GetSize() {
@@ -210,17 +331,36 @@ func (n *VtdNav) toSiblingElement(dir Direction) (bool, error) {
 		return false, nil
 	}
 	switch n.context[0] {
-	case -1, 0:
+	case -1:
 		return false, nil
+	case 0:
+		{
+			// only top-level elements of a fragment have siblings
+			if n.l0Buffer == nil {
+				return false, nil
+			}
+			if dir == NextSibling {
+				if n.l0index+1 >= n.l0Buffer.GetSize() {
+					return false, nil
+				}
+				n.l0index++
+			} else {
+				if n.l0index-1 < 0 {
+					return false, nil
+				}
+				n.l0index--
+			}
+			return true, nil
+		}
 	case 1:
 		{
 			if dir == NextSibling {
-				if n.l1index+1 >= n.l1Buffer.GetSize() {
+				if n.l1index+1 > int(n.l1upper) {
 					return false, nil
 				}
 				n.l1index++
 			} else {
-				if n.l1index-1 < 0 {
+				if n.l1index-1 < int(n.l1lower) {
 					return false, nil
 				}
 				n.l1index--
@@ -245,7 +385,7 @@ func (n *VtdNav) toSiblingElement(dir Direction) (bool, error) {
 				}
 				n.l2index--
 			}
-			upper, err := n.l1Buffer.Upper32At(n.l1index)
+			upper, err := n.l2Buffer.Upper32At(n.l2index)
 			if err != nil {
 				return false, err
 			}
@@ -265,24 +405,73 @@ func (n *VtdNav) toSiblingElement(dir Direction) (bool, error) {
 				}
 				n.l3index--
 			}
-			upper, err := n.l1Buffer.Upper32At(n.l1index)
+			upper, err := n.l3Buffer.Upper32At(n.l3index)
 			if err != nil {
 				return false, err
 			}
 			n.context[3] = upper
 			return true, nil
 		}
-	default:
+	case 4:
 		{
+			if n.l4Buffer == nil {
+				return n.toSiblingByScan(dir)
+			}
+			if dir == NextSibling {
+				if n.l4index+1 > int(n.l4upper) {
+					return false, nil
+				}
+				n.l4index++
+			} else {
+				if n.l4index-1 < int(n.l4lower) {
+					return false, nil
+				}
+				n.l4index--
+			}
+			upper, err := n.l4Buffer.Upper32At(n.l4index)
+			if err != nil {
+				return false, err
+			}
+			n.context[4] = upper
+			return true, nil
+		}
+	case 5:
+		{
+			if n.l5Buffer == nil {
+				return n.toSiblingByScan(dir)
+			}
 			if dir == NextSibling {
-				return n.toNextSibling()
+				if n.l5index+1 > int(n.l5upper) {
+					return false, nil
+				}
+				n.l5index++
 			} else {
-				return n.toPrevSibling()
+				if n.l5index-1 < int(n.l5lower) {
+					return false, nil
+				}
+				n.l5index--
+			}
+			index, err := n.l5Buffer.Lower32At(n.l5index)
+			if err != nil {
+				return false, err
 			}
+			n.context[5] = index
+			return true, nil
 		}
+	default:
+		return n.toSiblingByScan(dir)
 	}
 }
 
+// toSiblingByScan function moves to the next or previous sibling element by
+// scanning VTD records around the current element
+func (n *VtdNav) toSiblingByScan(dir Direction) (bool, error) {
+	if dir == NextSibling {
+		return n.toNextSibling()
+	}
+	return n.toPrevSibling()
+}
+
 func (n *VtdNav) toNextSibling() (bool, error) {
 	index := int(n.context[n.context[0]] + 1)
 	for index < n.vtdBuffer.GetSize() {
@@ -295,7 +484,7 @@ func (n *VtdNav) toNextSibling() (bool, error) {
 			return false, err
 		}
 		if common.Token(tokenType) == common.TokenStartingTag {
-			if depth <= n.context[0] {
+			if depth < n.context[0] {
 				return false, nil
 			} else if depth == n.context[0] {
 				n.context[n.context[0]] = int32(index)
@@ -19,23 +19,37 @@ func (n *VtdNav) ToStringAtIndex(index int) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	offset, err := n.GetTokenOffset(index)
+	offset, err := n.tokenOffset(index)
 	if err != nil {
 		return "", err
 	}
-	return n.ToStringAtRange(offset, length)
+	return n.toStringAtRange(offset, int(length))
 }
 
 func (n *VtdNav) ToStringAtRange(offset, length int32) (string, error) {
+	return n.toStringAtRange(int(offset), int(length))
+}
+
+func (n *VtdNav) toStringAtRange(offset, length int) (string, error) {
 	var buffer bytes.Buffer
 
 	endOffset := offset + length
-	for i := offset; i < endOffset; i++ {
-		ch, err := n.getCharResolved(int(i))
+	for i := offset; i < endOffset; {
+		ch, err := n.getChar(i)
+		if err != nil {
+			return "", err
+		}
+		if uint32(ch) != '&' {
+			buffer.WriteRune(rune(uint32(ch)))
+			i += int(ch >> 32)
+			continue
+		}
+		text, inc, err := n.resolveReference(i + 1)
 		if err != nil {
 			return "", err
 		}
-		buffer.WriteByte(byte(ch))
+		buffer.WriteString(text)
+		i += inc
 	}
 
 	return buffer.String(), nil
@@ -62,23 +76,28 @@ func (n *VtdNav) ToRawStringAtIndex(index int) (string, error) {
 		}
 		length = l
 	}
-	offset, err := n.GetTokenOffset(index)
+	offset, err := n.tokenOffset(index)
 	if err != nil {
 		return "", err
 	}
-	return n.ToRawStringAtRange(offset, length)
+	return n.toRawStringAtRange(offset, int(length))
 }
 
 func (n *VtdNav) ToRawStringAtRange(offset, length int32) (string, error) {
+	return n.toRawStringAtRange(int(offset), int(length))
+}
+
+func (n *VtdNav) toRawStringAtRange(offset, length int) (string, error) {
 	var buffer bytes.Buffer
 
 	endOffset := offset + length
-	for i := offset; i < endOffset; i++ {
-		ch, err := n.getChar(int(i))
+	for i := offset; i < endOffset; {
+		ch, err := n.getChar(i)
 		if err != nil {
 			return "", err
 		}
-		buffer.WriteByte(byte(ch))
+		buffer.WriteRune(rune(uint32(ch)))
+		i += int(ch >> 32)
 	}
 
 	return buffer.String(), nil
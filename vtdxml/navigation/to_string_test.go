@@ -39,7 +39,7 @@ func Test_VtdNav_ToStringAtRange_Success(t *testing.T) {
 			name:           "Range [0, 300]",
 			offset:         0,
 			length:         300,
-			expectedResult: "<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"yes\"?>\n\n<SwInt:ExchangeRequest xmlns:Sw=\"urn:swift:snl:ns.Sw\"\n\n                       xmlns:SwInt=\"urn:swift:snl:ns.SwInt\"\n\n                       xmlns:SwSec=\"urn:swift:snl:ns.SwSec\">\n\n    <SwInt:Request>\n\n        <SwInt:RequestHeader>\n\n            <",
+			expectedResult: "<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"yes\"?>\n<SwInt:ExchangeRequest xmlns:Sw=\"urn:swift:snl:ns.Sw\"\n                       xmlns:SwInt=\"urn:swift:snl:ns.SwInt\"\n                       xmlns:SwSec=\"urn:swift:snl:ns.SwSec\">\n    <SwInt:Request>\n        <SwInt:RequestHeader>\n            <",
 		},
 	}
 
@@ -120,7 +120,7 @@ func Test_VtdNav_ToRawStringAtRange_Success(t *testing.T) {
 			name:           "Range [0, 300]",
 			offset:         0,
 			length:         300,
-			expectedResult: "<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"yes\"?>\n\n<SwInt:ExchangeRequest xmlns:Sw=\"urn:swift:snl:ns.Sw\"\n\n                       xmlns:SwInt=\"urn:swift:snl:ns.SwInt\"\n\n                       xmlns:SwSec=\"urn:swift:snl:ns.SwSec\">\n\n    <SwInt:Request>\n\n        <SwInt:RequestHeader>\n\n            <",
+			expectedResult: "<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"yes\"?>\n<SwInt:ExchangeRequest xmlns:Sw=\"urn:swift:snl:ns.Sw\"\n                       xmlns:SwInt=\"urn:swift:snl:ns.SwInt\"\n                       xmlns:SwSec=\"urn:swift:snl:ns.SwSec\">\n    <SwInt:Request>\n        <SwInt:RequestHeader>\n            <",
 		},
 	}
 
@@ -1,8 +1,13 @@
 package navigation
 
 import (
+	"io"
+
 	"github.com/alexZaicev/go-vtd-xml/vtdxml/buffer"
 	"github.com/alexZaicev/go-vtd-xml/vtdxml/common"
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/dtd"
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/erroring"
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/reader"
 )
 
 type Nav interface {
@@ -14,8 +19,10 @@ type Nav interface {
 
 	GetTokenType(index int) (int32, error)
 	GetTokenOffset(index int) (int32, error)
+	GetTokenOffset64(index int) (int64, error)
 	GetTokenLength(index int) (int32, error)
 	GetTokenDepth(index int) (int32, error)
+	GetTokenPosition(index int) (int, int, error)
 
 	ToStringAtIndex(index int) (string, error)
 	ToStringAtRange(offset, length int32) (string, error)
@@ -28,25 +35,60 @@ type Nav interface {
 
 type VtdNav struct {
 	context                                 []int32
-	rootIndex, offset, length, depth        int32
+	rootIndex, depth                        int32
+	offset, length                          int
 	encoding                                common.FormatEncoding
-	nsAware                                 bool
+	nsAware, xml11                          bool
 	atTerminal                              bool
 	ln                                      int32
 	xmlChar                                 *common.XmlChar
 	xmlBuffer                               buffer.ByteBuffer
 	vtdBuffer, l1Buffer, l2Buffer, l3Buffer buffer.LongBuffer
-	l1index, l2index, l3index               int
+	l4Buffer, l5Buffer, offsetBuffer        buffer.LongBuffer
+	l0Buffer, nsUrlBuffer                   buffer.LongBuffer
+	l0index, l1index, l2index, l3index      int
+	l4index, l5index                        int
+	l1lower, l1upper                        int32
 	l2lower, l2upper, l3lower, l3upper      int32
+	l4lower, l4upper, l5lower, l5upper      int32
+	lineIndex                               *reader.LineIndex
+	dtd                                     *dtd.Dtd
+	entityExpansionLimit                    int
 }
 
 func NewVtdNav(
-	rootIndex, offset, length, depth int32,
+	rootIndex int32,
+	offset, length int,
+	depth int32,
 	encoding common.FormatEncoding,
 	nsAware bool,
 	bytes []byte,
 	vtdBuffer, l1Buffer, l2Buffer, l3Buffer buffer.LongBuffer,
 ) (*VtdNav, error) {
+	xmlBuffer, err := buffer.NewUniByteBuffer(bytes)
+	if err != nil {
+		return nil, err
+	}
+	return NewVtdNavWithBuffer(rootIndex, offset, length, depth, encoding, nsAware, xmlBuffer,
+		vtdBuffer, l1Buffer, l2Buffer, l3Buffer)
+}
+
+// NewVtdNavWithBuffer function creates navigation object over the document
+// held by the byte buffer. When the buffer implements io.Closer, e.g.
+// buffer.MappedByteBuffer, the navigation object takes ownership of it and
+// releases it on Close.
+func NewVtdNavWithBuffer(
+	rootIndex int32,
+	offset, length int,
+	depth int32,
+	encoding common.FormatEncoding,
+	nsAware bool,
+	xmlBuffer buffer.ByteBuffer,
+	vtdBuffer, l1Buffer, l2Buffer, l3Buffer buffer.LongBuffer,
+) (*VtdNav, error) {
+	if xmlBuffer == nil {
+		return nil, erroring.NewInvalidArgumentError("xmlBuffer", erroring.CannotBeNil, nil)
+	}
 	// TODO validate arguments
 	n := &VtdNav{
 		rootIndex: rootIndex,
@@ -59,19 +101,120 @@ func NewVtdNav(
 		l1Buffer:  l1Buffer,
 		l2Buffer:  l2Buffer,
 		l3Buffer:  l3Buffer,
-		context:   make([]int32, 0, depth),
+		context:   make([]int32, 0, depth+1),
 		xmlChar:   common.NewXmlChar(),
+		xmlBuffer: xmlBuffer,
 	}
 
-	for i := 0; i < int(depth); i++ {
+	// context[0] holds the current depth, context[1..depth] hold the
+	// element index at each level
+	for i := 0; i <= int(depth); i++ {
 		n.context = append(n.context, -1)
 	}
 
-	xmlBuffer, err := buffer.NewUniByteBuffer(bytes)
-	if err != nil {
-		return nil, err
+	return n, nil
+}
+
+// SetDtd function sets document type declaration whose general entities are
+// resolved when converting tokens to strings
+func (n *VtdNav) SetDtd(d *dtd.Dtd) {
+	n.dtd = d
+}
+
+// SetEntityExpansionLimit function sets the number of bytes a general entity
+// reference may expand to when converting tokens to strings. Zero disables
+// the limit.
+func (n *VtdNav) SetEntityExpansionLimit(limit int) {
+	n.entityExpansionLimit = limit
+}
+
+// GetDtd function returns document type declaration of the document, or nil
+// if the document does not have one
+func (n *VtdNav) GetDtd() *dtd.Dtd {
+	return n.dtd
+}
+
+// Close function releases the document buffer when the navigation object
+// owns it, e.g. unmaps a memory-mapped document. Navigation object must not
+// be used after Close.
+func (n *VtdNav) Close() error {
+	if c, ok := n.xmlBuffer.(io.Closer); ok {
+		return c.Close()
 	}
-	n.xmlBuffer = xmlBuffer
+	return nil
+}
 
-	return n, nil
+// SetDeepLcBuffers function sets level 4 and level 5 location cache buffers
+// filled by parsing with location cache depth 5. Level 3 buffer must come
+// from the same parse, as its entries then point to level 4 children.
+// Navigation uses them to move between elements at depth 4 and 5 without
+// scanning VTD records.
+func (n *VtdNav) SetDeepLcBuffers(l4Buffer, l5Buffer buffer.LongBuffer) error {
+	if l4Buffer == nil {
+		return erroring.NewInvalidArgumentError("l4Buffer", erroring.CannotBeNil, nil)
+	}
+	if l5Buffer == nil {
+		return erroring.NewInvalidArgumentError("l5Buffer", erroring.CannotBeNil, nil)
+	}
+	n.l4Buffer, n.l5Buffer = l4Buffer, l5Buffer
+	return nil
+}
+
+// SetOffsetBuffer function switches navigation to extended VTD mode, where
+// token offsets are taken from the offset buffer instead of VTD records. The
+// offset buffer holds one offset per VTD record, so documents whose offsets
+// do not fit the 30 bits of a VTD record can be navigated.
+func (n *VtdNav) SetOffsetBuffer(offsetBuffer buffer.LongBuffer) error {
+	if offsetBuffer == nil {
+		return erroring.NewInvalidArgumentError("offsetBuffer", erroring.CannotBeNil, nil)
+	}
+	if offsetBuffer.GetSize() != n.vtdBuffer.GetSize() {
+		return erroring.NewInvalidArgumentError("offsetBuffer", erroring.InvalidSliceLength, nil)
+	}
+	n.offsetBuffer = offsetBuffer
+	return nil
+}
+
+// IsExtended function returns true if navigation is in extended VTD mode
+func (n *VtdNav) IsExtended() bool {
+	return n.offsetBuffer != nil
+}
+
+// SetFragmentBuffer function sets level 0 location cache buffer filled by
+// parsing in fragment mode. It holds every top-level element of the fragment
+// together with its first level 1 child, so navigation moves between
+// top-level elements with NextSibling and PrevSibling.
+func (n *VtdNav) SetFragmentBuffer(l0Buffer buffer.LongBuffer) error {
+	if l0Buffer == nil {
+		return erroring.NewInvalidArgumentError("l0Buffer", erroring.CannotBeNil, nil)
+	}
+	n.l0Buffer = l0Buffer
+	return nil
+}
+
+// IsFragment function returns true if navigation is over a fragment that can
+// hold many top-level elements
+func (n *VtdNav) IsFragment() bool {
+	return n.l0Buffer != nil
+}
+
+// SetXml11 function marks the document as XML 1.1 document, whose character
+// references may refer to restricted characters and whose NEL and LSEP
+// characters end lines the same as LF
+func (n *VtdNav) SetXml11(xml11 bool) {
+	if n.xml11 == xml11 {
+		return
+	}
+	n.xml11 = xml11
+	if xml11 {
+		n.xmlChar = common.NewXmlChar11()
+	} else {
+		n.xmlChar = common.NewXmlChar()
+	}
+	n.lineIndex = nil
+}
+
+// IsXml11 function returns true if navigation is over XML 1.1 document
+func (n *VtdNav) IsXml11() bool {
+	return n.xml11
 }
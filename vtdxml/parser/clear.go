@@ -1,6 +1,94 @@
 package parser
 
+// Clear function resets parser state so the current document can be parsed
+// again. When buffer reuse is enabled VTD and LC buffers are truncated and
+// their pages are kept for the next parse, otherwise new buffers are allocated.
 func (p *VtdParser) Clear() error {
-	// TODO implement
-	return nil
+	p.offset, p.lastOffset = p.docOffset, p.docOffset
+	p.length1, p.length2 = 0, 0
+	p.depth, p.vtdDepth, p.lastDepth = DefaultDepth, 0, 0
+	p.increment = DefaultIncrement
+	p.rootIndex, p.lastL0Index, p.lastL1Index, p.lastL2Index, p.lastL3Index, p.lastL4Index = 0, 0, 0, 0, 0, 0
+	p.attrCount, p.prefixedAttCount = 0, 0
+	p.currentChar, p.lastChar = 0, 0
+	p.currentElementRecord, p.currentElementIndex = 0, 0
+	p.defaultNs, p.isNs, p.isXml, p.helper = false, false, false, false
+	p.singleByteEncoding, p.bomDetected, p.mustUtf8 = true, false, false
+	p.encoding = DefaultEncoding
+	p.setXml11(false)
+	p.parsed = false
+	p.state = StateDocStart
+	p.lineIndex = nil
+	p.dtd = nil
+	p.textTokenCount, p.entityExpansion = 0, 0
+	// errors already returned by Errors stay with the caller
+	p.recovered, p.resyncOffset = nil, 0
+	p.extended = false
+	p.spaceAttr = false
+	p.peStack = nil
+	p.pendingName = Event{}
+
+	for i := range p.tagStack {
+		p.tagStack[i] = 0
+	}
+	for i := range p.preserveSpace {
+		p.preserveSpace[i] = false
+	}
+	for i := range p.attrNameSlice {
+		p.attrNameSlice[i] = 0
+	}
+	for i := range p.prefixedAttrNameSlice {
+		p.prefixedAttrNameSlice[i] = 0
+	}
+	p.prefixUrlSlice = p.prefixUrlSlice[:DefaultAttrArraySize]
+	for i := range p.prefixUrlSlice {
+		p.prefixUrlSlice[i] = 0
+	}
+	for i := range p.prefixedAttrIndexSlice {
+		p.prefixedAttrIndexSlice[i] = 0
+	}
+
+	// offset buffer is created when the next document needs extended mode
+	if p.bufferReuse && p.offsetBuffer != nil {
+		p.offsetBuffer.Clear()
+	} else {
+		p.offsetBuffer = nil
+	}
+	// level 0 buffer is created when parsing a fragment
+	if p.bufferReuse && p.l0Buffer != nil {
+		p.l0Buffer.Clear()
+	} else {
+		p.l0Buffer = nil
+	}
+	// namespace URL buffer is created when parsing in namespace aware mode
+	if p.bufferReuse && p.nsUrlBuffer != nil {
+		p.nsUrlBuffer.Clear()
+	} else {
+		p.nsUrlBuffer = nil
+	}
+
+	// namespace buffers never leave the parser, so they are always truncated
+	p.nsBuffer1.Clear()
+	p.nsBuffer2.Clear()
+	p.nsBuffer3.Clear()
+	p.nsBuffer4.Clear()
+
+	if p.xmlDoc != nil {
+		if err := p.initReader(); err != nil {
+			return err
+		}
+	}
+
+	if p.bufferReuse && p.vtdBuffer != nil && (p.shallowDepth || p.l4Buffer != nil) {
+		p.vtdBuffer.Clear()
+		p.l1Buffer.Clear()
+		p.l2Buffer.Clear()
+		p.l3Buffer.Clear()
+		if !p.shallowDepth {
+			p.l4Buffer.Clear()
+			p.l5Buffer.Clear()
+		}
+		return nil
+	}
+	return p.initVtdBuffers()
 }
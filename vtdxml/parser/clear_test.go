@@ -0,0 +1,97 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/navigation"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_VtdParser_Clear_Success(t *testing.T) {
+	for _, reuse := range []bool{false, true} {
+		parser, err := NewVtdParser(
+			WithXmlDoc(readTestData(t, "camt.004.001.08", true)),
+			WithBufferReuse(reuse),
+		)
+		assert.Nil(t, err)
+		assert.Nil(t, parser.Parse())
+		expected, err := parser.vtdBuffer.ToLongArray()
+		assert.Nil(t, err)
+
+		vtdBuffer := parser.vtdBuffer
+		assert.Nil(t, parser.Clear())
+		assert.Equal(t, 0, parser.vtdBuffer.GetSize())
+		assert.Equal(t, reuse, vtdBuffer == parser.vtdBuffer)
+
+		assert.Nil(t, parser.Parse())
+		actual, err := parser.vtdBuffer.ToLongArray()
+		assert.Nil(t, err)
+		assert.Equal(t, expected, actual)
+	}
+}
+
+func Test_VtdParser_Clear_ResetsState(t *testing.T) {
+	parser, err := NewVtdParser(
+		WithXmlDoc([]byte(`<a xml:space="preserve"><b> </b></a>`)),
+		WithWhitespacePolicy(WhitespacePreserve),
+	)
+	assert.Nil(t, err)
+	assert.Nil(t, parser.Parse())
+
+	parser.spaceAttr = true
+	parser.peStack = []string{"pe"}
+	parser.pendingName = Event{Offset: 1, Length: 1, p: parser}
+	assert.Nil(t, parser.Clear())
+	assert.False(t, parser.spaceAttr)
+	assert.Nil(t, parser.peStack)
+	assert.Equal(t, Event{}, parser.pendingName)
+	for _, preserve := range parser.preserveSpace {
+		assert.False(t, preserve)
+	}
+}
+
+func Test_VtdParser_Reset_Success(t *testing.T) {
+	parser, err := NewVtdParser(
+		WithXmlDoc(readTestData(t, "camt.004.001.08", true)),
+		WithBufferReuse(true),
+	)
+	assert.Nil(t, err)
+	assert.Nil(t, parser.Parse())
+	nav, err := parser.GetNav()
+	assert.Nil(t, err)
+
+	l1Buffer := parser.l1Buffer
+	assert.Nil(t, parser.Reset(WithXmlDoc(readTestData(t, "xml_opt1", true))))
+	assert.True(t, l1Buffer == parser.l1Buffer)
+	assert.Nil(t, parser.Parse())
+
+	nav2, err := parser.GetNav()
+	assert.Nil(t, err)
+	assertCurrentElement(t, nav2, "pre:Vehicle")
+	assertMove(t, nav2, navigation.FirstChild, "seats")
+
+	// navigation object handed out before reset is not affected
+	assertCurrentElement(t, nav, "Document")
+	assertMove(t, nav, navigation.FirstChild, "RtrAcct")
+}
+
+func Test_VtdParser_Reset_AfterGetNavWithoutBufferReuse(t *testing.T) {
+	parser, err := NewVtdParser(WithXmlDoc(readTestData(t, "xml_opt1", true)))
+	assert.Nil(t, err)
+	assert.Nil(t, parser.Parse())
+	_, err = parser.GetNav()
+	assert.Nil(t, err)
+
+	assert.EqualError(t, parser.Parse(), "invalid argument xmlDoc: cannot be nil")
+	assert.Nil(t, parser.Reset(WithXmlDoc(readTestData(t, "xml_opt2", true))))
+	assert.Nil(t, parser.Parse())
+	_, err = parser.GetNav()
+	assert.Nil(t, err)
+}
+
+func Test_VtdParser_Reset_InvalidArgument(t *testing.T) {
+	parser, err := NewVtdParser(WithXmlDoc(readTestData(t, "xml_opt1", true)))
+	assert.Nil(t, err)
+
+	assert.EqualError(t, parser.Reset(WithXmlDoc([]byte{})), "invalid argument xmlDoc: document cannot be empty")
+}
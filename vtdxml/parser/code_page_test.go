@@ -0,0 +1,146 @@
+package parser
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/common"
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/navigation"
+	"github.com/stretchr/testify/assert"
+)
+
+const codePageXml = "<?xml version=\"1.0\" encoding=\"%s\"?>\r\n" +
+	"<doc>\r\n" +
+	"\t<name>%s</name>\r\n" +
+	"\t<text>%s &amp; more</text>\r\n" +
+	"</doc>"
+
+func Test_VtdParser_Parse_CodePage_Success(t *testing.T) {
+	testCases := []struct {
+		name             string
+		declaredEncoding string
+		encodedText      string
+		expectedEncoding common.FormatEncoding
+		expectedText     string
+	}{
+		{
+			name:             "ISO-8859-1",
+			declaredEncoding: "ISO-8859-1",
+			encodedText:      "caf\xE9 \xBD",
+			expectedEncoding: common.FormatIso88591,
+			expectedText:     "café ½",
+		},
+		{
+			name:             "ISO-8859-2",
+			declaredEncoding: "iso-8859-2",
+			encodedText:      "\xA3\xF3d\xBC",
+			expectedEncoding: common.FormatIso88592,
+			expectedText:     "Łódź",
+		},
+		{
+			name:             "ISO-8859-7",
+			declaredEncoding: "ISO-8859-7",
+			encodedText:      "\xE1\xE2\xE3",
+			expectedEncoding: common.FormatIso88597,
+			expectedText:     "αβγ",
+		},
+		{
+			name:             "ISO-8859-15",
+			declaredEncoding: "ISO-8859-15",
+			encodedText:      "\xA4 \xBD",
+			expectedEncoding: common.FormatIso885915,
+			expectedText:     "€ œ",
+		},
+		{
+			name:             "ISO-8859-16",
+			declaredEncoding: "ISO-8859-16",
+			encodedText:      "\xAA\xBA",
+			expectedEncoding: common.FormatIso885916,
+			expectedText:     "Șș",
+		},
+		{
+			name:             "Windows-1251",
+			declaredEncoding: "windows-1251",
+			encodedText:      "\xCF\xF0\xE8\xE2\xE5\xF2",
+			expectedEncoding: common.FormatWin1251,
+			expectedText:     "Привет",
+		},
+		{
+			name:             "Windows-1252 declared as CP1252",
+			declaredEncoding: "CP1252",
+			encodedText:      "\x80 \x93ok\x94",
+			expectedEncoding: common.FormatWin1252,
+			expectedText:     "€ “ok”",
+		},
+		{
+			name:             "Windows-1258",
+			declaredEncoding: "WINDOWS-1258",
+			encodedText:      "Vi\xD2t",
+			expectedEncoding: common.FormatWin1258,
+			expectedText:     "Vỉt",
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			doc := fmt.Sprintf(codePageXml, tc.declaredEncoding, tc.encodedText, tc.encodedText)
+			parser, err := NewVtdParser(WithXmlDoc([]byte(doc)))
+			assert.Nil(t, err)
+			assert.Nil(t, parser.Parse())
+
+			nav, err := parser.GetNav()
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectedEncoding, nav.GetEncoding())
+
+			assertCurrentElement(t, nav, "doc")
+			assertMove(t, nav, navigation.FirstChild, "name")
+			index, err := nav.GetCurrentIndex()
+			assert.Nil(t, err)
+			raw, err := nav.ToRawStringAtIndex(int(index) + 1)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectedText, raw)
+
+			assertMove(t, nav, navigation.NextSibling, "text")
+			index, err = nav.GetCurrentIndex()
+			assert.Nil(t, err)
+			text, err := nav.ToStringAtIndex(int(index) + 1)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectedText+" & more", text)
+		})
+	}
+}
+
+func Test_VtdParser_Parse_CodePage_InvalidDocument(t *testing.T) {
+	testCases := []struct {
+		name           string
+		doc            string
+		expectedErrMsg string
+	}{
+		{
+			name:           "ISO-8859-12 is not a code page",
+			doc:            fmt.Sprintf(codePageXml, "ISO-8859-12", "a", "b"),
+			expectedErrMsg: "unsupported encoding: ISO-8859-12",
+		},
+		{
+			name:           "Windows-1259 is not a code page",
+			doc:            fmt.Sprintf(codePageXml, "windows-1259", "a", "b"),
+			expectedErrMsg: "unsupported encoding: windows-1259",
+		},
+		{
+			name:           "Byte undefined in Windows-1252",
+			doc:            fmt.Sprintf(codePageXml, "windows-1252", "\x81", "b"),
+			expectedErrMsg: "unknown character encoding: byte 0x81 is not defined in code page",
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			parser, err := NewVtdParser(WithXmlDoc([]byte(tc.doc)))
+			assert.Nil(t, err)
+			err = parser.Parse()
+			if assert.NotNil(t, err) {
+				assert.Contains(t, err.Error(), tc.expectedErrMsg)
+			}
+		})
+	}
+}
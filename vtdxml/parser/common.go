@@ -4,8 +4,11 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/buffer"
 	"github.com/alexZaicev/go-vtd-xml/vtdxml/common"
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/dtd"
 	"github.com/alexZaicev/go-vtd-xml/vtdxml/erroring"
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/reader"
 )
 
 type State int
@@ -34,13 +37,20 @@ const (
 	cdata   = "CDATA["
 	docType = "DOCTYPE"
 
+	// errorSnippetWidth is the number of characters taken on each side of
+	// parse error offset
+	errorSnippetWidth = 20
+
 	maxTokenLength  = (1 << 20) - 1
 	maxDepth        = 254
 	maxPrefixLength = (1 << 9) - 1
 	maxQnameLength  = (1 << 11) - 1
+	// nameOffsetBits is the number of bits of tag stack and attribute name
+	// entries taken by the name offset
+	nameOffsetBits = 40
 
 	XMLNS1998 = "http://www.w3.org/XML/1998/namespace"
-	XMLNS2000 = "http://www.w3.org/2000/xmlns"
+	XMLNS2000 = "http://www.w3.org/2000/xmlns/"
 )
 
 // setOffset function set custom offset to parser byte reader and sets
@@ -60,6 +70,20 @@ func (p *VtdParser) getChar() (uint32, error) {
 	return ch, nil
 }
 
+// decodeUtf8 function makes the UTF-8 reader return characters of multi-byte
+// sequences rather than their bytes, so that names holding such characters
+// are read whole. The function returned restores reading by bytes.
+func (p *VtdParser) decodeUtf8() func() {
+	r, ok := p.reader.(*reader.Utf8Reader)
+	if !ok || r.IsDecoding() {
+		return func() {}
+	}
+	r.SetDecoding(true)
+	return func() {
+		r.SetDecoding(false)
+	}
+}
+
 func (p *VtdParser) skipChar(ch uint32) bool {
 	skipped := p.reader.SkipChar(ch)
 	p.offset = p.reader.GetOffset()
@@ -123,22 +147,150 @@ func (p *VtdParser) nextCharAfterWs() error {
 	}
 }
 
+// packName function packs offset and length of an element or attribute name
+// into a tag stack or attribute name entry. Offset takes the lower
+// nameOffsetBits bits, so that documents parsed in extended VTD mode fit.
+func packName(offset, length int) int64 {
+	return int64(length)<<nameOffsetBits | int64(offset)
+}
+
+// unpackName function returns offset and length of the name packed by
+// packName
+func unpackName(entry int64) (int, int) {
+	return int(entry & (1<<nameOffsetBits - 1)), int(entry >> nameOffsetBits)
+}
+
 // writeVtd function writes into VTD buffer
 func (p *VtdParser) writeVtd(tokenType common.Token, offset, length, depth int) error {
+	if p.chunk != nil {
+		p.chunk.tokens = append(p.chunk.tokens, chunkToken{tokenType, offset, length, depth})
+		return nil
+	}
+	if err := p.checkTokenLimits(tokenType); err != nil {
+		return err
+	}
 	offset64, length64, depth64 := int64(offset), int64(length), int64(depth)
 	a := int64(tokenType << 28)
 	b := (a | ((depth64 & 0xff) << 20) | length64) << 32
-	return p.vtdBuffer.Append(b | offset64)
+	if p.extended {
+		// full offset goes to the offset buffer, the record keeps the bits
+		// that fit so that it reads the same as in standard mode
+		if err := p.offsetBuffer.Append(offset64); err != nil {
+			return err
+		}
+		offset64 &= int64(common.MaskTokenOffset)
+	}
+	if err := p.vtdBuffer.Append(b | offset64); err != nil {
+		return err
+	}
+	if p.tracer != nil {
+		p.tracer.TokenWritten(p.vtdBuffer.GetSize()-1, tokenType, offset, length, depth)
+	}
+	if p.handler != nil {
+		return p.notify(tokenType, offset, length, depth)
+	}
+	return nil
+}
+
+// appendLc function appends an entry to the location cache of the level
+func (p *VtdParser) appendLc(level int, entry int64) error {
+	var lc buffer.LongBuffer
+	switch level {
+	case 0:
+		lc = p.l0Buffer
+	case 1:
+		lc = p.l1Buffer
+	case 2:
+		lc = p.l2Buffer
+	case 3:
+		lc = p.l3Buffer
+	case 4:
+		lc = p.l4Buffer
+	default:
+		lc = p.l5Buffer
+	}
+	if err := lc.Append(entry); err != nil {
+		return err
+	}
+	if p.tracer != nil {
+		p.tracer.LcAppended(level, entry)
+	}
+	return nil
+}
+
+// writeVtdL3 function writes into VTD buffer and 3-level location cache
+func (p *VtdParser) writeVtdL3(tokenType common.Token, offset, length, depth int) error {
+	if p.chunk != nil {
+		// location cache is written when the chunk is joined
+		return p.writeVtd(tokenType, offset, length, depth)
+	}
+	if err := p.writeVtd(tokenType, offset, length, depth); err != nil {
+		return err
+	}
+	switch depth {
+	case 0:
+		if p.fragment {
+			return p.startFragmentRoot()
+		}
+		p.rootIndex = p.vtdBuffer.GetSize() - 1
+	case 1:
+		if p.lastDepth == 1 {
+			if err := p.appendLc(1, int64((p.lastL1Index<<32)|0xFFFFFFFF)); err != nil {
+				return err
+			}
+		} else if p.lastDepth == 2 {
+			if err := p.appendLc(2, int64((p.lastL2Index<<32)|0xFFFFFFFF)); err != nil {
+				return err
+			}
+		} else if p.lastDepth == 0 && p.fragment {
+			if err := p.appendLc(0, int64((p.lastL0Index<<32)+p.l1Buffer.GetSize())); err != nil {
+				return err
+			}
+		}
+		p.lastL1Index = p.vtdBuffer.GetSize() - 1
+		p.lastDepth = 1
+	case 2:
+		if p.lastDepth == 1 {
+			if err := p.appendLc(1, int64((p.lastL1Index<<32)+p.l2Buffer.GetSize())); err != nil {
+				return err
+			}
+		} else if p.lastDepth == 2 {
+			if err := p.appendLc(2, int64((p.lastL2Index<<32)|0xFFFFFFFF)); err != nil {
+				return err
+			}
+		}
+		p.lastL2Index = p.vtdBuffer.GetSize() - 1
+		p.lastDepth = 2
+	case 3:
+		// level 3 entries keep the token index in the upper 32 bits, the
+		// same layout the 5-level cache uses, so navigation reads both alike
+		if err := p.appendLc(3, int64(((p.vtdBuffer.GetSize()-1)<<32)|0xFFFFFFFF)); err != nil {
+			return err
+		}
+		if p.lastDepth == 2 {
+			if err := p.appendLc(2, int64((p.lastL2Index<<32)+p.l3Buffer.GetSize()-1)); err != nil {
+				return err
+			}
+		}
+		p.lastDepth = 3
+	}
+	return nil
 }
 
 // writeVtdL5 function writes into VTD buffer and location cache
 func (p *VtdParser) writeVtdL5(tokenType common.Token, offset, length, depth int) error {
+	if p.chunk != nil {
+		return p.writeVtd(tokenType, offset, length, depth)
+	}
 	if err := p.writeVtd(tokenType, offset, length, depth); err != nil {
 		return err
 	}
 	switch depth {
 	case 0:
 		{
+			if p.fragment {
+				return p.startFragmentRoot()
+			}
 			// TODO check if this can be moved to default
 			p.rootIndex = p.vtdBuffer.GetSize() - 1
 			break
@@ -146,19 +298,23 @@ func (p *VtdParser) writeVtdL5(tokenType common.Token, offset, length, depth int
 	case 1:
 		{
 			if p.lastDepth == 1 {
-				if err := p.l1Buffer.Append(int64((p.lastL1Index << 32) | 0xFFFF)); err != nil {
+				if err := p.appendLc(1, int64((p.lastL1Index<<32)|0xFFFFFFFF)); err != nil {
 					return err
 				}
 			} else if p.lastDepth == 2 {
-				if err := p.l2Buffer.Append(int64((p.lastL2Index << 32) | 0xFFFF)); err != nil {
+				if err := p.appendLc(2, int64((p.lastL2Index<<32)|0xFFFFFFFF)); err != nil {
 					return err
 				}
 			} else if p.lastDepth == 3 {
-				if err := p.l3Buffer.Append(int64((p.lastL3Index << 32) | 0xFFFF)); err != nil {
+				if err := p.appendLc(3, int64((p.lastL3Index<<32)|0xFFFFFFFF)); err != nil {
 					return err
 				}
 			} else if p.lastDepth == 4 {
-				if err := p.l4Buffer.Append(int64((p.lastL4Index << 32) | 0xFFFF)); err != nil {
+				if err := p.appendLc(4, int64((p.lastL4Index<<32)|0xFFFFFFFF)); err != nil {
+					return err
+				}
+			} else if p.lastDepth == 0 && p.fragment {
+				if err := p.appendLc(0, int64((p.lastL0Index<<32)+p.l1Buffer.GetSize())); err != nil {
 					return err
 				}
 			}
@@ -169,19 +325,19 @@ func (p *VtdParser) writeVtdL5(tokenType common.Token, offset, length, depth int
 	case 2:
 		{
 			if p.lastDepth == 1 {
-				if err := p.l1Buffer.Append(int64((p.lastL1Index << 32) + p.l2Buffer.GetSize())); err != nil {
+				if err := p.appendLc(1, int64((p.lastL1Index<<32)+p.l2Buffer.GetSize())); err != nil {
 					return err
 				}
 			} else if p.lastDepth == 2 {
-				if err := p.l2Buffer.Append(int64((p.lastL2Index << 32) | 0xFFFF)); err != nil {
+				if err := p.appendLc(2, int64((p.lastL2Index<<32)|0xFFFFFFFF)); err != nil {
 					return err
 				}
 			} else if p.lastDepth == 3 {
-				if err := p.l3Buffer.Append(int64((p.lastL3Index << 32) | 0xFFFF)); err != nil {
+				if err := p.appendLc(3, int64((p.lastL3Index<<32)|0xFFFFFFFF)); err != nil {
 					return err
 				}
 			} else if p.lastDepth == 4 {
-				if err := p.l4Buffer.Append(int64((p.lastL4Index << 32) | 0xFFFF)); err != nil {
+				if err := p.appendLc(4, int64((p.lastL4Index<<32)|0xFFFFFFFF)); err != nil {
 					return err
 				}
 			}
@@ -192,15 +348,15 @@ func (p *VtdParser) writeVtdL5(tokenType common.Token, offset, length, depth int
 	case 3:
 		{
 			if p.lastDepth == 2 {
-				if err := p.l2Buffer.Append(int64((p.lastL2Index << 32) + p.l3Buffer.GetSize())); err != nil {
+				if err := p.appendLc(2, int64((p.lastL2Index<<32)+p.l3Buffer.GetSize())); err != nil {
 					return err
 				}
 			} else if p.lastDepth == 3 {
-				if err := p.l3Buffer.Append(int64((p.lastL3Index << 32) | 0xFFFF)); err != nil {
+				if err := p.appendLc(3, int64((p.lastL3Index<<32)|0xFFFFFFFF)); err != nil {
 					return err
 				}
 			} else if p.lastDepth == 4 {
-				if err := p.l4Buffer.Append(int64((p.lastL4Index << 32) | 0xFFFF)); err != nil {
+				if err := p.appendLc(4, int64((p.lastL4Index<<32)|0xFFFFFFFF)); err != nil {
 					return err
 				}
 			}
@@ -211,11 +367,11 @@ func (p *VtdParser) writeVtdL5(tokenType common.Token, offset, length, depth int
 	case 4:
 		{
 			if p.lastDepth == 3 {
-				if err := p.l3Buffer.Append(int64((p.lastL3Index << 32) + p.l4Buffer.GetSize())); err != nil {
+				if err := p.appendLc(3, int64((p.lastL3Index<<32)+p.l4Buffer.GetSize())); err != nil {
 					return err
 				}
 			} else if p.lastDepth == 4 {
-				if err := p.l4Buffer.Append(int64((p.lastL4Index << 32) | 0xFFFF)); err != nil {
+				if err := p.appendLc(4, int64((p.lastL4Index<<32)|0xFFFFFFFF)); err != nil {
 					return err
 				}
 			}
@@ -225,11 +381,11 @@ func (p *VtdParser) writeVtdL5(tokenType common.Token, offset, length, depth int
 		}
 	case 5:
 		{
-			if err := p.l5Buffer.Append(int64(p.vtdBuffer.GetSize() - 1)); err != nil {
+			if err := p.appendLc(5, int64(p.vtdBuffer.GetSize()-1)); err != nil {
 				return err
 			}
 			if p.lastDepth == 4 {
-				if err := p.l4Buffer.Append(int64((p.lastL4Index << 32) + p.l5Buffer.GetSize() - 1)); err != nil {
+				if err := p.appendLc(4, int64((p.lastL4Index<<32)+p.l5Buffer.GetSize()-1)); err != nil {
 					return err
 				}
 			}
@@ -259,14 +415,14 @@ func (p *VtdParser) writeVtdText(tokenType common.Token, offset, length, depth i
 func (p *VtdParser) writeVtdWithLengthCheck(tokenType common.Token, errMsg string) error {
 	if p.singleByteEncoding {
 		if p.length1 > maxTokenLength {
-			return erroring.NewParseError(errMsg, p.fmtLine(), nil)
+			return p.newParseError(errMsg)
 		}
 		if err := p.writeVtd(tokenType, p.lastOffset, p.length1, p.depth); err != nil {
 			return err
 		}
 	} else {
 		if p.length1 > maxTokenLength<<1 {
-			return erroring.NewParseError(errMsg, p.fmtLine(), nil)
+			return p.newParseError(errMsg)
 		}
 		if err := p.writeVtd(tokenType, p.lastOffset>>1, p.length1>>1, p.depth); err != nil {
 			return err
@@ -275,47 +431,39 @@ func (p *VtdParser) writeVtdWithLengthCheck(tokenType common.Token, errMsg strin
 	return nil
 }
 
-// fmtLine function format error message with current line number and
-// character offset
-func (p *VtdParser) fmtLine() string {
-	return p.fmtCustomLine(p.offset)
+// newParseError function creates parse error located at the current offset
+func (p *VtdParser) newParseError(msg string) *erroring.ParseError {
+	return p.newParseErrorAt(p.offset, msg)
 }
 
-// fmtLine function format error message with custom line number and
-// character offset
-func (p *VtdParser) fmtCustomLine(offset int) string {
-	so := p.docOffset
-	lineNumber, lineOffset := 0, 0
-
-	if p.encoding < common.FormatUtf16BE {
-		for so <= offset-1 {
-			if p.xmlDoc[so] == '\n' {
-				lineNumber++
-				lineOffset = so
-			}
-			so++
-		}
-		lineOffset = offset - lineOffset
-	} else if p.encoding == common.FormatUtf16BE {
-		for so <= offset-2 {
-			if p.xmlDoc[so+1] == '\n' && p.xmlDoc[so] == 0 {
-				lineNumber++
-				lineOffset = so
-			}
-			so += 2
-		}
-		lineOffset = (offset - lineOffset) >> 1
-	} else {
-		for so <= offset-2 {
-			if p.xmlDoc[so] == '\n' && p.xmlDoc[so+1] == 0 {
-				lineNumber++
-				lineOffset = so
-			}
-			so += 2
+// newParseErrorAt function creates parse error located at the byte offset.
+// Line and column numbers are resolved through the line index built on the
+// first error, as parsing valid documents should not pay for it.
+func (p *VtdParser) newParseErrorAt(offset int, msg string) *erroring.ParseError {
+	err := erroring.NewParseError(msg, "", nil)
+	p.locateParseError(err, offset)
+	return err
+}
+
+// locateParseError function fills in position of the parse error
+func (p *VtdParser) locateParseError(err *erroring.ParseError, offset int) {
+	if offset < p.docOffset {
+		offset = p.docOffset
+	} else if offset > p.endOffset {
+		offset = p.endOffset
+	}
+	if p.lineIndex == nil || p.lineIndex.GetEncoding() != p.encoding || p.lineIndex.IsXml11() != p.xml11 {
+		if p.xml11 {
+			p.lineIndex = reader.NewXml11LineIndex(p.xmlDoc, p.docOffset, p.endOffset, p.encoding)
+		} else {
+			p.lineIndex = reader.NewLineIndex(p.xmlDoc, p.docOffset, p.endOffset, p.encoding)
 		}
-		lineOffset = (offset - lineOffset) >> 1
 	}
-	return fmt.Sprintf("\nLine number: %d Offset: %d", lineNumber+1, lineOffset+1)
+	err.Line, err.Column = p.lineIndex.Position(offset)
+	err.Offset = offset
+	err.State = p.state
+	err.Snippet = p.lineIndex.Snippet(offset, errorSnippetWidth)
+	err.LineNumber = fmt.Sprintf("\nLine number: %d Offset: %d", err.Line, err.Column)
 }
 
 // getPrevOffset function returns previous offset depending on XML document encoding
@@ -323,6 +471,7 @@ func (p *VtdParser) getPrevOffset() (int, error) {
 	prevOffset := p.offset
 	switch p.encoding {
 	case common.FormatUtf8:
+		prevOffset--
 		for p.xmlDoc[prevOffset]&byte(0xc0) == byte(0x80) {
 			prevOffset--
 		}
@@ -333,8 +482,14 @@ func (p *VtdParser) getPrevOffset() (int, error) {
 		common.FormatIso885915, common.FormatIso885916, common.FormatWin1250, common.FormatWin1251, common.FormatWin1252,
 		common.FormatWin1253, common.FormatWin1254, common.FormatWin1255, common.FormatWin1256, common.FormatWin1257, common.FormatWin1258:
 		return p.offset - 1, nil
-	case common.FormatUtf16LE, common.FormatUtf16BE:
-		temp := (uint32(p.xmlDoc[p.offset])&0xFFFF)<<8 | (uint32(p.xmlDoc[p.offset+1]) & 0xFFFF)
+	case common.FormatUtf16BE:
+		temp := uint32(p.xmlDoc[p.offset-2])<<8 | uint32(p.xmlDoc[p.offset-1])
+		if temp < 0xd800 || temp > 0xdfff {
+			return p.offset - 2, nil
+		}
+		return p.offset - 4, nil
+	case common.FormatUtf16LE:
+		temp := uint32(p.xmlDoc[p.offset-1])<<8 | uint32(p.xmlDoc[p.offset-2])
 		if temp < 0xd800 || temp > 0xdfff {
 			return p.offset - 2, nil
 		}
@@ -344,83 +499,108 @@ func (p *VtdParser) getPrevOffset() (int, error) {
 }
 
 func (p *VtdParser) decideEncoding() error {
-	if int32(p.xmlDoc[p.offset]) == -2 {
-		p.increment = 2
-		if int32(p.xmlDoc[p.offset+1]) == -1 {
-			p.offset += 2
-			p.encoding = common.FormatUtf16BE
-			p.bomDetected = true
-			// g.reader = reader.NewUtf16BeReader()
-		} else {
-			return erroring.NewEncodingError("should be 0xFF 0xFE")
-		}
-	} else if int32(p.xmlDoc[p.offset]) == -1 {
-		p.increment = 2
-		if int32(p.xmlDoc[p.offset+1]) == -2 {
-			p.offset += 2
-			p.encoding = common.FormatUtf16LE
-			p.bomDetected = true
-			// g.reader = reader.NewUtf16LeReader()
-		} else {
+	doc := p.xmlDoc[p.offset:p.endOffset]
+	if len(doc) < 2 {
+		return erroring.NewEOFError(erroring.XmlIncomplete)
+	}
+	switch {
+	case doc[0] == 0xFE:
+		if doc[1] != 0xFF {
+			return erroring.NewEncodingError("should be 0xFE 0xFF")
+		}
+		p.offset += 2
+		p.encoding = common.FormatUtf16BE
+		p.bomDetected = true
+	case doc[0] == 0xFF:
+		if doc[1] != 0xFE {
 			return erroring.NewEncodingError("not UTF-16LE")
 		}
-	} else if int32(p.xmlDoc[p.offset]) == 0 {
-		if int32(p.xmlDoc[p.offset+1]) == 0x3c &&
-			int32(p.xmlDoc[p.offset+2]) == 0 &&
-			int32(p.xmlDoc[p.offset+3]) == 0x3f {
-			p.encoding = common.FormatUtf16BE
-			p.increment = 2
-			// g.reader = reader.NewUtf16BeReader()
-		} else {
+		p.offset += 2
+		p.encoding = common.FormatUtf16LE
+		p.bomDetected = true
+	case doc[0] == 0:
+		if len(doc) < 4 || doc[1] != '<' || doc[2] != 0 || doc[3] != '?' {
 			return erroring.NewEncodingError("not UTF-16BE")
 		}
-	} else if int32(p.xmlDoc[p.offset]) == -17 {
-		if int32(p.xmlDoc[p.offset+1]) == -69 &&
-			int32(p.xmlDoc[p.offset+2]) == -65 {
-			p.offset += 3
-			p.mustUtf8 = true
-		} else {
+		p.encoding = common.FormatUtf16BE
+	case doc[0] == 0xEF:
+		if len(doc) < 3 || doc[1] != 0xBB || doc[2] != 0xBF {
 			return erroring.NewEncodingError("not UTF-8")
 		}
-	} else if int32(p.xmlDoc[p.offset]) == 0x3c {
-		if int32(p.xmlDoc[p.offset+1]) == 0 &&
-			int32(p.xmlDoc[p.offset+2]) == 0x3f &&
-			int32(p.xmlDoc[p.offset+3]) == 0 {
+		p.offset += 3
+		p.mustUtf8 = true
+	case doc[0] == '<':
+		// no need to return error if failed the condition
+		if len(doc) >= 4 && doc[1] == 0 && doc[2] == '?' && doc[3] == 0 {
 			p.encoding = common.FormatUtf16LE
-			p.increment = 2
-			// g.reader = reader.NewUtf16LeReader()
 		}
-		// no need to return error if failed the condition
 	}
 
-	if p.encoding < common.FormatUtf16BE {
-		if p.nsAware {
-			if (p.offset + p.docLength) >= 1<<30 {
-				return erroring.NewInternalError("file size too big >= 1GB", nil)
-			}
-		} else {
-			if (p.offset + p.docLength) >= 1<<31 {
-				return erroring.NewInternalError("file size too big >= 2GB", nil)
-			}
+	switch p.encoding {
+	case common.FormatUtf16BE:
+		r, err := reader.NewUtf16BeReader(p.xmlDoc, p.offset, p.endOffset)
+		if err != nil {
+			return err
 		}
-	} else {
-		if (p.offset + p.docLength) >= 1<<31 {
-			return erroring.NewInternalError("file size too big >= 2GB", nil)
+		p.reader = r
+		p.increment = 2
+	case common.FormatUtf16LE:
+		r, err := reader.NewUtf16LeReader(p.xmlDoc, p.offset, p.endOffset)
+		if err != nil {
+			return err
 		}
+		p.reader = r
+		p.increment = 2
+	default:
+		p.reader.SetOffset(p.offset)
 	}
 
-	if p.encoding >= common.FormatUtf16BE {
+	if p.encoding.IsUtf16() {
 		p.singleByteEncoding = false
 	}
+	return p.decideVtdMode()
+}
+
+// decideVtdMode function switches the parser to extended VTD mode when token
+// offsets of the document do not fit the VTD record, or when extended mode is
+// forced by WithExtendedVtd
+func (p *VtdParser) decideVtdMode() error {
+	if p.nsAware && p.endOffset > MaxNsAwareDocumentSize {
+		// namespace bookkeeping keeps 32-bit offsets
+		return erroring.NewInternalError("file size too big >= 2GB", nil)
+	}
+	p.extended = p.forceExtended || !fitsVtdRecord(p.endOffset, p.singleByteEncoding)
+	if !p.extended {
+		return nil
+	}
+	if p.offsetBuffer == nil {
+		b, err := buffer.NewFastLongBuffer([]buffer.FastLongBufferOption{
+			buffer.WithFastLongBufferPageSize(pageExp(p.docLength >> 4)),
+		}...)
+		if err != nil {
+			return err
+		}
+		p.offsetBuffer = b
+	}
 	return nil
 }
 
+// fitsVtdRecord function returns true if every token offset of the document
+// ending at endOffset fits the offset bits of the VTD record. Offsets of
+// UTF-16 documents are counted in 16-bit units.
+func fitsVtdRecord(endOffset int, singleByteEncoding bool) bool {
+	if !singleByteEncoding {
+		endOffset >>= 1
+	}
+	return uint64(endOffset) <= common.MaskTokenOffset+1
+}
+
 // checkXmlPrefix functions checks XML character sequence in XML document
 // byte array starting from offset. Length is passed to validate correct length
 // of the expected sequence
 func (p *VtdParser) checkXmlPrefix(offset, length int, checkLength bool) bool {
 	var valid bool
-	if p.encoding < common.FormatUtf16BE {
+	if !p.encoding.IsUtf16() {
 		valid = p.xmlDoc[offset] == 'x' &&
 			p.xmlDoc[offset+1] == 'm' &&
 			p.xmlDoc[offset+2] == 'l'
@@ -441,7 +621,7 @@ func (p *VtdParser) checkXmlPrefix(offset, length int, checkLength bool) bool {
 	}
 
 	if valid && checkLength {
-		valid = (p.encoding < common.FormatUtf16BE && length == 4) || length == 8
+		valid = length == 4*p.increment
 	}
 	return valid
 }
@@ -451,7 +631,7 @@ func (p *VtdParser) checkXmlPrefix(offset, length int, checkLength bool) bool {
 // of the expected sequence
 func (p *VtdParser) checkXmlnsPrefix(offset, length int, checkLength bool) bool {
 	var valid bool
-	if p.encoding < common.FormatUtf16BE {
+	if !p.encoding.IsUtf16() {
 		valid = p.xmlDoc[offset] == 'x' &&
 			p.xmlDoc[offset+1] == 'm' &&
 			p.xmlDoc[offset+2] == 'l' &&
@@ -482,52 +662,15 @@ func (p *VtdParser) checkXmlnsPrefix(offset, length int, checkLength bool) bool
 	}
 
 	if valid && checkLength {
-		valid = (p.encoding < common.FormatUtf16BE && length == 5) || length == 10
+		valid = length == 5*p.increment
 	}
 	return valid
 }
 
-// qualifyElement function does basic qualification on XML element by the
-// following criteria:
-// (1) current element has no prefix, then look for XMLNS;
-// (2) current element has prefix, then look for XMLNS:<SOMETHING>
-func (p *VtdParser) qualifyElement() error {
-	preLen := int(int32((p.currentElementRecord & 0xFFFF00000000000) >> 48))
-	preOs := int(int32(p.currentElementRecord))
-	for i := p.nsBuffer3.GetSize() - 1; i >= 0; i-- {
-		upVal, err := p.nsBuffer3.Upper32At(i)
-		if err != nil {
-			return err
-		}
-		diff := int((upVal & 0xFFFF) - (upVal >> 16))
-		if diff == preLen {
-			lowVal, err := p.nsBuffer3.Lower32At(i)
-			if err != nil {
-				return nil
-			}
-			os := int(lowVal+(upVal>>16)) + p.increment
-			var j int
-			for ; j < preLen-p.increment; j++ {
-				if p.xmlDoc[os+j] != p.xmlDoc[preOs+j] {
-					break
-				}
-			}
-			if j == preLen-p.increment {
-				return nil
-			}
-		}
-	}
-	if p.checkXmlPrefix(preOs, preLen, true) {
-		return nil
-	}
-	return erroring.NewParseError("namespace qualification exception: element not qualified",
-		p.fmtCustomLine(preOs), nil)
-}
-
-// recordWhiteSpace function record whitespaces into VTD text buffer that are
-// ignored by default
+// recordWhiteSpace function records whitespace-only text into VTD buffer when
+// the whitespace policy keeps it
 func (p *VtdParser) recordWhiteSpace() error {
-	if p.depth > -1 {
+	if p.depth > -1 && p.keepWhiteSpace() {
 		length := p.offset - p.increment - p.lastOffset
 		if length != 0 {
 			if p.singleByteEncoding {
@@ -545,13 +688,9 @@ func (p *VtdParser) handleOtherTextChar(ch uint32) error {
 	switch ch {
 	case '&':
 		{
-			ch2, err := p.entityIdentifier()
-			if err != nil {
+			if err := p.entityIdentifier(false); err != nil {
 				return err
 			}
-			if !p.xmlChar.IsValidChar(ch2) {
-				return erroring.NewParseError(erroring.InvalidCharInText, p.fmtLine(), nil)
-			}
 			break
 		}
 	case ']':
@@ -560,128 +699,115 @@ func (p *VtdParser) handleOtherTextChar(ch uint32) error {
 			for p.skipChar(']') {
 			}
 			if p.skipChar('>') {
-				return erroring.NewParseError("]]> sequence in text content", p.fmtLine(), nil)
+				return p.newParseError("]]> sequence in text content")
 			}
 			break
 		}
 	default:
-		return erroring.NewParseError(erroring.InvalidCharInText, p.fmtLine(), nil)
+		return p.newParseError(erroring.InvalidCharInText)
 	}
 	return nil
 }
 
-// entityIdentifier function validates the preceding sequence of characters that
-// started with & is a valid XML entity, e.g. &amp; or &quot; and returns it
-// real byte equivalent
-func (p *VtdParser) entityIdentifier() (uint32, error) {
-
-	checkSeq := func(seq string) error {
-		for _, seqCh := range seq {
-			ch, err := p.getChar()
-			if err != nil {
-				return err
-			}
-			if int32(ch) != seqCh {
-				return erroring.NewEntityError(erroring.IllegalBuiltInEntity)
-			}
+// entityIdentifier function validates the sequence of characters following
+// & is a valid entity reference, e.g. &amp; or &#x26;. References to
+// declared general entities must expand without errors, in attribute values
+// their replacement text must not contain < character.
+func (p *VtdParser) entityIdentifier(attrVal bool) error {
+	if p.skipChar('#') {
+		_, err := p.charReference()
+		return err
+	}
+	defer p.decodeUtf8()()
+	start := p.offset
+	ch, err := p.getChar()
+	if err != nil {
+		return err
+	}
+	if !p.xmlChar.IsNameStartChar(ch) {
+		return erroring.NewEntityError("illegal entity character")
+	}
+	for p.xmlChar.IsNameChar(ch) {
+		if ch, err = p.getChar(); err != nil {
+			return err
 		}
+	}
+	if ch != ';' {
+		return erroring.NewEntityError("entity reference must end with ;")
+	}
+	name, err := p.docString(start, p.offset-p.increment-start)
+	if err != nil {
+		return err
+	}
+	if _, ok := dtd.BuiltInEntity(name); ok {
 		return nil
 	}
+	if p.dtd == nil {
+		return erroring.NewEntityError(erroring.IllegalBuiltInEntity)
+	}
+	text, err := p.dtd.ExpandEntity(name, p.entityExpansion, p.entityExpansionLimit())
+	if err != nil {
+		return err
+	}
+	p.entityExpansion += len(text)
+	if attrVal && strings.ContainsRune(text, '<') {
+		return erroring.NewEntityError(fmt.Sprintf("entity %s referenced in attribute value contains <", name))
+	}
+	return nil
+}
+
+// docString function decodes the document text at the offset and of the
+// length given in bytes
+func (p *VtdParser) docString(offset, length int) (string, error) {
+	if !p.singleByteEncoding {
+		offset, length = offset>>1, length>>1
+	}
+	return Event{Offset: offset, Length: length, p: p}.RawString()
+}
 
+// charReference function validates decimal or hexadecimal character
+// reference following &# and returns the character it stands for
+func (p *VtdParser) charReference() (uint32, error) {
+	var value uint32
 	ch, err := p.getChar()
 	if err != nil {
 		return 0, err
 	}
-	switch ch {
-	case '#':
-		{
-			var value uint32
+	if ch == 'x' {
+		for {
 			ch, err = p.getChar()
 			if err != nil {
 				return 0, err
 			}
-			if ch == 'x' {
-				for {
-					ch, err = p.getChar()
-					if err != nil {
-						return 0, err
-					}
-					if ch >= '0' && ch <= '9' {
-						value = (value << 4) + (ch - '0')
-					} else if ch >= 'a' && ch <= 'f' {
-						value = (value << 4) + (ch - 'a' + 10)
-					} else if ch >= 'A' && ch <= 'F' {
-						value = (value << 4) + (ch - 'A' + 10)
-					} else if ch == ';' {
-						break
-					} else {
-						return 0, erroring.NewEntityError("illegal char following &#x")
-					}
-				}
+			if ch >= '0' && ch <= '9' {
+				value = (value << 4) + (ch - '0')
+			} else if ch >= 'a' && ch <= 'f' {
+				value = (value << 4) + (ch - 'a' + 10)
+			} else if ch >= 'A' && ch <= 'F' {
+				value = (value << 4) + (ch - 'A' + 10)
+			} else if ch == ';' {
+				break
 			} else {
-				for {
-					if ch >= '0' && ch <= '9' {
-						value = value*10 + (ch - '0')
-					} else if ch == ';' {
-						break
-					} else {
-						return 0, erroring.NewEntityError("illegal char following &#x")
-					}
-					ch, err = p.getChar()
-					if err != nil {
-						return 0, err
-					}
-				}
+				return 0, erroring.NewEntityError("illegal char following &#x")
 			}
-			if !p.xmlChar.IsValidChar(value) {
-				return 0, erroring.NewEntityError(erroring.InvalidChar)
-			}
-			return value, nil
 		}
-	case 'a':
-		{
-			// checks that the sequence matcher &amp;
-			ch2, err := p.getChar()
-			if err != nil {
-				return 0, err
-			}
-			if ch2 == 'm' {
-				// checks that the sequence matcher &amp;
-				if err := checkSeq("p;"); err != nil {
-					return 0, err
-				}
-				return '&', nil
-			} else if ch2 == 'p' {
-				// checks that the sequence matcher &apos;
-				if err := checkSeq("os;"); err != nil {
-					return 0, err
-				}
-				return '\'', nil
+	} else {
+		for {
+			if ch >= '0' && ch <= '9' {
+				value = value*10 + (ch - '0')
+			} else if ch == ';' {
+				break
 			} else {
-				return 0, erroring.NewEntityError(erroring.IllegalBuiltInEntity)
+				return 0, erroring.NewEntityError("illegal char following &#x")
 			}
-		}
-	case 'q':
-		{
-			// checks that the sequence matcher &quot;
-			if err := checkSeq("uot;"); err != nil {
-				return 0, err
-			}
-			return '"', nil
-		}
-	case 'g', 'l':
-		{
-			// checks that the sequence matcher &gt; or &lt;
-			if err := checkSeq("t;"); err != nil {
+			ch, err = p.getChar()
+			if err != nil {
 				return 0, err
 			}
-			if ch == 'g' {
-				return '>', nil
-			} else {
-				return '<', nil
-			}
 		}
-	default:
-		return 0, erroring.NewEntityError("illegal entity character")
 	}
+	if !p.xmlChar.IsReferenceChar(value) {
+		return 0, erroring.NewEntityError(erroring.InvalidChar)
+	}
+	return value, nil
 }
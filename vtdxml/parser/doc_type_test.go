@@ -0,0 +1,213 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/dtd"
+	"github.com/stretchr/testify/assert"
+)
+
+const docTypeXml = `<?xml version="1.0"?>
+<!DOCTYPE feed SYSTEM "feed.dtd" [
+  <!-- legacy entities -->
+  <!ELEMENT feed (item)*>
+  <!ATTLIST item id ID #REQUIRED note CDATA "a > b">
+  <?pi data?>
+  <!ENTITY company "Acme &amp; Co">
+  <!ENTITY copy "&#169; &company;">
+  <!ENTITY % local "ignored">
+  %local;
+  <!ENTITY logo SYSTEM "logo.png" NDATA png>
+  <!NOTATION png SYSTEM "image/png">
+]>
+<feed><item id="i1" note="&company;">&copy; 2024</item></feed>`
+
+func Test_VtdParser_Parse_DocType_Success(t *testing.T) {
+	testCases := []struct {
+		name string
+		doc  []byte
+	}{
+		{
+			name: "UTF-8",
+			doc:  []byte(docTypeXml),
+		},
+		{
+			name: "UTF-16LE",
+			doc:  encodeUtf16(docTypeXml, false, true),
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			parser, err := NewVtdParser(WithXmlDoc(tc.doc))
+			assert.Nil(t, err)
+			assert.Nil(t, parser.Parse())
+
+			d := parser.GetDtd()
+			if !assert.NotNil(t, d) {
+				return
+			}
+			assert.Equal(t, "feed", d.Name)
+			assert.Equal(t, "feed.dtd", d.SystemID)
+			assert.Equal(t, 3, d.GetEntityCount())
+
+			expectedDecls := []struct {
+				declType dtd.DeclarationType
+				name     string
+				content  string
+				raw      string
+			}{
+				{dtd.DeclarationElement, "feed", "(item)*", "<!ELEMENT feed (item)*>"},
+				{dtd.DeclarationAttlist, "item", `id ID #REQUIRED note CDATA "a > b"`, `<!ATTLIST item id ID #REQUIRED note CDATA "a > b">`},
+				{dtd.DeclarationEntity, "company", "", `<!ENTITY company "Acme &amp; Co">`},
+				{dtd.DeclarationEntity, "copy", "", `<!ENTITY copy "&#169; &company;">`},
+				{dtd.DeclarationEntity, "local", "", `<!ENTITY % local "ignored">`},
+				{dtd.DeclarationEntity, "logo", "", `<!ENTITY logo SYSTEM "logo.png" NDATA png>`},
+				{dtd.DeclarationNotation, "png", `SYSTEM "image/png"`, `<!NOTATION png SYSTEM "image/png">`},
+			}
+			nav, err := parser.GetNav()
+			assert.Nil(t, err)
+			if assert.Len(t, d.Declarations, len(expectedDecls)) {
+				for i, expected := range expectedDecls {
+					decl := d.Declarations[i]
+					assert.Equal(t, expected.declType, decl.Type)
+					assert.Equal(t, expected.name, decl.Name)
+					assert.Equal(t, expected.content, decl.Content)
+					raw, err := nav.ToRawStringAtRange(int32(decl.Offset), int32(decl.Length))
+					assert.Nil(t, err)
+					assert.Equal(t, expected.raw, raw)
+				}
+			}
+
+			copyEntity, ok := d.GetEntity("copy")
+			if assert.True(t, ok) {
+				assert.Equal(t, "© &company;", copyEntity.Value)
+			}
+			logo, ok := d.GetEntity("logo")
+			if assert.True(t, ok) {
+				assert.True(t, logo.IsUnparsed())
+				assert.Equal(t, "png", logo.Notation)
+			}
+			local, ok := d.GetParameterEntity("local")
+			if assert.True(t, ok) {
+				assert.Equal(t, "ignored", local.Value)
+			}
+
+			assert.Equal(t, d, nav.GetDtd())
+			// attribute value and text referencing declared entities
+			for index, expected := range map[int]string{9: "Acme & Co", 10: "© Acme & Co 2024"} {
+				text, err := nav.ToStringAtIndex(index)
+				assert.Nil(t, err)
+				assert.Equal(t, expected, text)
+			}
+		})
+	}
+}
+
+func Test_VtdParser_Parse_DocType_NonAsciiEntityName(t *testing.T) {
+	const doc = `<!DOCTYPE a [<!ENTITY café "coffee">]><a x="&café;">&café;</a>`
+	const latin1Decl = `<?xml version="1.0" encoding="ISO-8859-1"?>`
+	var latin1Doc []byte
+	for _, r := range latin1Decl + doc {
+		latin1Doc = append(latin1Doc, byte(r))
+	}
+
+	testCases := []struct {
+		name string
+		doc  []byte
+	}{
+		{
+			name: "UTF-8",
+			doc:  []byte(doc),
+		},
+		{
+			name: "UTF-16LE",
+			doc:  encodeUtf16(doc, false, true),
+		},
+		{
+			name: "ISO-8859-1",
+			doc:  latin1Doc,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			nav := parseTestNav(t, tc.doc)
+			text, err := nav.ToStringAtIndex(nav.GetVtdBufferSize() - 1)
+			assert.Nil(t, err)
+			assert.Equal(t, "coffee", text)
+		})
+	}
+}
+
+func Test_VtdParser_Parse_DocType_Failed(t *testing.T) {
+	testCases := []struct {
+		name           string
+		doc            string
+		expectedErrMsg string
+	}{
+		{
+			name:           "undeclared entity",
+			doc:            `<!DOCTYPE a [<!ENTITY b "b">]><a>&c;</a>`,
+			expectedErrMsg: "unknown character encoding: undeclared entity c",
+		},
+		{
+			name:           "entity without DOCTYPE",
+			doc:            `<a>&c;</a>`,
+			expectedErrMsg: "unknown character encoding: illegal build-in entity reference",
+		},
+		{
+			name:           "recursive entity",
+			doc:            `<!DOCTYPE a [<!ENTITY b "&c;"><!ENTITY c "x&b;">]><a>&b;</a>`,
+			expectedErrMsg: "unknown character encoding: recursive reference to entity b",
+		},
+		{
+			name:           "entity with < in attribute value",
+			doc:            `<!DOCTYPE a [<!ENTITY b "<i/>">]><a x="&b;"/>`,
+			expectedErrMsg: "unknown character encoding: entity b referenced in attribute value contains <",
+		},
+		{
+			name:           "external entity",
+			doc:            `<!DOCTYPE a [<!ENTITY b SYSTEM "b.xml">]><a>&b;</a>`,
+			expectedErrMsg: "unknown character encoding: external entity b is not resolved",
+		},
+		{
+			name:           "unparsed entity",
+			doc:            `<!DOCTYPE a [<!ENTITY b SYSTEM "b.png" NDATA png>]><a>&b;</a>`,
+			expectedErrMsg: "unknown character encoding: reference to unparsed entity b",
+		},
+		{
+			name:           "parameter entity reference in entity value",
+			doc:            `<!DOCTYPE a [<!ENTITY % b "b"><!ENTITY c "%b;">]><a/>`,
+			expectedErrMsg: "a parse error occurred: parameter entity reference in internal subset declaration",
+		},
+		{
+			name:           "unknown markup declaration",
+			doc:            `<!DOCTYPE a [<!ELEMENTS a ANY>]><a/>`,
+			expectedErrMsg: "a parse error occurred: unknown markup declaration ELEMENTS",
+		},
+		{
+			name:           "missing document element name",
+			doc:            `<!DOCTYPE [<!ELEMENT a ANY>]><a/>`,
+			expectedErrMsg: "a parse error occurred: invalid name in DOCTYPE",
+		},
+		{
+			name:           "DOCTYPE after root element",
+			doc:            `<a/><!DOCTYPE a>`,
+			expectedErrMsg: "a parse error occurred: XML not terminated properly",
+		},
+		{
+			name:           "second DOCTYPE",
+			doc:            `<!DOCTYPE a><!DOCTYPE a><a/>`,
+			expectedErrMsg: "a parse error occurred: wrong place for DOCTYPE",
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			parser, err := NewVtdParser(WithXmlDoc([]byte(tc.doc)))
+			assert.Nil(t, err)
+			assert.EqualError(t, parser.Parse(), tc.expectedErrMsg)
+		})
+	}
+}
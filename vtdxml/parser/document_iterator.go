@@ -0,0 +1,163 @@
+package parser
+
+import (
+	"context"
+	"io"
+
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/erroring"
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/navigation"
+)
+
+// DocumentIterator parses input holding several complete XML documents back
+// to back, e.g. a file of messages each starting with its own XML
+// declaration. A document ends where the XML declaration or the root element
+// of the next one starts, whitespace, comments and processing instructions in
+// between belong to the document before them. Documents are parsed one at a
+// time by a single parser whose buffers are reused via Clear.
+//
+//	it := parser.NewDocumentIterator(input)
+//	for it.Next() {
+//		nav := it.Nav()
+//		offset, length := it.Range()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type DocumentIterator struct {
+	ctx    context.Context
+	input  []byte
+	offset int
+	opts   []Option
+	parser *VtdParser
+	nav    *navigation.VtdNav
+	length int
+	err    error
+}
+
+// NewDocumentIterator function creates iterator over documents held by the
+// input, parsed with options provided. Document options, e.g. WithXmlDoc,
+// are set by the iterator for each document.
+func NewDocumentIterator(input []byte, opts ...Option) *DocumentIterator {
+	it := &DocumentIterator{
+		ctx:   context.Background(),
+		input: input,
+		opts:  opts,
+	}
+	if input == nil {
+		it.err = erroring.NewInvalidArgumentError("input", erroring.CannotBeNil, nil)
+	}
+	return it
+}
+
+// NewReaderDocumentIterator function reads the whole input from the reader
+// and creates iterator over documents it holds. The input is fully buffered
+// in memory before the first document is parsed, reading stops with an error
+// as soon as it exceeds the maximum document size (see WithMaxDocumentSize).
+// Reading and parsing stop once the context is done.
+func NewReaderDocumentIterator(ctx context.Context, r io.Reader, opts ...Option) (*DocumentIterator, error) {
+	if r == nil {
+		return nil, erroring.NewInvalidArgumentError("r", erroring.CannotBeNil, nil)
+	}
+	maxSize, err := maxDocumentSizeOf(opts)
+	if err != nil {
+		return nil, err
+	}
+	input, err := readDocument(ctx, r, sizeHint(r), maxSize)
+	if err != nil {
+		return nil, err
+	}
+	it := NewDocumentIterator(input, opts...)
+	it.ctx = ctx
+	return it, nil
+}
+
+// Next function parses the next document and returns true if there is one.
+// It returns false once the input is exhausted or parsing fails, see Err.
+func (it *DocumentIterator) Next() bool {
+	it.nav = nil
+	if it.err != nil {
+		return false
+	}
+	if it.parser != nil {
+		it.offset = it.parser.endOffset
+	}
+	if it.atEnd() {
+		return false
+	}
+
+	doc := WithXmlDocCustomOffset(it.input, it.offset, len(it.input)-it.offset)
+	if it.parser == nil {
+		p, err := NewVtdParser(append(it.opts[:len(it.opts):len(it.opts)], WithBufferReuse(true), doc)...)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		p.stream = true
+		it.parser = p
+	} else if err := it.parser.Reset(doc); err != nil {
+		it.err = err
+		return false
+	}
+	if err := it.parser.ParseContext(it.ctx); err != nil {
+		it.err = err
+		return false
+	}
+	nav, err := it.parser.GetNav()
+	if err != nil {
+		it.err = err
+		return false
+	}
+	it.nav, it.length = nav, it.parser.docLength
+	return true
+}
+
+// atEnd function returns true if only whitespace is left of the input
+func (it *DocumentIterator) atEnd() bool {
+	for _, b := range it.input[it.offset:] {
+		if b != ' ' && b != '\t' && b != '\n' && b != '\r' {
+			return false
+		}
+	}
+	return true
+}
+
+// Nav function returns navigation object of the current document positioned
+// at its root element. Navigation objects stay valid once the iterator moves
+// on, offsets of their tokens are offsets in the whole input.
+func (it *DocumentIterator) Nav() *navigation.VtdNav {
+	return it.nav
+}
+
+// Range function returns offset and length of the current document in the
+// input
+func (it *DocumentIterator) Range() (int, int) {
+	return it.offset, it.length
+}
+
+// Err function returns the error that stopped iteration, or nil if the input
+// was exhausted
+func (it *DocumentIterator) Err() error {
+	return it.err
+}
+
+// endAtNextDocument function returns true if < just read starts the next
+// document of the input, with its XML declaration or its root element, and
+// ends the document being parsed right before it
+func (p *VtdParser) endAtNextDocument() bool {
+	lt, offset := p.offset-p.increment, p.offset
+	next := false
+	if p.skipCharSeq("?xml") {
+		ch, err := p.getChar()
+		next = err == nil && p.xmlChar.IsSpaceChar(ch)
+	} else if !p.fragment {
+		p.setOffset(offset)
+		ch, err := p.getChar()
+		next = err == nil && p.xmlChar.IsNameStartChar(ch)
+	}
+	p.setOffset(offset)
+	if next {
+		p.endOffset, p.docLength = lt, lt-p.docOffset
+	}
+	return next
+}
@@ -0,0 +1,102 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"testing/iotest"
+
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/navigation"
+	"github.com/stretchr/testify/assert"
+)
+
+var streamDocs = []string{
+	"<?xml version=\"1.0\"?>\n<a><b/></a>\n",
+	"<?xml version=\"1.0\" encoding=\"UTF-8\"?><c x=\"1\">text</c><!-- trailer --><?pi?>\n\n",
+	"<?xml version=\"1.0\"?><d><?xml-stylesheet href=\"s\"?><e/></d>",
+}
+
+type streamDoc struct {
+	root string
+	doc  string
+}
+
+func iterateDocuments(t *testing.T, input []byte, it *DocumentIterator) []streamDoc {
+	var docs []streamDoc
+	for it.Next() {
+		nav := it.Nav()
+		index, err := nav.GetCurrentIndex()
+		assert.Nil(t, err)
+		root, err := nav.ToRawStringAtIndex(int(index))
+		assert.Nil(t, err)
+		offset, length := it.Range()
+		docs = append(docs, streamDoc{root: root, doc: string(input[offset : offset+length])})
+	}
+	return docs
+}
+
+func Test_DocumentIterator_Success(t *testing.T) {
+	expected := []streamDoc{
+		{root: "a", doc: streamDocs[0]},
+		{root: "c", doc: streamDocs[1]},
+		{root: "d", doc: streamDocs[2] + "\n"},
+	}
+	input := []byte(streamDocs[0] + streamDocs[1] + streamDocs[2] + "\n")
+
+	it := NewDocumentIterator(input, WithNameSpaceAware(true))
+	assert.Equal(t, expected, iterateDocuments(t, input, it))
+	assert.Nil(t, it.Err())
+	assert.Nil(t, it.Nav())
+	assert.False(t, it.Next())
+
+	it, err := NewReaderDocumentIterator(context.Background(), iotest.OneByteReader(bytes.NewReader(input)))
+	assert.Nil(t, err)
+	assert.Equal(t, expected, iterateDocuments(t, input, it))
+	assert.Nil(t, it.Err())
+}
+
+func Test_DocumentIterator_WithoutDeclarations(t *testing.T) {
+	input := []byte("<a/>\n<b><c/></b><d/>")
+	it := NewDocumentIterator(input)
+	assert.Equal(t, []streamDoc{
+		{root: "a", doc: "<a/>\n"},
+		{root: "b", doc: "<b><c/></b>"},
+		{root: "d", doc: "<d/>"},
+	}, iterateDocuments(t, input, it))
+	assert.Nil(t, it.Err())
+}
+
+func Test_DocumentIterator_NavigationKept(t *testing.T) {
+	it := NewDocumentIterator([]byte(streamDocs[0] + streamDocs[2]))
+	var navs []*navigation.VtdNav
+	for it.Next() {
+		navs = append(navs, it.Nav())
+	}
+	assert.Nil(t, it.Err())
+	if assert.Len(t, navs, 2) {
+		assertMove(t, navs[0], navigation.FirstChild, "b")
+		assertMove(t, navs[1], navigation.FirstChild, "e")
+	}
+}
+
+func Test_DocumentIterator_Failed(t *testing.T) {
+	input := []byte(streamDocs[0] + "<?xml version=\"1.0\"?><c></d>" + streamDocs[2])
+	it := NewDocumentIterator(input)
+	assert.Equal(t, []streamDoc{{root: "a", doc: streamDocs[0]}}, iterateDocuments(t, input, it))
+	assert.EqualError(t, it.Err(), "a parse error occurred: start/end tag mismatch")
+	assert.False(t, it.Next())
+
+	it = NewDocumentIterator(nil)
+	assert.False(t, it.Next())
+	assert.EqualError(t, it.Err(), "invalid argument input: cannot be nil")
+
+	_, err := NewReaderDocumentIterator(context.Background(), nil)
+	assert.EqualError(t, err, "invalid argument r: cannot be nil")
+
+	input = []byte(streamDocs[0] + streamDocs[2])
+	_, err = NewReaderDocumentIterator(context.Background(), bytes.NewReader(input), WithMaxDocumentSize(len(input)-1))
+	assert.EqualError(t, err, "invalid argument xmlDoc: document exceeds maximum size")
+
+	_, err = NewReaderDocumentIterator(context.Background(), bytes.NewReader(input), WithMaxDocumentSize(-1))
+	assert.EqualError(t, err, "invalid argument maxDocumentSize: array index out of range")
+}
@@ -0,0 +1,151 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/common"
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/erroring"
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/reader"
+)
+
+// encodingUtf16 is the encoding name that requires a byte order mark to
+// resolve byte order
+const encodingUtf16 = "utf-16"
+
+// encodingAliases maps lower-case encoding names accepted in the XML
+// declaration to document encodings
+var encodingAliases = newEncodingAliases()
+
+func newEncodingAliases() map[string]common.FormatEncoding {
+	aliases := map[string]common.FormatEncoding{
+		"ascii":      common.FormatAscii,
+		"us-ascii":   common.FormatAscii,
+		"utf-8":      common.FormatUtf8,
+		"utf8":       common.FormatUtf8,
+		"utf-16be":   common.FormatUtf16BE,
+		"utf16be":    common.FormatUtf16BE,
+		"utf-16le":   common.FormatUtf16LE,
+		"utf16le":    common.FormatUtf16LE,
+		"latin1":     common.FormatIso88591,
+		"latin2":     common.FormatIso88592,
+		"latin3":     common.FormatIso88593,
+		"latin4":     common.FormatIso88594,
+		"cyrillic":   common.FormatIso88595,
+		"arabic":     common.FormatIso88596,
+		"greek":      common.FormatIso88597,
+		"hebrew":     common.FormatIso88598,
+		"latin5":     common.FormatIso88599,
+		"latin6":     common.FormatIso885910,
+		"latin7":     common.FormatIso885913,
+		"latin8":     common.FormatIso885914,
+		"latin9":     common.FormatIso885915,
+		"latin10":    common.FormatIso885916,
+		"l1":         common.FormatIso88591,
+		"cp819":      common.FormatIso88591,
+		"iso-ir-100": common.FormatIso88591,
+	}
+	isoParts := map[int]common.FormatEncoding{
+		1: common.FormatIso88591, 2: common.FormatIso88592, 3: common.FormatIso88593, 4: common.FormatIso88594,
+		5: common.FormatIso88595, 6: common.FormatIso88596, 7: common.FormatIso88597, 8: common.FormatIso88598,
+		9: common.FormatIso88599, 10: common.FormatIso885910, 11: common.FormatIso885911,
+		13: common.FormatIso885913, 14: common.FormatIso885914, 15: common.FormatIso885915,
+		16: common.FormatIso885916,
+	}
+	for part, enc := range isoParts {
+		for _, prefix := range []string{"iso-8859-", "iso8859-", "iso_8859-", "iso8859_", "iso8859"} {
+			aliases[fmt.Sprintf("%s%d", prefix, part)] = enc
+		}
+	}
+	for i := 0; i <= 8; i++ {
+		enc := common.FormatWin1250 + common.FormatEncoding(i)
+		for _, prefix := range []string{"windows-", "cp"} {
+			aliases[fmt.Sprintf("%s%d", prefix, 1250+i)] = enc
+		}
+	}
+	return aliases
+}
+
+// isEncodingNameChar function checks that the character may appear in an
+// encoding name (EncName production of the XML specification)
+func isEncodingNameChar(ch uint32) bool {
+	return (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9') ||
+		ch == '-' || ch == '_' || ch == '.'
+}
+
+// switchEncoding function resolves the encoding name declared in the XML
+// declaration and replaces the byte reader when the declared encoding
+// differs from the one detected by decideEncoding. It returns an
+// EncodingError when the declaration contradicts the byte order mark or the
+// detected byte order.
+func (p *VtdParser) switchEncoding(name string) error {
+	lowerName := strings.ToLower(name)
+	if lowerName == encodingUtf16 || lowerName == "utf16" {
+		if p.singleByteEncoding {
+			return p.contradictingEncodingError(name)
+		}
+		if !p.bomDetected {
+			return p.newParseError("BOM not detected for UTF-16")
+		}
+		return nil
+	}
+
+	enc, ok := encodingAliases[lowerName]
+	if !ok {
+		return erroring.NewUnsupportedEncodingError(name)
+	}
+	if enc == p.encoding {
+		return nil
+	}
+	if !p.singleByteEncoding || enc.IsUtf16() || (p.mustUtf8 && enc != common.FormatUtf8) {
+		return p.contradictingEncodingError(name)
+	}
+
+	switch enc {
+	case common.FormatAscii:
+		r, err := reader.NewAsciiReader(p.xmlDoc, p.offset, p.endOffset)
+		if err != nil {
+			return err
+		}
+		p.encoding = enc
+		p.reader = r
+		return nil
+	case common.FormatUtf8:
+		r, err := reader.NewUtf8Reader(p.xmlDoc, p.offset, p.endOffset)
+		if err != nil {
+			return err
+		}
+		p.encoding = enc
+		p.reader = r
+		return nil
+	default:
+		return p.switchSingleByteEncoding(enc)
+	}
+}
+
+// contradictingEncodingError function returns an error describing why the
+// declared encoding cannot be used with the document bytes
+func (p *VtdParser) contradictingEncodingError(name string) error {
+	switch {
+	case p.mustUtf8:
+		return erroring.NewEncodingError(fmt.Sprintf("declared encoding %s contradicts UTF-8 BOM", name))
+	case p.bomDetected:
+		return erroring.NewEncodingError(fmt.Sprintf("declared encoding %s contradicts %s BOM", name, p.encoding))
+	case !p.singleByteEncoding:
+		return erroring.NewEncodingError(fmt.Sprintf("declared encoding %s contradicts detected %s", name, p.encoding))
+	default:
+		return erroring.NewEncodingError(fmt.Sprintf("declared encoding %s requires a byte order mark", name))
+	}
+}
+
+// switchSingleByteEncoding function sets document encoding and replaces the
+// byte reader with a code page reader positioned at the current offset
+func (p *VtdParser) switchSingleByteEncoding(enc common.FormatEncoding) error {
+	r, err := reader.NewSingleByteReader(p.xmlDoc, p.offset, p.endOffset, enc)
+	if err != nil {
+		return err
+	}
+	p.encoding = enc
+	p.reader = r
+	return nil
+}
@@ -0,0 +1,110 @@
+package parser
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/common"
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/erroring"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_VtdParser_Parse_EncodingAlias_Success(t *testing.T) {
+	testCases := []struct {
+		declaredEncoding string
+		encodedText      string
+		expectedEncoding common.FormatEncoding
+		expectedText     string
+	}{
+		{declaredEncoding: "latin1", encodedText: "caf\xE9", expectedEncoding: common.FormatIso88591, expectedText: "café"},
+		{declaredEncoding: "Latin9", encodedText: "\xA4", expectedEncoding: common.FormatIso885915, expectedText: "€"},
+		{declaredEncoding: "ISO8859_2", encodedText: "\xA3", expectedEncoding: common.FormatIso88592, expectedText: "Ł"},
+		{declaredEncoding: "cp1252", encodedText: "\x80", expectedEncoding: common.FormatWin1252, expectedText: "€"},
+		{declaredEncoding: "utf8", encodedText: "caf\xC3\xA9", expectedEncoding: common.FormatUtf8, expectedText: "café"},
+		{declaredEncoding: "UTF-8", encodedText: "ok", expectedEncoding: common.FormatUtf8, expectedText: "ok"},
+		{declaredEncoding: "US-ASCII", encodedText: "ok", expectedEncoding: common.FormatAscii, expectedText: "ok"},
+		{declaredEncoding: "ascii", encodedText: "ok", expectedEncoding: common.FormatAscii, expectedText: "ok"},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.declaredEncoding, func(t *testing.T) {
+			doc := fmt.Sprintf(codePageXml, tc.declaredEncoding, tc.encodedText, tc.encodedText)
+			parser, err := NewVtdParser(WithXmlDoc([]byte(doc)))
+			assert.Nil(t, err)
+			assert.Nil(t, parser.Parse())
+
+			nav, err := parser.GetNav()
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectedEncoding, nav.GetEncoding())
+			// encoding declaration value is the 5th token
+			value, err := nav.ToRawStringAtIndex(4)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.declaredEncoding, value)
+			text, err := nav.ToStringAtIndex(7)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectedText, text)
+		})
+	}
+}
+
+func Test_VtdParser_Parse_EncodingContradictsBom(t *testing.T) {
+	utf8Bom := "\xEF\xBB\xBF"
+	testCases := []struct {
+		name           string
+		doc            []byte
+		expectedErrMsg string
+	}{
+		{
+			name:           "ISO-8859-1 declared after UTF-8 BOM",
+			doc:            []byte(utf8Bom + fmt.Sprintf(codePageXml, "ISO-8859-1", "a", "b")),
+			expectedErrMsg: "unknown character encoding: declared encoding ISO-8859-1 contradicts UTF-8 BOM",
+		},
+		{
+			name:           "UTF-16 declared after UTF-8 BOM",
+			doc:            []byte(utf8Bom + fmt.Sprintf(codePageXml, "UTF-16", "a", "b")),
+			expectedErrMsg: "unknown character encoding: declared encoding UTF-16 contradicts UTF-8 BOM",
+		},
+		{
+			name:           "UTF-16BE declared after UTF-16LE BOM",
+			doc:            encodeUtf16(fmt.Sprintf(codePageXml, "UTF-16BE", "a", "b"), false, true),
+			expectedErrMsg: "unknown character encoding: declared encoding UTF-16BE contradicts UTF-16LE BOM",
+		},
+		{
+			name:           "windows-1252 declared after UTF-16BE BOM",
+			doc:            encodeUtf16(fmt.Sprintf(codePageXml, "windows-1252", "a", "b"), true, true),
+			expectedErrMsg: "unknown character encoding: declared encoding windows-1252 contradicts UTF-16BE BOM",
+		},
+		{
+			name:           "latin1 declared in UTF-16LE document without BOM",
+			doc:            encodeUtf16(fmt.Sprintf(codePageXml, "latin1", "a", "b"), false, false),
+			expectedErrMsg: "unknown character encoding: declared encoding latin1 contradicts detected UTF-16LE",
+		},
+		{
+			name:           "UTF-16 declared in single-byte document",
+			doc:            []byte(fmt.Sprintf(codePageXml, "UTF-16", "a", "b")),
+			expectedErrMsg: "unknown character encoding: declared encoding UTF-16 requires a byte order mark",
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			parser, err := NewVtdParser(WithXmlDoc(tc.doc))
+			assert.Nil(t, err)
+			err = parser.Parse()
+			if assert.EqualError(t, err, tc.expectedErrMsg) {
+				assert.IsType(t, erroring.EncodingErrorType, err)
+			}
+		})
+	}
+}
+
+func Test_VtdParser_Parse_UnsupportedEncoding(t *testing.T) {
+	parser, err := NewVtdParser(WithXmlDoc([]byte(fmt.Sprintf(codePageXml, "Shift_JIS", "a", "b"))))
+	assert.Nil(t, err)
+
+	err = parser.Parse()
+	if assert.EqualError(t, err, "unsupported encoding: Shift_JIS") {
+		assert.IsType(t, erroring.UnsupportedEncodingErrorType, err)
+		assert.Equal(t, "Shift_JIS", err.(*erroring.UnsupportedEncodingError).Encoding)
+	}
+}
@@ -0,0 +1,175 @@
+package parser
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/common"
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/navigation"
+	"github.com/stretchr/testify/assert"
+)
+
+func parseTestNav(t *testing.T, doc []byte, opts ...Option) *navigation.VtdNav {
+	parser, err := NewVtdParser(append([]Option{WithXmlDoc(doc)}, opts...)...)
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	if !assert.Nil(t, parser.Parse()) {
+		t.FailNow()
+	}
+	nav, err := parser.GetNav()
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	return nav
+}
+
+// assertSameTokens function checks that both navigation objects hold the same
+// tokens at the same offsets
+func assertSameTokens(t *testing.T, expected, actual *navigation.VtdNav) {
+	assert.Equal(t, expected.GetVtdBufferSize(), actual.GetVtdBufferSize())
+	for i := 0; i < expected.GetVtdBufferSize(); i++ {
+		for _, get := range []func(n *navigation.VtdNav, i int) (int32, error){
+			(*navigation.VtdNav).GetTokenType,
+			(*navigation.VtdNav).GetTokenDepth,
+			(*navigation.VtdNav).GetTokenLength,
+			(*navigation.VtdNav).GetTokenOffset,
+		} {
+			e, err := get(expected, i)
+			assert.Nil(t, err)
+			a, err := get(actual, i)
+			assert.Nil(t, err)
+			assert.Equal(t, e, a)
+		}
+		offset, err := actual.GetTokenOffset64(i)
+		assert.Nil(t, err)
+		expectedOffset, err := expected.GetTokenOffset(i)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(expectedOffset), offset)
+
+		e, err := expected.ToStringAtIndex(i)
+		assert.Nil(t, err)
+		a, err := actual.ToStringAtIndex(i)
+		assert.Nil(t, err)
+		assert.Equal(t, e, a)
+	}
+}
+
+func Test_VtdParser_Parse_ExtendedVtd_Success(t *testing.T) {
+	testCases := []struct {
+		name    string
+		doc     []byte
+		nsAware bool
+	}{
+		{
+			name:    "UTF-8 with namespace awareness",
+			doc:     readTestData(t, "camt.053.001.08", true),
+			nsAware: true,
+		},
+		{
+			name: "UTF-8 without namespace awareness",
+			doc:  readTestData(t, "xml_opt1", true),
+		},
+		{
+			name:    "UTF-16BE",
+			doc:     encodeUtf16(utf16Xml, true, true),
+			nsAware: true,
+		},
+		{
+			name:    "UTF-16LE",
+			doc:     encodeUtf16(utf16Xml, false, true),
+			nsAware: true,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			standard := parseTestNav(t, tc.doc, WithNameSpaceAware(tc.nsAware))
+			extended := parseTestNav(t, tc.doc, WithNameSpaceAware(tc.nsAware), WithExtendedVtd(true))
+			assert.False(t, standard.IsExtended())
+			assert.True(t, extended.IsExtended())
+			assertSameTokens(t, standard, extended)
+
+			line, column, err := extended.GetTokenPosition(extended.GetVtdBufferSize() - 1)
+			assert.Nil(t, err)
+			expectedLine, expectedColumn, err := standard.GetTokenPosition(standard.GetVtdBufferSize() - 1)
+			assert.Nil(t, err)
+			assert.Equal(t, []int{expectedLine, expectedColumn}, []int{line, column})
+
+			var index bytes.Buffer
+			assert.Nil(t, extended.WriteIndex(&index))
+			loaded, err := navigation.LoadIndex(tc.doc, &index)
+			assert.Nil(t, err)
+			assert.True(t, loaded.IsExtended())
+			assertSameTokens(t, standard, loaded)
+		})
+	}
+}
+
+func Test_VtdParser_Parse_ExtendedVtd_Navigation(t *testing.T) {
+	nav := parseTestNav(t, readTestData(t, "xml_opt1", true), WithNameSpaceAware(true), WithExtendedVtd(true))
+
+	assertCurrentElement(t, nav, "pre:Vehicle")
+	assertMove(t, nav, navigation.FirstChild, "seats")
+	assertMove(t, nav, navigation.NextSibling, "colour")
+	assertMove(t, nav, navigation.NextSibling, "engine")
+	assertMove(t, nav, navigation.LastChild, "capacity")
+	assertMove(t, nav, navigation.PrevSibling, "petrol")
+	assertMove(t, nav, navigation.Parent, "engine")
+}
+
+func Test_VtdParser_Parse_ExtendedVtd_BufferReuse(t *testing.T) {
+	doc := readTestData(t, "xml_opt1", true)
+	parser, err := NewVtdParser(WithXmlDoc(doc), WithBufferReuse(true), WithExtendedVtd(true))
+	assert.Nil(t, err)
+	assert.Nil(t, parser.Parse())
+	first, err := parser.GetNav()
+	assert.Nil(t, err)
+
+	// navigation objects keep their offsets once the parser moves on
+	assert.Nil(t, parser.Reset(WithXmlDoc([]byte("<a><b/></a>")), WithExtendedVtd(false)))
+	assert.Nil(t, parser.Parse())
+	second, err := parser.GetNav()
+	assert.Nil(t, err)
+
+	assert.True(t, first.IsExtended())
+	assert.False(t, second.IsExtended())
+	assertSameTokens(t, parseTestNav(t, doc), first)
+	assertCurrentElement(t, second, "a")
+}
+
+func Test_FitsVtdRecord(t *testing.T) {
+	testCases := []struct {
+		name               string
+		endOffset          int
+		singleByteEncoding bool
+		expected           bool
+	}{
+		{
+			name:               "single-byte document at the limit",
+			endOffset:          int(common.MaskTokenOffset) + 1,
+			singleByteEncoding: true,
+			expected:           true,
+		},
+		{
+			name:               "single-byte document above the limit",
+			endOffset:          int(common.MaskTokenOffset) + 2,
+			singleByteEncoding: true,
+		},
+		{
+			name:      "UTF-16 document counted in units",
+			endOffset: int(common.MaskTokenOffset)*2 + 2,
+			expected:  true,
+		},
+		{
+			name:      "UTF-16 document above the limit",
+			endOffset: int(common.MaskTokenOffset)*2 + 4,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, fitsVtdRecord(tc.endOffset, tc.singleByteEncoding))
+		})
+	}
+}
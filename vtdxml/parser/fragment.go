@@ -0,0 +1,105 @@
+package parser
+
+import (
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/buffer"
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/common"
+)
+
+// WithFragmentMode option makes the parser accept XML fragments and forests,
+// e.g. message bodies, that hold any number of top-level elements mixed with
+// text instead of a single root element. Top-level elements are recorded at
+// depth 0, the first of them becomes the root element, and navigation moves
+// between them with NextSibling and PrevSibling. Top-level text is recorded at
+// depth -1, the same as comments and processing instructions outside of
+// elements. XML declaration and DOCTYPE are still accepted at the start.
+func WithFragmentMode() Option {
+	return func(p *VtdParser) {
+		p.fragment = true
+	}
+}
+
+// initFragmentBuffer function creates the level 0 location cache holding
+// top-level elements of a fragment. Its entries have the same layout as level
+// 1 entries: token index in the upper 32 bits and index of the first level 1
+// child, or -1, in the lower 32 bits.
+func (p *VtdParser) initFragmentBuffer() error {
+	if !p.fragment || p.l0Buffer != nil {
+		return nil
+	}
+	b, err := buffer.NewFastLongBuffer([]buffer.FastLongBufferOption{
+		buffer.WithFastLongBufferPageSize(pageExp(p.docLength >> 8)),
+	}...)
+	if err != nil {
+		return err
+	}
+	p.l0Buffer = b
+	return nil
+}
+
+// atFragmentTop function returns true if the parser reads top-level items of
+// a fragment, which are all dispatched by processDocEnd
+func (p *VtdParser) atFragmentTop() bool {
+	return p.fragment && p.depth == -1
+}
+
+// startFragmentRoot function records the top-level element just written. The
+// first one becomes the root element, for the others location cache entries
+// still pending for the previous top-level element are written first.
+func (p *VtdParser) startFragmentRoot() error {
+	index := p.vtdBuffer.GetSize() - 1
+	if p.rootIndex == 0 {
+		p.rootIndex = index
+	} else if err := p.finishUp(); err != nil {
+		return err
+	}
+	p.lastL0Index = index
+	p.lastDepth = 0
+	return nil
+}
+
+// processFragmentItem function starts reading the top-level item of a
+// fragment whose first character other than whitespace is the current
+// character. Text of the item starts at the given offset.
+func (p *VtdParser) processFragmentItem(start int) (State, error) {
+	if p.currentChar == '<' {
+		return p.processLtSeen()
+	}
+	p.lastOffset = start
+	return p.processFragmentText()
+}
+
+// processFragmentText function reads top-level text of a fragment starting at
+// the last offset, whose first character other than whitespace is the
+// current character, up to the next < or the end of the document
+func (p *VtdParser) processFragmentText() (State, error) {
+	for {
+		if !p.xmlChar.IsContentChar(p.currentChar) {
+			if p.currentChar == '<' {
+				if err := p.writeFragmentText(p.offset - p.increment - p.lastOffset); err != nil {
+					return StateInvalid, err
+				}
+				return p.processLtSeen()
+			}
+			if err := p.handleOtherTextChar(p.currentChar); err != nil {
+				return StateInvalid, err
+			}
+		}
+		if p.offset >= p.endOffset {
+			break
+		}
+		if err := p.nextChar(); err != nil {
+			return StateInvalid, err
+		}
+	}
+	if err := p.writeFragmentText(p.offset - p.lastOffset); err != nil {
+		return StateInvalid, err
+	}
+	return StateDocEnd, nil
+}
+
+func (p *VtdParser) writeFragmentText(length int) error {
+	if p.singleByteEncoding {
+		return p.writeVtdText(common.TokenCharacterData, p.lastOffset, length, p.depth)
+	}
+	return p.writeVtdText(common.TokenCharacterData, p.lastOffset>>1, length>>1, p.depth)
+}
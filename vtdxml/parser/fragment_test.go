@@ -0,0 +1,180 @@
+package parser
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/navigation"
+	"github.com/stretchr/testify/assert"
+)
+
+const fragmentXml = "head <a><b/>x</a> <!--c--><?pi v?>mid<c/>\n" +
+	"<d><e><g/></e><f/></d>tail"
+
+// topLevelElements function returns names of the top-level elements visited
+// with NextSibling starting from the root element
+func topLevelElements(t *testing.T, nav *navigation.VtdNav) []string {
+	var names []string
+	ok, err := nav.ToElement(navigation.Root)
+	for assert.Nil(t, err) && ok {
+		index, err := nav.GetCurrentIndex()
+		assert.Nil(t, err)
+		name, err := nav.ToRawStringAtIndex(int(index))
+		assert.Nil(t, err)
+		names = append(names, name)
+		ok, err = nav.ToElement(navigation.NextSibling)
+	}
+	return names
+}
+
+func Test_VtdParser_WithFragmentMode_Success(t *testing.T) {
+	testCases := []struct {
+		name string
+		doc  []byte
+		opts []Option
+	}{
+		{
+			name: "UTF-8 with LC depth 3",
+			doc:  []byte(fragmentXml),
+		},
+		{
+			name: "UTF-8 with LC depth 5",
+			doc:  []byte(fragmentXml),
+			opts: []Option{WithLcDepth(5)},
+		},
+		{
+			name: "UTF-8 with XML declaration",
+			doc:  []byte(`<?xml version="1.0"?>` + fragmentXml),
+		},
+		{
+			name: "UTF-16LE with namespace awareness",
+			doc:  encodeUtf16(fragmentXml, false, true),
+			opts: []Option{WithNameSpaceAware(true)},
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			nav := parseTestNav(t, tc.doc, append([]Option{WithFragmentMode()}, tc.opts...)...)
+			assert.True(t, nav.IsFragment())
+			assert.Equal(t, []string{"a", "c", "d"}, topLevelElements(t, nav))
+			assert.Equal(t, []string{`-1 "head "`, `0 "x"`, `-1 "mid"`, `-1 "tail"`}, textTokens(t, tc.doc,
+				append([]Option{WithFragmentMode()}, tc.opts...)...))
+
+			assertCurrentElement(t, nav, "d")
+			assertMove(t, nav, navigation.LastChild, "f")
+			assertMove(t, nav, navigation.PrevSibling, "e")
+			assertMove(t, nav, navigation.FirstChild, "g")
+			assertMove(t, nav, navigation.Parent, "e")
+			assertMove(t, nav, navigation.Parent, "d")
+			assertMove(t, nav, navigation.PrevSibling, "c")
+			ok, err := nav.ToElement(navigation.FirstChild)
+			assert.Nil(t, err)
+			assert.False(t, ok)
+			assertMove(t, nav, navigation.PrevSibling, "a")
+			assertMove(t, nav, navigation.LastChild, "b")
+			// children of the other top-level elements are not siblings
+			ok, err = nav.ToElement(navigation.NextSibling)
+			assert.Nil(t, err)
+			assert.False(t, ok)
+			assertMove(t, nav, navigation.Parent, "a")
+			ok, err = nav.ToElement(navigation.PrevSibling)
+			assert.Nil(t, err)
+			assert.False(t, ok)
+
+			assertMove(t, nav, navigation.Parent, "")
+			assertMove(t, nav, navigation.LastChild, "d")
+			assertMove(t, nav, navigation.Root, "a")
+		})
+	}
+}
+
+func Test_VtdParser_WithFragmentMode_TextOnly(t *testing.T) {
+	doc := []byte("just &amp; text")
+	nav := parseTestNav(t, doc, WithFragmentMode())
+	ok, err := nav.ToElement(navigation.Root)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, []string{`-1 "just &amp; text"`}, textTokens(t, doc, WithFragmentMode()))
+}
+
+func Test_VtdParser_WithFragmentMode_LoadIndex(t *testing.T) {
+	doc := []byte(fragmentXml)
+	nav := parseTestNav(t, doc, WithFragmentMode(), WithExtendedVtd(true))
+
+	var index bytes.Buffer
+	assert.Nil(t, nav.WriteIndex(&index))
+	loaded, err := navigation.LoadIndex(doc, &index)
+	assert.Nil(t, err)
+	assert.True(t, loaded.IsFragment())
+	assert.True(t, loaded.IsExtended())
+	assert.Equal(t, []string{"a", "c", "d"}, topLevelElements(t, loaded))
+}
+
+func Test_VtdParser_WithFragmentMode_BufferReuse(t *testing.T) {
+	parser, err := NewVtdParser(WithXmlDoc([]byte(fragmentXml)), WithBufferReuse(true), WithFragmentMode())
+	assert.Nil(t, err)
+	assert.Nil(t, parser.Parse())
+	first, err := parser.GetNav()
+	assert.Nil(t, err)
+
+	assert.Nil(t, parser.Reset(WithXmlDoc([]byte("<x/><y/>"))))
+	assert.Nil(t, parser.Parse())
+	second, err := parser.GetNav()
+	assert.Nil(t, err)
+
+	assert.Equal(t, []string{"a", "c", "d"}, topLevelElements(t, first))
+	assert.Equal(t, []string{"x", "y"}, topLevelElements(t, second))
+}
+
+func Test_VtdParser_WithFragmentMode_Error(t *testing.T) {
+	testCases := []struct {
+		name     string
+		doc      string
+		fragment bool
+		expected string
+	}{
+		{
+			name:     "many top-level elements without fragment mode",
+			doc:      "<a/><b/>",
+			expected: "XML not terminated properly",
+		},
+		{
+			name:     "end tag without start tag",
+			doc:      "<a/></a>",
+			fragment: true,
+			expected: "end tag without start tag",
+		},
+		{
+			name:     "unclosed top-level element",
+			doc:      "<a/><b>",
+			fragment: true,
+		},
+		{
+			name:     "invalid entity in top-level text",
+			doc:      "<a/>x &y",
+			fragment: true,
+		},
+		{
+			name:     "DOCTYPE after top-level element",
+			doc:      "<a/><!DOCTYPE a>",
+			fragment: true,
+			expected: "wrong place for DOCTYPE",
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			opts := []Option{WithXmlDoc([]byte(tc.doc))}
+			if tc.fragment {
+				opts = append(opts, WithFragmentMode())
+			}
+			parser, err := NewVtdParser(opts...)
+			assert.Nil(t, err)
+			err = parser.Parse()
+			if assert.NotNil(t, err) && tc.expected != "" {
+				assert.Contains(t, err.Error(), tc.expected)
+			}
+		})
+	}
+}
@@ -1,9 +1,171 @@
 package parser
 
-import "github.com/alexZaicev/go-vtd-xml/vtdxml/navigation"
+import (
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/buffer"
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/erroring"
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/navigation"
+)
 
-// GetNav function returns VTD navigation object after parsing
+// GetNav function returns VTD navigation object after parsing. Navigation
+// object is positioned at the root element.
+//
+// When buffer reuse is disabled the parser hands its VTD, LC, offset and
+// namespace URL buffers over to the navigation object and releases them, so
+// the parser must be given a new document before parsing again. When buffer
+// reuse is enabled the parser keeps its buffers for the next document and the
+// navigation object receives a copy of them, so clearing the parser does not
+// affect navigation objects that were already handed out.
 func (p *VtdParser) GetNav() (*navigation.VtdNav, error) {
-	// TODO
-	return nil, nil
+	if !p.parsed {
+		return nil, erroring.NewInternalError(erroring.DocumentNotParsed, nil)
+	}
+
+	vtdBuffer, l1Buffer, l2Buffer, l3Buffer := p.vtdBuffer, p.l1Buffer, p.l2Buffer, p.l3Buffer
+	var l0Buffer, l4Buffer, l5Buffer, offsetBuffer, nsUrlBuffer buffer.LongBuffer
+	if !p.shallowDepth {
+		l4Buffer, l5Buffer = p.l4Buffer, p.l5Buffer
+	}
+	if p.extended {
+		offsetBuffer = p.offsetBuffer
+	}
+	if p.fragment {
+		l0Buffer = p.l0Buffer
+	}
+	if p.nsAware {
+		nsUrlBuffer = p.nsUrlBuffer
+	}
+	if p.bufferReuse {
+		var err error
+		if l0Buffer != nil {
+			if l0Buffer, err = copyLongBuffer(p.l0Buffer); err != nil {
+				return nil, err
+			}
+		}
+		if nsUrlBuffer != nil {
+			if nsUrlBuffer, err = copyLongBuffer(p.nsUrlBuffer); err != nil {
+				return nil, err
+			}
+		}
+		if l4Buffer != nil {
+			if l4Buffer, err = copyLongBuffer(p.l4Buffer); err != nil {
+				return nil, err
+			}
+			if l5Buffer, err = copyLongBuffer(p.l5Buffer); err != nil {
+				return nil, err
+			}
+		}
+		if offsetBuffer != nil {
+			if offsetBuffer, err = copyLongBuffer(p.offsetBuffer); err != nil {
+				return nil, err
+			}
+		}
+		if vtdBuffer, err = copyLongBuffer(p.vtdBuffer); err != nil {
+			return nil, err
+		}
+		if l1Buffer, err = copyLongBuffer(p.l1Buffer); err != nil {
+			return nil, err
+		}
+		if l2Buffer, err = copyLongBuffer(p.l2Buffer); err != nil {
+			return nil, err
+		}
+		if l3Buffer, err = copyLongBuffer(p.l3Buffer); err != nil {
+			return nil, err
+		}
+	}
+
+	// mapped document is handed over together with the other buffers, with
+	// buffer reuse the parser keeps it and navigation objects receive a copy,
+	// as the mapping is released once the parser moves on
+	var xmlBuffer buffer.ByteBuffer
+	if p.mappedFile != nil && !p.bufferReuse && p.usesMappedFile() {
+		xmlBuffer = p.mappedFile
+	} else {
+		xmlDoc := p.xmlDoc
+		if p.mappedFile != nil && p.usesMappedFile() {
+			xmlDoc = append([]byte(nil), xmlDoc...)
+		}
+		b, err := buffer.NewUniByteBuffer(xmlDoc)
+		if err != nil {
+			return nil, err
+		}
+		xmlBuffer = b
+	}
+
+	nav, err := navigation.NewVtdNavWithBuffer(
+		int32(p.rootIndex), p.docOffset, p.docLength, int32(p.vtdDepth),
+		p.encoding, p.nsAware, xmlBuffer,
+		vtdBuffer, l1Buffer, l2Buffer, l3Buffer,
+	)
+	if err != nil {
+		return nil, err
+	}
+	nav.SetDtd(p.dtd)
+	nav.SetEntityExpansionLimit(p.entityExpansionLimit())
+	nav.SetXml11(p.xml11)
+	if l4Buffer != nil {
+		if err := nav.SetDeepLcBuffers(l4Buffer, l5Buffer); err != nil {
+			return nil, err
+		}
+	}
+	if offsetBuffer != nil {
+		if err := nav.SetOffsetBuffer(offsetBuffer); err != nil {
+			return nil, err
+		}
+	}
+	if l0Buffer != nil {
+		if err := nav.SetFragmentBuffer(l0Buffer); err != nil {
+			return nil, err
+		}
+	}
+	if nsUrlBuffer != nil {
+		if err := nav.SetNsUrlBuffer(nsUrlBuffer); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := nav.ToElement(navigation.Root); err != nil {
+		return nil, err
+	}
+
+	if !p.bufferReuse {
+		// buffers now belong to the navigation object
+		if xmlBuffer == p.mappedFile {
+			p.mappedFile = nil
+		}
+		p.xmlDoc = nil
+		p.vtdBuffer, p.l1Buffer, p.l2Buffer, p.l3Buffer, p.l4Buffer, p.l5Buffer = nil, nil, nil, nil, nil, nil
+		p.l0Buffer, p.offsetBuffer, p.nsUrlBuffer = nil, nil, nil
+		p.parsed = false
+	}
+	return nav, nil
+}
+
+// copyLongBuffer function creates a copy of the long buffer with pages sized
+// for the number of entries
+func copyLongBuffer(src buffer.LongBuffer) (buffer.LongBuffer, error) {
+	values, err := src.ToLongArray()
+	if err != nil {
+		return nil, err
+	}
+	dst, err := buffer.NewFastLongBuffer([]buffer.FastLongBufferOption{
+		buffer.WithFastLongBufferPageSize(pageExp(len(values))),
+	}...)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range values {
+		if err := dst.Append(v); err != nil {
+			return nil, err
+		}
+	}
+	return dst, nil
+}
+
+// pageExp function returns a buffer page size exponent large enough to hold
+// the given number of entries in a few pages
+func pageExp(size int) int {
+	exp := 5
+	for exp < 20 && (1<<exp) < size {
+		exp++
+	}
+	return exp
 }
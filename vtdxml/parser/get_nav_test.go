@@ -0,0 +1,197 @@
+package parser
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/erroring"
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/navigation"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_VtdParser_GetNav_Success(t *testing.T) {
+	testCases := []struct {
+		name    string
+		nsAware bool
+		lcDepth int
+	}{
+		{
+			name:    "LC depth 3 without namespace awareness",
+			lcDepth: 3,
+		},
+		{
+			name:    "LC depth 3 with namespace awareness",
+			nsAware: true,
+			lcDepth: 3,
+		},
+		{
+			name:    "LC depth 5 with namespace awareness",
+			nsAware: true,
+			lcDepth: 5,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			parser, err := NewVtdParser([]Option{
+				WithXmlDoc(readTestData(t, "xml_opt1", true)),
+				WithNameSpaceAware(tc.nsAware),
+				WithLcDepth(tc.lcDepth),
+			}...)
+			assert.Nil(t, err)
+			assert.Nil(t, parser.Parse())
+
+			nav, err := parser.GetNav()
+			assert.Nil(t, err)
+			assert.NotNil(t, nav)
+
+			assertCurrentElement(t, nav, "pre:Vehicle")
+			assertMove(t, nav, navigation.FirstChild, "seats")
+			assertMove(t, nav, navigation.NextSibling, "colour")
+			assertMove(t, nav, navigation.NextSibling, "engine")
+			assertMove(t, nav, navigation.LastChild, "capacity")
+			assertMove(t, nav, navigation.PrevSibling, "petrol")
+			assertMove(t, nav, navigation.Parent, "engine")
+			assertMove(t, nav, navigation.PrevSibling, "colour")
+
+			ok, err := nav.ToElement(navigation.FirstChild)
+			assert.Nil(t, err)
+			assert.False(t, ok)
+		})
+	}
+}
+
+func Test_VtdParser_GetNav_NotParsed(t *testing.T) {
+	parser, err := NewVtdParser(WithXmlDoc(readTestData(t, "xml_opt1", true)))
+	assert.Nil(t, err)
+
+	nav, err := parser.GetNav()
+	assert.Nil(t, nav)
+	if assert.EqualError(t, err, "an internal error occurred: document has not been parsed") {
+		assert.IsType(t, erroring.InternalErrorType, err)
+	}
+}
+
+func Test_VtdParser_GetNav_WithoutBufferReuse(t *testing.T) {
+	parser, err := NewVtdParser(WithXmlDoc(readTestData(t, "xml_opt1", true)))
+	assert.Nil(t, err)
+	assert.Nil(t, parser.Parse())
+
+	nav, err := parser.GetNav()
+	assert.Nil(t, err)
+	assert.NotNil(t, nav)
+
+	// buffers were handed over to the first navigation object
+	nav2, err := parser.GetNav()
+	assert.Nil(t, nav2)
+	assert.EqualError(t, err, "an internal error occurred: document has not been parsed")
+	assertCurrentElement(t, nav, "pre:Vehicle")
+}
+
+func Test_VtdParser_GetNav_WithBufferReuse(t *testing.T) {
+	parser, err := NewVtdParser(
+		WithXmlDoc(readTestData(t, "xml_opt1", true)),
+		WithBufferReuse(true),
+	)
+	assert.Nil(t, err)
+	assert.Nil(t, parser.Parse())
+
+	nav, err := parser.GetNav()
+	assert.Nil(t, err)
+	nav2, err := parser.GetNav()
+	assert.Nil(t, err)
+
+	// navigation objects own separate copies of the parser buffers
+	parser.vtdBuffer.Clear()
+	assert.Equal(t, nav.GetVtdBufferSize(), nav2.GetVtdBufferSize())
+	assert.NotEqual(t, 0, nav.GetVtdBufferSize())
+	assertCurrentElement(t, nav, "pre:Vehicle")
+	assertMove(t, nav2, navigation.LastChild, "engine")
+}
+
+func Test_VtdParser_GetNav_LoadIndex(t *testing.T) {
+	doc := readTestData(t, "xml_opt1", true)
+	parser, err := NewVtdParser(WithXmlDoc(doc), WithNameSpaceAware(true))
+	assert.Nil(t, err)
+	assert.Nil(t, parser.Parse())
+	nav, err := parser.GetNav()
+	assert.Nil(t, err)
+
+	var index bytes.Buffer
+	assert.Nil(t, nav.WriteIndex(&index))
+	loaded, err := navigation.LoadIndex(doc, &index)
+	assert.Nil(t, err)
+
+	assert.Equal(t, nav.GetVtdBufferSize(), loaded.GetVtdBufferSize())
+	for i := 0; i < nav.GetVtdBufferSize(); i++ {
+		expected, err := nav.ToStringAtIndex(i)
+		assert.Nil(t, err)
+		actual, err := loaded.ToStringAtIndex(i)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, actual)
+	}
+	assertCurrentElement(t, loaded, "pre:Vehicle")
+	assertMove(t, loaded, navigation.LastChild, "engine")
+	assertMove(t, loaded, navigation.LastChild, "capacity")
+	assertMove(t, loaded, navigation.PrevSibling, "petrol")
+}
+
+func Test_VtdParser_GetNav_DocumentEndingInCR(t *testing.T) {
+	testCases := []struct {
+		name        string
+		decl        string
+		utf16       bool
+		expectedRaw string
+	}{
+		{
+			name:        "UTF-8",
+			expectedRaw: "<a>x</a>\n",
+		},
+		{
+			name:        "ASCII",
+			decl:        `<?xml version="1.0" encoding="US-ASCII"?>`,
+			expectedRaw: `<?xml version="1.0" encoding="US-ASCII"?><a>x</a>` + "\n",
+		},
+		{
+			name:        "ISO-8859-1",
+			decl:        `<?xml version="1.0" encoding="ISO-8859-1"?>`,
+			expectedRaw: `<?xml version="1.0" encoding="ISO-8859-1"?><a>x</a>` + "\n",
+		},
+		{
+			name:        "UTF-16LE",
+			utf16:       true,
+			expectedRaw: "\uFEFF<a>x</a>\n",
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			doc, length := []byte(tc.decl+"<a>x</a>\r"), len(tc.decl)+9
+			if tc.utf16 {
+				doc, length = encodeUtf16("<a>x</a>\r", false, true), 10
+			}
+			nav := parseTestNav(t, doc)
+			raw, err := nav.ToRawStringAtRange(0, int32(length))
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectedRaw, raw)
+			text, err := nav.ToStringAtRange(0, int32(length))
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectedRaw, text)
+		})
+	}
+}
+
+func assertMove(t *testing.T, nav *navigation.VtdNav, dir navigation.Direction, expected string) {
+	ok, err := nav.ToElement(dir)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assertCurrentElement(t, nav, expected)
+}
+
+func assertCurrentElement(t *testing.T, nav *navigation.VtdNav, expected string) {
+	index, err := nav.GetCurrentIndex()
+	assert.Nil(t, err)
+	name, err := nav.ToRawStringAtIndex(int(index))
+	assert.Nil(t, err)
+	assert.Equal(t, expected, name)
+}
@@ -0,0 +1,117 @@
+package parser
+
+import (
+	"strings"
+	"unicode/utf16"
+
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/common"
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/reader"
+)
+
+// Event locates a document item reported to a Handler. Offset and Length are
+// in the units of VTD token offsets, i.e. bytes or 16-bit units for UTF-16
+// documents, Depth is the depth of the element the item belongs to, the root
+// element being at depth 0.
+type Event struct {
+	Offset, Length, Depth int
+	p                     *VtdParser
+}
+
+// RawString function decodes text of the event, entity and character
+// references are not resolved. Text is decoded only when asked for, so
+// handlers interested in positions alone do not pay for decoding.
+func (e Event) RawString() (string, error) {
+	if e.p == nil {
+		return "", nil
+	}
+	doc := e.p.xmlDoc
+	switch e.p.encoding {
+	case common.FormatAscii, common.FormatUtf8:
+		return string(doc[e.Offset : e.Offset+e.Length]), nil
+	case common.FormatUtf16BE, common.FormatUtf16LE:
+		units := make([]uint16, e.Length)
+		for i := range units {
+			hi, lo := doc[(e.Offset+i)<<1], doc[(e.Offset+i)<<1+1]
+			if e.p.encoding == common.FormatUtf16LE {
+				hi, lo = lo, hi
+			}
+			units[i] = uint16(hi)<<8 | uint16(lo)
+		}
+		return string(utf16.Decode(units)), nil
+	}
+	var sb strings.Builder
+	for _, b := range doc[e.Offset : e.Offset+e.Length] {
+		ch, err := reader.DecodeSingleByte(e.p.encoding, b)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteRune(rune(ch))
+	}
+	return sb.String(), nil
+}
+
+// Handler receives document items as the parser tokenizes them, in document
+// order. Attributes of an element are reported after its StartElement, text
+// longer than the maximum token length is reported in several Text or CDATA
+// calls. Names are qualified names as they appear in the document. Non-nil
+// error returned by any method stops parsing and is returned by Parse.
+type Handler interface {
+	StartElement(name Event) error
+	EndElement(name Event) error
+	Attr(name, value Event) error
+	Text(text Event) error
+	Comment(text Event) error
+	PI(target, data Event) error
+	CDATA(text Event) error
+	DocType(text Event) error
+}
+
+// WithHandler option registers handler receiving document items while
+// parsing, the VTD index is built as well
+func WithHandler(handler Handler) Option {
+	return func(p *VtdParser) {
+		p.handler = handler
+	}
+}
+
+// notify function reports token written into VTD buffer to the handler.
+// Attribute and PI values are reported together with the name written
+// before them.
+func (p *VtdParser) notify(tokenType common.Token, offset, length, depth int) error {
+	e := Event{Offset: offset, Length: length, Depth: depth, p: p}
+	switch tokenType {
+	case common.TokenStartingTag:
+		e.Length &= 0x7FF
+		return p.handler.StartElement(e)
+	case common.TokenAttrName, common.TokenAttrNs:
+		e.Length &= 0x7FF
+		p.pendingName = e
+	case common.TokenAttrVal:
+		return p.handler.Attr(p.pendingName, e)
+	case common.TokenPiName:
+		p.pendingName = e
+	case common.TokenPiVal:
+		return p.handler.PI(p.pendingName, e)
+	case common.TokenCharacterData:
+		return p.handler.Text(e)
+	case common.TokenCdataVal:
+		return p.handler.CDATA(e)
+	case common.TokenComment:
+		return p.handler.Comment(e)
+	case common.TokenDtdVal:
+		return p.handler.DocType(e)
+	}
+	return nil
+}
+
+// notifyEndElement function reports end of the element at the depth to the
+// handler, offset and length locate the name in the start tag in bytes
+func (p *VtdParser) notifyEndElement(offset, length, depth int) error {
+	if p.handler == nil {
+		return nil
+	}
+	if !p.singleByteEncoding {
+		offset, length = offset>>1, length>>1
+	}
+	return p.handler.EndElement(Event{Offset: offset, Length: length, Depth: depth, p: p})
+}
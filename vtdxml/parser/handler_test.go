@@ -0,0 +1,113 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingHandler records every event as "kind depth text" lines
+type recordingHandler struct {
+	events  []string
+	stopAt  string
+	stopErr error
+}
+
+func (h *recordingHandler) record(kind string, events ...Event) error {
+	line := kind
+	for _, e := range events {
+		text, err := e.RawString()
+		if err != nil {
+			return err
+		}
+		line += fmt.Sprintf(" %d:%q", e.Depth, text)
+	}
+	h.events = append(h.events, line)
+	if kind == h.stopAt {
+		return h.stopErr
+	}
+	return nil
+}
+
+func (h *recordingHandler) StartElement(name Event) error { return h.record("start", name) }
+func (h *recordingHandler) EndElement(name Event) error   { return h.record("end", name) }
+func (h *recordingHandler) Attr(name, value Event) error  { return h.record("attr", name, value) }
+func (h *recordingHandler) Text(text Event) error         { return h.record("text", text) }
+func (h *recordingHandler) Comment(text Event) error      { return h.record("comment", text) }
+func (h *recordingHandler) PI(target, data Event) error   { return h.record("pi", target, data) }
+func (h *recordingHandler) CDATA(text Event) error        { return h.record("cdata", text) }
+func (h *recordingHandler) DocType(text Event) error      { return h.record("doctype", text) }
+
+const handlerXml = `<?xml version="1.0"?>
+<!DOCTYPE r [<!ENTITY e "x">]>
+<!--head-->
+<r a="1" p:b='&e;'>
+  <c/>
+  <d x="y">t &amp; u<![CDATA[<raw>]]><?go run?><?stop?></d>
+</r>
+`
+
+var handlerEvents = []string{
+	`doctype -1:" r [<!ENTITY e \"x\">]"`,
+	`comment -1:"head"`,
+	`start 0:"r"`,
+	`attr 0:"a" 0:"1"`,
+	`attr 0:"p:b" 0:"&e;"`,
+	`start 1:"c"`,
+	`end 1:"c"`,
+	`start 1:"d"`,
+	`attr 1:"x" 1:"y"`,
+	`text 1:"t &amp; u"`,
+	`cdata 1:"<raw>"`,
+	`pi 1:"go" 1:"run"`,
+	`pi 1:"stop" 1:""`,
+	`end 1:"d"`,
+	`end 0:"r"`,
+}
+
+func Test_VtdParser_WithHandler_Success(t *testing.T) {
+	testCases := []struct {
+		name string
+		doc  []byte
+	}{
+		{name: "UTF-8", doc: []byte(handlerXml)},
+		{name: "UTF-16BE", doc: encodeUtf16(handlerXml, true, true)},
+		{name: "UTF-16LE", doc: encodeUtf16(handlerXml, false, true)},
+		{name: "ISO-8859-1", doc: []byte(`<?xml version="1.0" encoding="ISO-8859-1"?><r a="` + "\xe9" + `"/>`)},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			handler := &recordingHandler{}
+			parser, err := NewVtdParser(WithXmlDoc(tc.doc), WithHandler(handler))
+			assert.Nil(t, err)
+			assert.Nil(t, parser.Parse())
+			if tc.name == "ISO-8859-1" {
+				assert.Equal(t, []string{`start 0:"r"`, `attr 0:"a" 0:"é"`, `end 0:"r"`}, handler.events)
+				return
+			}
+			assert.Equal(t, handlerEvents, handler.events)
+		})
+	}
+}
+
+func Test_VtdParser_WithHandler_Abort(t *testing.T) {
+	stop := errors.New("stop")
+	for _, kind := range []string{"start", "end", "attr", "text", "cdata", "pi", "comment", "doctype"} {
+		kind := kind
+		t.Run(kind, func(t *testing.T) {
+			handler := &recordingHandler{stopAt: kind, stopErr: stop}
+			parser, err := NewVtdParser(WithXmlDoc([]byte(handlerXml)), WithHandler(handler))
+			assert.Nil(t, err)
+			assert.True(t, errors.Is(parser.Parse(), stop))
+			for i, e := range handlerEvents {
+				if len(e) > len(kind) && e[:len(kind)+1] == kind+" " {
+					assert.Equal(t, handlerEvents[:i+1], handler.events)
+					break
+				}
+			}
+		})
+	}
+}
@@ -6,6 +6,18 @@ import (
 )
 
 func (p *VtdParser) init() error {
+	if err := p.initNsBuffers(); err != nil {
+		return err
+	}
+	if err := p.initReader(); err != nil {
+		return err
+	}
+	return p.initVtdBuffers()
+}
+
+// initNsBuffers function allocates buffers used to track namespace
+// declarations
+func (p *VtdParser) initNsBuffers() error {
 	bufInt, err := buffer.NewFastIntBuffer([]buffer.FastIntBufferOption{
 		buffer.WithFastIntBufferPageSize(DefaultNsBufferSize),
 	}...)
@@ -14,6 +26,14 @@ func (p *VtdParser) init() error {
 	}
 	p.nsBuffer1 = bufInt
 
+	bufInt, err = buffer.NewFastIntBuffer([]buffer.FastIntBufferOption{
+		buffer.WithFastIntBufferPageSize(DefaultNsBufferSize),
+	}...)
+	if err != nil {
+		return err
+	}
+	p.nsBuffer4 = bufInt
+
 	bufLong, err := buffer.NewFastLongBuffer([]buffer.FastLongBufferOption{
 		buffer.WithFastLongBufferPageSize(DefaultNsBufferSize),
 	}...)
@@ -29,13 +49,23 @@ func (p *VtdParser) init() error {
 		return err
 	}
 	p.nsBuffer3 = bufLong
+	return nil
+}
 
+// initReader function creates a byte reader positioned at the beginning of
+// the document
+func (p *VtdParser) initReader() error {
 	r, err := reader.NewUtf8Reader(p.xmlDoc, p.offset, p.endOffset)
 	if err != nil {
 		return err
 	}
 	p.reader = r
+	return nil
+}
 
+// initVtdBuffers function allocates VTD and LC buffers sized according to the
+// document length and location cache depth
+func (p *VtdParser) initVtdBuffers() error {
 	if p.shallowDepth {
 		if err := p.initWithShallowDepth(); err != nil {
 			return err
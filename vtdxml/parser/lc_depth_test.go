@@ -0,0 +1,225 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/navigation"
+	"github.com/stretchr/testify/assert"
+)
+
+// walkElements function visits every element below the current one in
+// document order and, when reverse is set, in reverse document order. Each
+// visited element is recorded with its depth and token index.
+func walkElements(nav *navigation.VtdNav, reverse bool, visit func(index int32)) error {
+	index, err := nav.GetCurrentIndex()
+	if err != nil {
+		return err
+	}
+	visit(index)
+
+	child, sibling := navigation.FirstChild, navigation.NextSibling
+	if reverse {
+		child, sibling = navigation.LastChild, navigation.PrevSibling
+	}
+	ok, err := nav.ToElement(child)
+	if err != nil || !ok {
+		return err
+	}
+	for ok {
+		if err := walkElements(nav, reverse, visit); err != nil {
+			return err
+		}
+		if ok, err = nav.ToElement(sibling); err != nil {
+			return err
+		}
+	}
+	_, err = nav.ToElement(navigation.Parent)
+	return err
+}
+
+func recordElements(t *testing.T, nav *navigation.VtdNav, reverse bool) []string {
+	var elements []string
+	assert.Nil(t, walkElements(nav, reverse, func(index int32) {
+		name, err := nav.ToRawStringAtIndex(int(index))
+		assert.Nil(t, err)
+		depth, err := nav.GetTokenDepth(int(index))
+		assert.Nil(t, err)
+		elements = append(elements, fmt.Sprintf("%d %d %s", depth, index, name))
+	}))
+	return elements
+}
+
+func Test_VtdParser_GetNav_LcDepth5(t *testing.T) {
+	for _, name := range []string{
+		"camt.004.001.08", "camt.053.001.08", "mirs.095.001.01", "mirs.095.001.01.no_snl",
+		"xml_opt1", "xml_opt2", "xml_opt3", "xml_opt4", "xml_opt5",
+	} {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			doc := readTestData(t, name, true)
+			shallow := parseTestNav(t, doc, WithNameSpaceAware(true), WithLcDepth(3))
+			deep := parseTestNav(t, doc, WithNameSpaceAware(true), WithLcDepth(5))
+			for _, reverse := range []bool{false, true} {
+				for _, nav := range []*navigation.VtdNav{shallow, deep} {
+					_, err := nav.ToElement(navigation.Root)
+					assert.Nil(t, err)
+				}
+				expected := recordElements(t, shallow, reverse)
+				assert.NotEmpty(t, expected)
+				assert.Equal(t, expected, recordElements(t, deep, reverse))
+			}
+		})
+	}
+}
+
+func Test_VtdParser_GetNav_LcDepth5_Moves(t *testing.T) {
+	nav := parseTestNav(t, readTestData(t, "camt.053.001.08", true), WithLcDepth(5))
+
+	assertCurrentElement(t, nav, "Document")
+	assertMove(t, nav, navigation.FirstChild, "BkToCstmrStmt")
+	assertMove(t, nav, navigation.LastChild, "Stmt")
+	assertMove(t, nav, navigation.LastChild, "Ntry")
+	assertMove(t, nav, navigation.PrevSibling, "Ntry")
+	assertMove(t, nav, navigation.FirstChild, "NtryRef")
+	assertMove(t, nav, navigation.NextSibling, "Amt")
+	assertMove(t, nav, navigation.NextSibling, "CdtDbtInd")
+	assertMove(t, nav, navigation.NextSibling, "Sts")
+	assertMove(t, nav, navigation.FirstChild, "Cd")
+	ok, err := nav.ToElement(navigation.NextSibling)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	assertMove(t, nav, navigation.Parent, "Sts")
+	assertMove(t, nav, navigation.PrevSibling, "CdtDbtInd")
+	ok, err = nav.ToElement(navigation.FirstChild)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	assertMove(t, nav, navigation.Parent, "Ntry")
+	assertMove(t, nav, navigation.LastChild, "NtryDtls")
+	assertMove(t, nav, navigation.FirstChild, "TxDtls")
+	assertMove(t, nav, navigation.FirstChild, "Refs")
+	assertMove(t, nav, navigation.Parent, "TxDtls")
+	assertMove(t, nav, navigation.Parent, "NtryDtls")
+	assertMove(t, nav, navigation.PrevSibling, "BkTxCd")
+}
+
+func Test_VtdParser_GetNav_LcDepth5_LoadIndex(t *testing.T) {
+	doc := readTestData(t, "camt.053.001.08", true)
+	nav := parseTestNav(t, doc, WithLcDepth(5))
+
+	var index bytes.Buffer
+	assert.Nil(t, nav.WriteIndex(&index))
+	loaded, err := navigation.LoadIndex(doc, &index)
+	assert.Nil(t, err)
+	assert.Equal(t, recordElements(t, nav, false), recordElements(t, loaded, false))
+}
+
+// deepStatement function returns a camt.053 statement holding the entries of
+// the golden file repeated the number of times given
+func deepStatement(b *testing.B, repeat int) []byte {
+	g, err := ioutil.ReadFile(testDataPath("camt.053.001.08"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	doc := string(g)
+	start, end := strings.Index(doc, "<Ntry>"), strings.LastIndex(doc, "</Ntry>")+len("</Ntry>")
+	if start < 0 || end < start {
+		b.Fatal("camt.053 golden file has no entries")
+	}
+	return []byte(doc[:start] + strings.Repeat(doc[start:end], repeat) + doc[end:])
+}
+
+// BenchmarkVtdNav_Walk walks every element of a camt.053 statement with many
+// entries. Documents are parsed once, outside of the measured walks.
+func BenchmarkVtdNav_Walk(b *testing.B) {
+	doc := deepStatement(b, 20)
+	for _, lcDepth := range []int{3, 5} {
+		parser, err := NewVtdParser(WithXmlDoc(doc), WithLcDepth(lcDepth))
+		if err != nil {
+			b.Fatal(err)
+		}
+		if err := parser.Parse(); err != nil {
+			b.Fatal(err)
+		}
+		nav, err := parser.GetNav()
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(fmt.Sprintf("LC depth %d", lcDepth), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for _, reverse := range []bool{false, true} {
+					if err := walkElements(nav, reverse, func(int32) {}); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkVtdNav_DeepMoves moves between siblings and to the parent at depth
+// 4 and 5, starting from elements picked at random. Elements at depth 4 have
+// many siblings, each with many children.
+func BenchmarkVtdNav_DeepMoves(b *testing.B) {
+	const siblings, children = 200, 50
+	var sb strings.Builder
+	sb.WriteString("<r><a><b><c>")
+	for i := 0; i < siblings; i++ {
+		sb.WriteString("<d>")
+		for j := 0; j < children; j++ {
+			fmt.Fprintf(&sb, "<e>%d</e>", j)
+		}
+		sb.WriteString("</d>")
+	}
+	sb.WriteString("</c></b></a></r>")
+	doc := []byte(sb.String())
+
+	// start positions are picked once so that both navigations make the same
+	// moves
+	rnd := rand.New(rand.NewSource(1))
+	starts := make([][2]int, 64)
+	for i := range starts {
+		starts[i] = [2]int{rnd.Intn(siblings - 1), rnd.Intn(children - 1)}
+	}
+	move := func(nav *navigation.VtdNav, direction navigation.Direction, count int) {
+		for i := 0; i < count; i++ {
+			ok, err := nav.ToElement(direction)
+			if err != nil || !ok {
+				b.Fatalf("move %d failed: %v", direction, err)
+			}
+		}
+	}
+
+	for _, lcDepth := range []int{3, 5} {
+		parser, err := NewVtdParser(WithXmlDoc(doc), WithLcDepth(lcDepth))
+		if err != nil {
+			b.Fatal(err)
+		}
+		if err := parser.Parse(); err != nil {
+			b.Fatal(err)
+		}
+		nav, err := parser.GetNav()
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(fmt.Sprintf("LC depth %d", lcDepth), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				start := starts[i%len(starts)]
+				move(nav, navigation.Root, 1)
+				move(nav, navigation.FirstChild, 4)
+				move(nav, navigation.NextSibling, start[0])
+				move(nav, navigation.FirstChild, 1)
+				move(nav, navigation.NextSibling, start[1])
+				move(nav, navigation.NextSibling, 1)
+				move(nav, navigation.PrevSibling, 1)
+				move(nav, navigation.Parent, 1)
+				move(nav, navigation.NextSibling, 1)
+				move(nav, navigation.PrevSibling, 1)
+			}
+		})
+	}
+}
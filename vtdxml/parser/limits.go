@@ -0,0 +1,120 @@
+package parser
+
+import (
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/common"
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/erroring"
+)
+
+const (
+	// DefaultEntityExpansionRatio is the entity expansion ratio enforced when
+	// Limits.MaxEntityExpansionRatio is zero
+	DefaultEntityExpansionRatio = 100
+	// minEntityExpansionLimit is the number of bytes entity references of
+	// small documents may expand to under the default ratio
+	minEntityExpansionLimit = 1 << 20
+)
+
+// Limits bounds resources a document may consume while being parsed, so
+// hostile documents fail early with a typed error instead of exhausting
+// memory. Zero value of a field disables the limit, except for entity
+// expansion which is always bounded. Hard-coded limits of the VTD format
+// (e.g. depth of 254 or token length of 0xFFFFF) apply regardless.
+type Limits struct {
+	// MaxDepth is the maximum element nesting depth, the root element is at
+	// depth 1
+	MaxDepth int
+	// MaxAttributes is the maximum number of attributes of a single element,
+	// namespace declarations included
+	MaxAttributes int
+	// MaxAttrValueLength is the maximum length of an attribute value in bytes
+	// of the document
+	MaxAttrValueLength int
+	// MaxTextTokens is the maximum number of character data and CDATA tokens
+	MaxTextTokens int
+	// MaxTokens is the maximum number of VTD tokens
+	MaxTokens int
+	// MaxEntityExpansionRatio is the maximum ratio of the text produced by
+	// expanding general entity references to the document length. Zero
+	// applies DefaultEntityExpansionRatio, allowing at least 1 MiB of text.
+	MaxEntityExpansionRatio int
+}
+
+// WithLimits option sets limits enforced while parsing
+func WithLimits(limits Limits) Option {
+	return func(p *VtdParser) {
+		p.limits = limits
+	}
+}
+
+// validate function checks none of the limits is negative
+func (l Limits) validate() error {
+	limits := []struct {
+		name  string
+		value int
+	}{
+		{"MaxDepth", l.MaxDepth},
+		{"MaxAttributes", l.MaxAttributes},
+		{"MaxAttrValueLength", l.MaxAttrValueLength},
+		{"MaxTextTokens", l.MaxTextTokens},
+		{"MaxTokens", l.MaxTokens},
+		{"MaxEntityExpansionRatio", l.MaxEntityExpansionRatio},
+	}
+	for _, limit := range limits {
+		if limit.value < 0 {
+			return erroring.NewInvalidArgumentError(limit.name, erroring.CannotBeNegative, nil)
+		}
+	}
+	return nil
+}
+
+// checkDepthLimit function checks depth of the element being started
+func (p *VtdParser) checkDepthLimit() error {
+	if p.limits.MaxDepth > 0 && p.depth >= p.limits.MaxDepth {
+		return erroring.NewDepthLimitError(p.limits.MaxDepth)
+	}
+	return nil
+}
+
+// checkAttributeLimit function checks the number of attributes of the
+// current element before another one is recorded
+func (p *VtdParser) checkAttributeLimit() error {
+	if p.limits.MaxAttributes > 0 && p.attrCount >= p.limits.MaxAttributes {
+		return erroring.NewAttributeLimitError(p.limits.MaxAttributes)
+	}
+	return nil
+}
+
+// checkAttrValueLengthLimit function checks length of the attribute value
+// given in bytes
+func (p *VtdParser) checkAttrValueLengthLimit(length int) error {
+	if p.limits.MaxAttrValueLength > 0 && length > p.limits.MaxAttrValueLength {
+		return erroring.NewAttrValueLengthLimitError(p.limits.MaxAttrValueLength)
+	}
+	return nil
+}
+
+// checkTokenLimits function checks the token of the type can be written
+func (p *VtdParser) checkTokenLimits(tokenType common.Token) error {
+	if p.limits.MaxTokens > 0 && p.vtdBuffer.GetSize() >= p.limits.MaxTokens {
+		return erroring.NewTokenLimitError(p.limits.MaxTokens)
+	}
+	if tokenType == common.TokenCharacterData || tokenType == common.TokenCdataVal {
+		if p.limits.MaxTextTokens > 0 && p.textTokenCount >= p.limits.MaxTextTokens {
+			return erroring.NewTextTokenLimitError(p.limits.MaxTextTokens)
+		}
+		p.textTokenCount++
+	}
+	return nil
+}
+
+// entityExpansionLimit function returns the number of bytes general entity
+// references may expand to
+func (p *VtdParser) entityExpansionLimit() int {
+	if p.limits.MaxEntityExpansionRatio > 0 {
+		return p.limits.MaxEntityExpansionRatio * p.docLength
+	}
+	if limit := DefaultEntityExpansionRatio * p.docLength; limit > minEntityExpansionLimit {
+		return limit
+	}
+	return minEntityExpansionLimit
+}
@@ -0,0 +1,145 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/erroring"
+	"github.com/stretchr/testify/assert"
+)
+
+const billionLaughsXml = `<!DOCTYPE lolz [
+<!ENTITY lol "lol">
+<!ENTITY lol1 "&lol;&lol;&lol;&lol;&lol;&lol;&lol;&lol;&lol;&lol;">
+<!ENTITY lol2 "&lol1;&lol1;&lol1;&lol1;&lol1;&lol1;&lol1;&lol1;&lol1;&lol1;">
+<!ENTITY lol3 "&lol2;&lol2;&lol2;&lol2;&lol2;&lol2;&lol2;&lol2;&lol2;&lol2;">
+<!ENTITY lol4 "&lol3;&lol3;&lol3;&lol3;&lol3;&lol3;&lol3;&lol3;&lol3;&lol3;">
+<!ENTITY lol5 "&lol4;&lol4;&lol4;&lol4;&lol4;&lol4;&lol4;&lol4;&lol4;&lol4;">
+<!ENTITY lol6 "&lol5;&lol5;&lol5;&lol5;&lol5;&lol5;&lol5;&lol5;&lol5;&lol5;">
+<!ENTITY lol7 "&lol6;&lol6;&lol6;&lol6;&lol6;&lol6;&lol6;&lol6;&lol6;&lol6;">
+<!ENTITY lol8 "&lol7;&lol7;&lol7;&lol7;&lol7;&lol7;&lol7;&lol7;&lol7;&lol7;">
+<!ENTITY lol9 "&lol8;&lol8;&lol8;&lol8;&lol8;&lol8;&lol8;&lol8;&lol8;&lol8;">
+]>
+<lolz>&lol9;</lolz>`
+
+func Test_VtdParser_Parse_Limits_Exceeded(t *testing.T) {
+	testCases := []struct {
+		name           string
+		doc            string
+		limits         Limits
+		expectedType   interface{}
+		expectedErrMsg string
+	}{
+		{
+			name:           "element depth",
+			doc:            "<a><b><c><d/></c></b></a>",
+			limits:         Limits{MaxDepth: 3},
+			expectedType:   &erroring.DepthLimitErrorType,
+			expectedErrMsg: "a limit was exceeded: element depth exceeds the limit of 3",
+		},
+		{
+			name:           "attributes per element",
+			doc:            `<a x="1" y="2"><b x="1" y="2" z="3"/></a>`,
+			limits:         Limits{MaxAttributes: 2},
+			expectedType:   &erroring.AttributeLimitErrorType,
+			expectedErrMsg: "a limit was exceeded: number of attributes exceeds the limit of 2",
+		},
+		{
+			name:           "attribute value length",
+			doc:            `<a x="short" y="too long"/>`,
+			limits:         Limits{MaxAttrValueLength: 5},
+			expectedType:   &erroring.AttrValueLengthLimitErrorType,
+			expectedErrMsg: "a limit was exceeded: attribute value length exceeds the limit of 5",
+		},
+		{
+			name:           "text tokens",
+			doc:            "<a>1<b/>2<![CDATA[3]]></a>",
+			limits:         Limits{MaxTextTokens: 2},
+			expectedType:   &erroring.TextTokenLimitErrorType,
+			expectedErrMsg: "a limit was exceeded: number of text tokens exceeds the limit of 2",
+		},
+		{
+			name:           "total tokens",
+			doc:            `<a x="1"><b/><c/></a>`,
+			limits:         Limits{MaxTokens: 4},
+			expectedType:   &erroring.TokenLimitErrorType,
+			expectedErrMsg: "a limit was exceeded: number of tokens exceeds the limit of 4",
+		},
+		{
+			name:           "entity expansion ratio",
+			doc:            billionLaughsXml,
+			limits:         Limits{MaxEntityExpansionRatio: 10},
+			expectedType:   &erroring.EntityExpansionLimitErrorType,
+			expectedErrMsg: fmt.Sprintf("a limit was exceeded: entity expansion exceeds the limit of %d bytes", 10*len(billionLaughsXml)),
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			parser, err := NewVtdParser(WithXmlDoc([]byte(tc.doc)), WithLimits(tc.limits))
+			assert.Nil(t, err)
+
+			err = parser.Parse()
+			assert.EqualError(t, err, tc.expectedErrMsg)
+			assert.True(t, errors.As(err, tc.expectedType))
+		})
+	}
+}
+
+func Test_VtdParser_Parse_Limits_NotExceeded(t *testing.T) {
+	doc := `<!DOCTYPE a [<!ENTITY e "entity">]><a x="1" y="&e;"><b>&e;</b><![CDATA[c]]></a>`
+	parser, err := NewVtdParser(WithXmlDoc([]byte(doc)), WithLimits(Limits{
+		MaxDepth:                2,
+		MaxAttributes:           2,
+		MaxAttrValueLength:      3,
+		MaxTextTokens:           2,
+		MaxTokens:               10,
+		MaxEntityExpansionRatio: 1,
+	}))
+	assert.Nil(t, err)
+	assert.Nil(t, parser.Parse())
+}
+
+func Test_VtdParser_Parse_Limits_DefaultEntityExpansion(t *testing.T) {
+	parser, err := NewVtdParser(WithXmlDoc([]byte(billionLaughsXml)))
+	assert.Nil(t, err)
+
+	err = parser.Parse()
+	assert.EqualError(t, err, fmt.Sprintf("a limit was exceeded: entity expansion exceeds the limit of %d bytes",
+		minEntityExpansionLimit))
+	assert.True(t, errors.As(err, &erroring.EntityExpansionLimitErrorType))
+}
+
+func Test_VtdParser_GetNav_EntityExpansionLimit(t *testing.T) {
+	doc := `<!DOCTYPE a [<!ENTITY e "entity">]><a>&e;</a>`
+	nav := parseTestNav(t, []byte(doc), WithLimits(Limits{MaxEntityExpansionRatio: 1}))
+	text, err := nav.ToStringAtIndex(3)
+	assert.Nil(t, err)
+	assert.Equal(t, "entity", text)
+
+	nav.SetEntityExpansionLimit(5)
+	_, err = nav.ToStringAtIndex(3)
+	assert.EqualError(t, err, "a limit was exceeded: entity expansion exceeds the limit of 5 bytes")
+}
+
+func Test_VtdParser_Parse_ManyAttributes(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString("<a")
+	for i := 0; i < 2*DefaultAttrArraySize; i++ {
+		sb.WriteString(fmt.Sprintf(` a%d="%d"`, i, i))
+	}
+	sb.WriteString("/>")
+
+	parser, err := NewVtdParser(WithXmlDoc([]byte(sb.String())))
+	assert.Nil(t, err)
+	assert.Nil(t, parser.Parse())
+	assert.Equal(t, 2+4*DefaultAttrArraySize, parser.vtdBuffer.GetSize())
+}
+
+func Test_VtdParser_WithLimits_InvalidArgument(t *testing.T) {
+	parser, err := NewVtdParser(WithXmlDoc([]byte("<a/>")), WithLimits(Limits{MaxTokens: -1}))
+	assert.Nil(t, parser)
+	assert.EqualError(t, err, "invalid argument MaxTokens: cannot be negative")
+}
@@ -0,0 +1,59 @@
+package parser
+
+import "github.com/alexZaicev/go-vtd-xml/vtdxml/buffer"
+
+// WithMappedFile option parses the file through a read-only memory mapping
+// instead of copying it into the heap. Without buffer reuse the mapping is
+// handed over to the navigation object returned by GetNav and released by
+// VtdNav.Close. With buffer reuse the parser keeps the mapping and releases
+// it on Close, Reset with another document, or mapping another file, while
+// navigation objects receive a copy of the document.
+func WithMappedFile(path string) Option {
+	return func(p *VtdParser) {
+		p.mappedPath = path
+	}
+}
+
+// Close function releases the memory-mapped document owned by the parser.
+// Navigation objects sharing the mapping must not be used afterwards.
+func (p *VtdParser) Close() error {
+	if p.mappedFile == nil {
+		return nil
+	}
+	if p.usesMappedFile() {
+		p.xmlDoc = nil
+		p.parsed = false
+	}
+	m := p.mappedFile
+	p.mappedFile = nil
+	return m.Close()
+}
+
+// openMappedFile function maps the file requested with WithMappedFile and
+// releases the mapping that is no longer used as the parser document
+func (p *VtdParser) openMappedFile() error {
+	if p.mappedPath == "" {
+		if p.mappedFile != nil && !p.usesMappedFile() {
+			return p.Close()
+		}
+		return nil
+	}
+	if err := p.Close(); err != nil {
+		return err
+	}
+	path := p.mappedPath
+	p.mappedPath = ""
+	m, err := buffer.NewMappedByteBuffer(path)
+	if err != nil {
+		return err
+	}
+	p.mappedFile = m
+	WithXmlDoc(m.GetBytes())(p)
+	return nil
+}
+
+// usesMappedFile function checks if the parser document is the mapped file
+func (p *VtdParser) usesMappedFile() bool {
+	mapped := p.mappedFile.GetBytes()
+	return len(p.xmlDoc) > 0 && len(mapped) > 0 && &p.xmlDoc[0] == &mapped[0]
+}
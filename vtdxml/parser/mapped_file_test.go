@@ -0,0 +1,79 @@
+//go:build linux
+// +build linux
+
+package parser
+
+import (
+	"os"
+	"testing"
+
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/navigation"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_VtdParser_WithMappedFile_Success(t *testing.T) {
+	parser, err := NewVtdParser(WithMappedFile(testDataPath("xml_opt1")), WithNameSpaceAware(true))
+	assert.Nil(t, err)
+	assert.Nil(t, parser.Parse())
+
+	nav, err := parser.GetNav()
+	assert.Nil(t, err)
+	// mapping now belongs to the navigation object
+	assert.Nil(t, parser.mappedFile)
+	assert.Nil(t, parser.Close())
+
+	assertCurrentElement(t, nav, "pre:Vehicle")
+	assertMove(t, nav, navigation.LastChild, "engine")
+	assert.Nil(t, nav.Close())
+	assert.Nil(t, nav.Close())
+}
+
+func Test_VtdParser_WithMappedFile_BufferReuse(t *testing.T) {
+	parser, err := NewVtdParser(WithMappedFile(testDataPath("xml_opt1")), WithBufferReuse(true))
+	assert.Nil(t, err)
+	assert.Nil(t, parser.Parse())
+
+	nav, err := parser.GetNav()
+	assert.Nil(t, err)
+	// navigation objects copy the document of the mapping owned by the parser
+	assert.NotNil(t, parser.mappedFile)
+	assertMove(t, nav, navigation.FirstChild, "seats")
+
+	// mapping another file releases the previous mapping
+	previous := parser.mappedFile
+	assert.Nil(t, parser.Reset(WithMappedFile(testDataPath("camt.004.001.08"))))
+	assert.Equal(t, 0, previous.GetSize())
+	// navigation objects handed out earlier are not affected
+	assertMove(t, nav, navigation.NextSibling, "colour")
+	assert.Nil(t, nav.Close())
+	assert.Nil(t, parser.Parse())
+	nav, err = parser.GetNav()
+	assert.Nil(t, err)
+	assertCurrentElement(t, nav, "Document")
+
+	// switching to an in-memory document releases the mapping
+	previous = parser.mappedFile
+	assert.Nil(t, parser.Reset(WithXmlDoc(readTestData(t, "xml_opt2", true))))
+	assert.Nil(t, parser.mappedFile)
+	assert.Equal(t, 0, previous.GetSize())
+	assert.Nil(t, parser.Parse())
+
+	assert.Nil(t, parser.Close())
+}
+
+func Test_VtdParser_WithMappedFile_Close(t *testing.T) {
+	parser, err := NewVtdParser(WithMappedFile(testDataPath("xml_opt1")))
+	assert.Nil(t, err)
+	assert.Nil(t, parser.Close())
+	assert.EqualError(t, parser.Parse(), "invalid argument xmlDoc: cannot be nil")
+}
+
+func Test_VtdParser_WithMappedFile_Failed(t *testing.T) {
+	parser, err := NewVtdParser(WithMappedFile(testDataPath("missing")))
+	assert.Nil(t, parser)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	parser, err = NewVtdParser(WithMappedFile(testDataPath("xml_opt1")), WithMaxDocumentSize(16))
+	assert.Nil(t, parser)
+	assert.EqualError(t, err, "invalid argument xmlDoc: document exceeds maximum size")
+}
@@ -0,0 +1,228 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/buffer"
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/erroring"
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/navigation"
+)
+
+const (
+	// unboundNs and xmlNs stand for namespace declaration index of names in
+	// no namespace and of names with the xml prefix, which is bound without
+	// a declaration
+	unboundNs = -1
+	xmlNs     = -2
+)
+
+// initNsUrlBuffer function creates the buffer holding namespace URL index of
+// every VTD record in namespace aware mode
+func (p *VtdParser) initNsUrlBuffer() error {
+	if !p.nsAware || p.nsUrlBuffer != nil {
+		return nil
+	}
+	b, err := buffer.NewFastLongBuffer([]buffer.FastLongBufferOption{
+		buffer.WithFastLongBufferPageSize(pageExp(p.docLength >> 4)),
+	}...)
+	if err != nil {
+		return err
+	}
+	p.nsUrlBuffer = b
+	return nil
+}
+
+// padNsUrls function appends NsUrlNone to the namespace URL buffer up to the
+// number of VTD records written, so that it holds an entry for each of them
+func (p *VtdParser) padNsUrls() error {
+	if p.nsUrlBuffer == nil {
+		return nil
+	}
+	for p.nsUrlBuffer.GetSize() < p.vtdBuffer.GetSize() {
+		if err := p.nsUrlBuffer.Append(navigation.NsUrlNone); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkQName function checks the element or attribute name at the offset
+// given has at most one colon, which neither starts nor ends it
+func (p *VtdParser) checkQName(offset, length int) error {
+	colons, first, err := p.countColons(offset, length)
+	if err != nil {
+		return err
+	}
+	if colons > 1 || (colons == 1 && (first == 0 || first == length-p.increment)) {
+		return p.newParseErrorAt(offset, fmt.Sprintf("%s: %s", erroring.InvalidQName, p.nameString(offset, length)))
+	}
+	return nil
+}
+
+// countColons function returns the number of colons in the name at the
+// offset given and offset of the first one relative to the name start
+func (p *VtdParser) countColons(offset, length int) (int, int, error) {
+	colons, first := 0, -1
+	for k := 0; k < length; k += p.increment {
+		ch, err := p.getCharUnit(offset + k)
+		if err != nil {
+			return 0, 0, err
+		}
+		if ch == ':' {
+			if colons == 0 {
+				first = k
+			}
+			colons++
+		}
+	}
+	return colons, first, nil
+}
+
+// nameString function decodes the name at the offset given for error
+// messages
+func (p *VtdParser) nameString(offset, length int) string {
+	name, err := p.docString(offset, length)
+	if err != nil {
+		return ""
+	}
+	return name
+}
+
+// resolvePrefix function returns index of the innermost namespace
+// declaration in scope binding the prefix at the offset given, or unboundNs
+// if there is none. Prefix of zero length stands for the default namespace.
+func (p *VtdParser) resolvePrefix(offset, length int) (int, error) {
+	for i := p.nsBuffer3.GetSize() - 1; i >= 0; i-- {
+		upper, err := p.nsBuffer3.Upper32At(i)
+		if err != nil {
+			return unboundNs, err
+		}
+		lower, err := p.nsBuffer3.Lower32At(i)
+		if err != nil {
+			return unboundNs, err
+		}
+		// declarations of the default namespace have no prefix
+		declOffset, declLength := int(lower), 0
+		if xmlnsLength := int(upper >> 16); xmlnsLength != 0 {
+			declOffset += xmlnsLength + p.increment
+			declLength = int(upper&0xFFFF) - xmlnsLength - p.increment
+		}
+		if declLength == length &&
+			bytes.Equal(p.xmlDoc[declOffset:declOffset+length], p.xmlDoc[offset:offset+length]) {
+			return i, nil
+		}
+	}
+	return unboundNs, nil
+}
+
+// checkNsDeclaration function checks value of the namespace declaration just
+// read does not bind reserved prefixes and namespace names, and records it as
+// in scope unless it declares the xml prefix
+func (p *VtdParser) checkNsDeclaration() error {
+	if !p.defaultNs && p.length1 == 0 {
+		return p.newParseError(erroring.NonDefaultNsEmpty)
+	}
+	nsUrlType, err := p.identifyNsUrl()
+	if err != nil {
+		return err
+	}
+	switch {
+	case p.isXml && nsUrlType != NsUrl1998:
+		return p.newParseError(fmt.Sprintf("%s: xml can only be bound to %s", erroring.NsPrefixReserved, XMLNS1998))
+	case p.isXml:
+		// xml prefix is bound whether declared or not
+		return nil
+	case nsUrlType == NsUrl1998:
+		return p.newParseError(fmt.Sprintf("%s: %s can only be bound to xml", erroring.NsUrlReserved, XMLNS1998))
+	case nsUrlType == NsUrl2000:
+		return p.newParseError(fmt.Sprintf("%s: %s cannot be declared", erroring.NsUrlReserved, XMLNS2000))
+	}
+	if err := p.nsBuffer2.Append(int64(p.lastOffset<<32 | p.length1)); err != nil {
+		return err
+	}
+	// value of the declaration is the next VTD record
+	return p.nsBuffer4.Append(int32(p.vtdBuffer.GetSize()))
+}
+
+// qualifyNames function resolves namespaces of the element whose start tag
+// was just read and of its attributes, checks expanded attribute names are
+// unique and records namespace URL index of their VTD records
+func (p *VtdParser) qualifyNames() error {
+	if err := p.nsBuffer1.Append(int32(p.nsBuffer3.GetSize() - 1)); err != nil {
+		return err
+	}
+	elementNs, err := p.qualifyElement()
+	if err != nil {
+		return err
+	}
+	if p.prefixedAttCount > 0 {
+		if err := p.qualifyAttributes(); err != nil {
+			return err
+		}
+		if err := p.checkQualifiedAttributeUniqueness(); err != nil {
+			return err
+		}
+	}
+	if err := p.padNsUrls(); err != nil {
+		return err
+	}
+	if err := p.recordNsUrl(p.currentElementIndex, elementNs); err != nil {
+		return err
+	}
+	for j := 0; j < p.prefixedAttCount; j++ {
+		if err := p.recordNsUrl(p.prefixedAttrIndexSlice[j], p.prefixUrlSlice[j]); err != nil {
+			return err
+		}
+	}
+	p.prefixedAttCount = 0
+	return nil
+}
+
+// qualifyElement function resolves prefix of the current element, or the
+// default namespace if it has none, and returns index of the namespace
+// declaration in scope, xmlNs or unboundNs
+func (p *VtdParser) qualifyElement() (int, error) {
+	if p.currentElementRecord == 0 {
+		i, err := p.resolvePrefix(0, 0)
+		if err != nil || i == unboundNs {
+			return unboundNs, err
+		}
+		// default namespace is undeclared by xmlns=""
+		length, err := p.nsBuffer2.Lower32At(i)
+		if err != nil || length == 0 {
+			return unboundNs, err
+		}
+		return i, nil
+	}
+	preLen := int(p.currentElementRecord>>48) & 0xFFFF
+	preOs := int(int32(p.currentElementRecord))
+	if p.checkXmlPrefix(preOs, preLen, true) {
+		return xmlNs, nil
+	}
+	i, err := p.resolvePrefix(preOs, preLen-p.increment)
+	if err != nil {
+		return unboundNs, err
+	}
+	if i == unboundNs {
+		return unboundNs, p.newParseErrorAt(preOs,
+			fmt.Sprintf("%s: %s", erroring.NsPrefixNotDeclared, p.nameString(preOs, preLen-p.increment)))
+	}
+	return i, nil
+}
+
+// recordNsUrl function sets namespace URL index of the VTD record to the
+// value token of the namespace declaration given
+func (p *VtdParser) recordNsUrl(index, ns int) error {
+	switch ns {
+	case unboundNs:
+		return p.nsUrlBuffer.ModifyEntry(index, navigation.NsUrlNone)
+	case xmlNs:
+		return p.nsUrlBuffer.ModifyEntry(index, navigation.NsUrlXml)
+	}
+	urlIndex, err := p.nsBuffer4.IntAt(ns)
+	if err != nil {
+		return err
+	}
+	return p.nsUrlBuffer.ModifyEntry(index, int64(urlIndex))
+}
@@ -0,0 +1,220 @@
+package parser
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/navigation"
+	"github.com/stretchr/testify/assert"
+)
+
+const nsUrlXml = `<a xmlns="u" xmlns:p="v"><p:b p:x="1" y="2" xml:lang="en"/><c xmlns=""/></a>`
+
+// tokenNsUrls function returns namespace URL index and namespace URL of
+// element and attribute name tokens of nsUrlXml
+func tokenNsUrls(t *testing.T, nav *navigation.VtdNav) ([]int32, []string) {
+	var indexes []int32
+	var urls []string
+	for _, index := range []int{1, 2, 6, 7, 9, 11, 13} {
+		urlIndex, err := nav.GetTokenNsUrlIndex(index)
+		assert.Nil(t, err)
+		url, err := nav.GetTokenNsUrl(index)
+		assert.Nil(t, err)
+		indexes = append(indexes, urlIndex)
+		urls = append(urls, url)
+	}
+	return indexes, urls
+}
+
+func Test_VtdParser_NameSpaceAware_NsUrls(t *testing.T) {
+	expectedIndexes := []int32{3, navigation.NsUrlNone, 5, 5, navigation.NsUrlNone, navigation.NsUrlXml,
+		navigation.NsUrlNone}
+	expectedUrls := []string{"u", "", "v", "v", "", navigation.XmlNamespace, ""}
+
+	testCases := []struct {
+		name string
+		doc  []byte
+		opts []Option
+	}{
+		{
+			name: "UTF-8",
+			doc:  []byte(nsUrlXml),
+		},
+		{
+			name: "UTF-16LE",
+			doc:  encodeUtf16(nsUrlXml, false, true),
+		},
+		{
+			name: "UTF-8 with buffer reuse",
+			doc:  []byte(nsUrlXml),
+			opts: []Option{WithBufferReuse(true)},
+		},
+		{
+			name: "UTF-8 with extended VTD",
+			doc:  []byte(nsUrlXml),
+			opts: []Option{WithExtendedVtd(true)},
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			nav := parseTestNav(t, tc.doc, append(tc.opts, WithNameSpaceAware(true))...)
+			indexes, urls := tokenNsUrls(t, nav)
+			assert.Equal(t, expectedIndexes, indexes)
+			assert.Equal(t, expectedUrls, urls)
+
+			var index bytes.Buffer
+			assert.Nil(t, nav.WriteIndex(&index))
+			loaded, err := navigation.LoadIndex(tc.doc, &index)
+			assert.Nil(t, err)
+			indexes, urls = tokenNsUrls(t, loaded)
+			assert.Equal(t, expectedIndexes, indexes)
+			assert.Equal(t, expectedUrls, urls)
+		})
+	}
+}
+
+func Test_VtdParser_NameSpaceAware_NsUrlsNotResolved(t *testing.T) {
+	nav := parseTestNav(t, []byte(nsUrlXml))
+	_, err := nav.GetTokenNsUrlIndex(1)
+	assert.EqualError(t, err, "an internal error occurred: namespace URLs are resolved by namespace aware parsing only")
+}
+
+func Test_VtdParser_NameSpaceAware_Success(t *testing.T) {
+	testCases := []struct {
+		name string
+		doc  string
+	}{
+		{
+			name: "xml prefix declared with its namespace name",
+			doc:  `<a xmlns:xml="http://www.w3.org/XML/1998/namespace" xml:lang="en"/>`,
+		},
+		{
+			name: "xml prefix used without declaration",
+			doc:  `<xml:a xml:lang="en"/>`,
+		},
+		{
+			name: "same local names in different namespaces",
+			doc:  `<a xmlns:p="u" xmlns:q="v" p:x="1" q:x="2"/>`,
+		},
+		{
+			name: "prefixed and unprefixed attributes with the same local name",
+			doc:  `<a xmlns:p="u" p:x="1" x="2"/>`,
+		},
+		{
+			name: "prefix bound again in a child element",
+			doc:  `<a xmlns:p="u" xmlns:q="v"><b xmlns:p="w" p:x="1" q:x="2"/><p:c/></a>`,
+		},
+		{
+			name: "default namespace undeclared",
+			doc:  `<a xmlns="u"><b xmlns=""/></a>`,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			parser, err := NewVtdParser(WithXmlDoc([]byte(tc.doc)), WithNameSpaceAware(true))
+			assert.Nil(t, err)
+			assert.Nil(t, parser.Parse())
+		})
+	}
+}
+
+func Test_VtdParser_NameSpaceAware_Failed(t *testing.T) {
+	testCases := []struct {
+		name        string
+		doc         string
+		expectedErr string
+	}{
+		{
+			name:        "undeclared element prefix",
+			doc:         `<a><b xmlns:p="u"/><p:c/></a>`,
+			expectedErr: "a parse error occurred: namespace prefix not declared: p",
+		},
+		{
+			name:        "undeclared attribute prefix",
+			doc:         `<a xmlns:p="u" p:x="1" q:y="2"/>`,
+			expectedErr: "a parse error occurred: namespace prefix not declared: q",
+		},
+		{
+			name:        "prefixes bound to the same namespace name",
+			doc:         `<a xmlns:p="u" xmlns:q="u" p:x="1" q:x="2"/>`,
+			expectedErr: "a parse error occurred: attribute expanded name not unique: q:x",
+		},
+		{
+			name:        "namespace names equal once references are resolved",
+			doc:         `<a xmlns:p="a&amp;b" xmlns:q="a&#38;b"><b p:x="1" q:x="2"/></a>`,
+			expectedErr: "a parse error occurred: attribute expanded name not unique: q:x",
+		},
+		{
+			name:        "prefix undeclared",
+			doc:         `<a xmlns:p=""/>`,
+			expectedErr: "a parse error occurred: non-default namespace cannot be empty",
+		},
+		{
+			name:        "xml prefix bound to other namespace name",
+			doc:         `<a xmlns:xml="u"/>`,
+			expectedErr: "a parse error occurred: reserved namespace prefix: xml can only be bound to http://www.w3.org/XML/1998/namespace",
+		},
+		{
+			name:        "xmlns prefix declared",
+			doc:         `<a xmlns:xmlns="u"/>`,
+			expectedErr: "a parse error occurred: reserved namespace prefix: xmlns cannot be declared",
+		},
+		{
+			name:        "xmlns element prefix",
+			doc:         `<xmlns:a/>`,
+			expectedErr: "a parse error occurred: reserved namespace prefix: xmlns cannot be element prefix",
+		},
+		{
+			name:        "xml namespace name bound to other prefix",
+			doc:         `<a xmlns:p="http://www.w3.org/XML/1998/namespace"/>`,
+			expectedErr: "a parse error occurred: reserved namespace name: http://www.w3.org/XML/1998/namespace can only be bound to xml",
+		},
+		{
+			name:        "xml namespace name as default namespace",
+			doc:         `<a xmlns="http://www.w3.org/XML/1998/namespace"/>`,
+			expectedErr: "a parse error occurred: reserved namespace name: http://www.w3.org/XML/1998/namespace can only be bound to xml",
+		},
+		{
+			name:        "xmlns namespace name declared",
+			doc:         `<a xmlns:p="http://www.w3.org/2000/xmlns/"/>`,
+			expectedErr: "a parse error occurred: reserved namespace name: http://www.w3.org/2000/xmlns/ cannot be declared",
+		},
+		{
+			name:        "element name with two colons",
+			doc:         `<a:b:c xmlns:a="u"/>`,
+			expectedErr: "a parse error occurred: name is not a valid QName: a:b:c",
+		},
+		{
+			name:        "element name with empty prefix",
+			doc:         `<:a/>`,
+			expectedErr: "a parse error occurred: name is not a valid QName: :a",
+		},
+		{
+			name:        "attribute name with empty local part",
+			doc:         `<a xmlns:p="u" p:="1"/>`,
+			expectedErr: "a parse error occurred: name is not a valid QName: p:",
+		},
+		{
+			name:        "processing instruction target with colon",
+			doc:         `<a><?p:i x?></a>`,
+			expectedErr: "a parse error occurred: processing instruction target cannot contain colon",
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			for _, doc := range [][]byte{[]byte(tc.doc), encodeUtf16(tc.doc, true, true)} {
+				parser, err := NewVtdParser(WithXmlDoc(doc), WithNameSpaceAware(true))
+				assert.Nil(t, err)
+				assert.EqualError(t, parser.Parse(), tc.expectedErr)
+			}
+
+			// namespaces are not checked unless parsing is namespace aware
+			parser, err := NewVtdParser(WithXmlDoc([]byte(tc.doc)))
+			assert.Nil(t, err)
+			assert.Nil(t, parser.Parse())
+		})
+	}
+}
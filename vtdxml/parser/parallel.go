@@ -0,0 +1,301 @@
+package parser
+
+import (
+	"sync"
+
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/common"
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/reader"
+)
+
+const (
+	// DefaultMinChunkSize is the smallest part of a document, in bytes,
+	// parsed by a goroutine of its own in parallel mode
+	DefaultMinChunkSize = 1024 * 1024
+	// chunkBaseDepth is the depth a chunk parser starts at, so that end tags
+	// of up to chunkBaseDepth elements started before the chunk fit the tag
+	// stack
+	chunkBaseDepth = 127
+)
+
+// WithParallelism option enables parallel parsing of large documents by the
+// number of goroutines given. The document is split at < characters starting
+// an element inside the root element, chunks are parsed concurrently and their
+// tokens are joined into the same index sequential parsing produces. A chunk
+// whose start turns out not to be markup, e.g. because it lies in a comment,
+// and a chunk that is not well-formed are parsed again sequentially, so
+// errors are reported the same way too. Values below 2 keep parsing
+// sequential, as do namespace aware parsing, fragment mode, handlers,
+// tracers, limits and WhitespacePreserve, which all depend on state carried
+// through the document.
+func WithParallelism(workers int) Option {
+	return func(p *VtdParser) {
+		p.parallelism = workers
+	}
+}
+
+// WithMinChunkSize option sets the smallest part of a document parsed by a
+// goroutine of its own in parallel mode, DefaultMinChunkSize by default
+func WithMinChunkSize(size int) Option {
+	return func(p *VtdParser) {
+		p.minChunkSize = size
+	}
+}
+
+// chunkToken is a token written by a chunk parser, its depth is relative to
+// chunkBaseDepth
+type chunkToken struct {
+	tokenType             common.Token
+	offset, length, depth int
+}
+
+// chunkEndTag is an end tag of an element started before the chunk, its name
+// is checked once the chunk is joined
+type chunkEndTag struct {
+	depth, offset, length int
+}
+
+// chunk is a part of the document between two < characters, parsed by a
+// parser of its own
+type chunk struct {
+	start, end int
+	parser     *VtdParser
+	tokens     []chunkToken
+	endTags    []chunkEndTag
+	err        error
+}
+
+// startParallel function returns offset after which the parser may switch
+// to parallel parsing, or zero if the document is parsed sequentially. The
+// prolog and the start of the root element are parsed sequentially first.
+func (p *VtdParser) startParallel() int {
+	if p.parallelism < 2 || p.nsAware || p.fragment || p.stream || p.handler != nil || p.tracer != nil ||
+		p.limits != (Limits{}) || p.whitespacePolicy == WhitespacePreserve {
+		return 0
+	}
+	if p.endOffset-p.offset < 2*p.minChunkSize {
+		return 0
+	}
+	return p.offset + p.minChunkSize/2
+}
+
+// parseParallel function parses the rest of the document in chunks when the
+// parser reads < starting an element inside the root element. The parser
+// is then positioned at the start of the first chunk which was not joined,
+// or of the last element of the document, and parses the rest sequentially.
+func (p *VtdParser) parseParallel() {
+	ltOffset := p.offset - p.increment
+	if p.depth < 0 || !p.startsElementAt(ltOffset) {
+		return
+	}
+	p.parallelStart = 0
+
+	chunks := p.splitChunks(ltOffset)
+	var wg sync.WaitGroup
+	for _, c := range chunks {
+		wg.Add(1)
+		go func(c *chunk) {
+			defer wg.Done()
+			c.parse(p)
+		}(c)
+	}
+	wg.Wait()
+
+	resume := ltOffset
+	for _, c := range chunks {
+		if !p.joinChunk(c) {
+			break
+		}
+		resume = c.end
+	}
+	p.setOffset(resume + p.increment)
+	p.currentChar = '<'
+}
+
+// splitChunks function splits the document from the < at the offset given
+// up to the last element it starts into chunks starting with an element
+func (p *VtdParser) splitChunks(start int) []*chunk {
+	end := p.endOffset - p.increment
+	for end > start && !p.startsElementAt(end) {
+		end -= p.increment
+	}
+	count := p.parallelism
+	if n := (end - start) / p.minChunkSize; n < count {
+		count = n
+	}
+	var chunks []*chunk
+	for i, chunkStart := 1, start; i <= count; i++ {
+		next := end
+		if i < count {
+			if next = p.nextElementStart(start + (end-start)/count*i); next > end {
+				next = end
+			}
+		}
+		if next > chunkStart {
+			chunks = append(chunks, &chunk{start: chunkStart, end: next})
+			chunkStart = next
+		}
+	}
+	return chunks
+}
+
+// nextElementStart function returns offset of the first < starting an
+// element at or after the offset given
+func (p *VtdParser) nextElementStart(offset int) int {
+	offset -= (offset - p.docOffset) % p.increment
+	for offset < p.endOffset && !p.startsElementAt(offset) {
+		offset += p.increment
+	}
+	return offset
+}
+
+// startsElementAt function returns true if document holds < followed by
+// an ASCII name start character at the offset given. Offsets between them may
+// fall in comments, CDATA sections or processing instructions, which only
+// parsing tells.
+func (p *VtdParser) startsElementAt(offset int) bool {
+	if offset < p.docOffset || offset+2*p.increment > p.endOffset {
+		return false
+	}
+	lt, next := p.xmlDoc[offset], p.xmlDoc[offset+p.increment]
+	switch p.encoding {
+	case common.FormatUtf16BE:
+		if p.xmlDoc[offset] != 0 || p.xmlDoc[offset+2] != 0 {
+			return false
+		}
+		lt, next = p.xmlDoc[offset+1], p.xmlDoc[offset+3]
+	case common.FormatUtf16LE:
+		if p.xmlDoc[offset+1] != 0 || p.xmlDoc[offset+3] != 0 {
+			return false
+		}
+		next = p.xmlDoc[offset+2]
+	}
+	return lt == '<' && (next == '_' || next == ':' || (next|0x20 >= 'a' && next|0x20 <= 'z'))
+}
+
+// parse function parses the chunk up to the < ending it
+func (c *chunk) parse(p *VtdParser) {
+	cp := &VtdParser{
+		xmlDoc:                p.xmlDoc,
+		offset:                c.start + p.increment,
+		docOffset:             p.docOffset,
+		endOffset:             c.end + p.increment,
+		docLength:             p.docLength,
+		depth:                 chunkBaseDepth,
+		increment:             p.increment,
+		currentChar:           '<',
+		singleByteEncoding:    p.singleByteEncoding,
+		ws:                    p.ws,
+		whitespacePolicy:      p.whitespacePolicy,
+		dtd:                   p.dtd,
+		encoding:              p.encoding,
+		xmlChar:               p.xmlChar,
+		xml11:                 p.xml11,
+		state:                 StateLtSeen,
+		chunk:                 c,
+		tagStack:              make([]int64, DefaultTagArraySize),
+		preserveSpace:         make([]bool, DefaultTagArraySize),
+		attrNameSlice:         make([]int64, DefaultAttrArraySize),
+		prefixedAttrNameSlice: make([]int64, DefaultAttrArraySize),
+		prefixUrlSlice:        make([]int, DefaultAttrArraySize),
+	}
+	if cp.reader, c.err = newReader(p.encoding, p.xml11, p.xmlDoc, cp.offset, cp.endOffset); c.err != nil {
+		return
+	}
+	c.parser = cp
+	for cp.state != StateLtSeen || cp.offset != cp.endOffset {
+		if cp.state, c.err = cp.processState(); c.err != nil {
+			return
+		}
+	}
+}
+
+// joinChunk function writes tokens of the chunk parsed without errors after
+// checking its end tags match elements started before it. It returns false
+// if the chunk cannot be joined and has to be parsed sequentially.
+func (p *VtdParser) joinChunk(c *chunk) bool {
+	if c.err != nil {
+		return false
+	}
+	absolute := func(depth int) int {
+		return p.depth + depth - chunkBaseDepth
+	}
+	for _, t := range c.endTags {
+		// root element ends in the last part of the document only
+		depth := absolute(t.depth)
+		if depth < 1 {
+			return false
+		}
+		offset, length := unpackName(p.tagStack[depth])
+		if length != t.length || string(p.xmlDoc[offset:offset+length]) != string(p.xmlDoc[t.offset:t.offset+length]) {
+			return false
+		}
+	}
+	for _, t := range c.tokens {
+		if absolute(t.depth) > maxDepth {
+			return false
+		}
+	}
+
+	for _, t := range c.tokens {
+		depth := absolute(t.depth)
+		var err error
+		switch {
+		case t.tokenType != common.TokenStartingTag:
+			err = p.writeVtd(t.tokenType, t.offset, t.length, depth)
+		case p.shallowDepth:
+			err = p.writeVtdL3(t.tokenType, t.offset, t.length, depth)
+		default:
+			err = p.writeVtdL5(t.tokenType, t.offset, t.length, depth)
+		}
+		if err != nil {
+			return false
+		}
+		if t.tokenType == common.TokenStartingTag && depth > p.vtdDepth {
+			p.vtdDepth = depth
+		}
+	}
+	for i := 0; i <= c.parser.depth; i++ {
+		if c.parser.tagStack[i] != 0 {
+			p.tagStack[absolute(i)] = c.parser.tagStack[i]
+		}
+	}
+	p.depth = absolute(c.parser.depth)
+	return true
+}
+
+// newReader function creates document reader for the encoding given, UTF-8
+// documents of XML 1.1 are read by characters
+func newReader(enc common.FormatEncoding, xml11 bool, xmlDoc []byte, offset, endOffset int) (reader.Reader, error) {
+	switch {
+	case enc == common.FormatUtf8 && xml11:
+		return reader.NewDecodingUtf8Reader(xmlDoc, offset, endOffset)
+	case enc == common.FormatUtf8:
+		return reader.NewUtf8Reader(xmlDoc, offset, endOffset)
+	case enc == common.FormatAscii:
+		return reader.NewAsciiReader(xmlDoc, offset, endOffset)
+	case enc == common.FormatUtf16BE:
+		return reader.NewUtf16BeReader(xmlDoc, offset, endOffset)
+	case enc == common.FormatUtf16LE:
+		return reader.NewUtf16LeReader(xmlDoc, offset, endOffset)
+	default:
+		return reader.NewSingleByteReader(xmlDoc, offset, endOffset, enc)
+	}
+}
+
+// chunkEndTagName function reads name of the end tag of an element started
+// before the chunk and returns its length. Parser is left at the start of
+// the name.
+func (p *VtdParser) chunkEndTagName() (int, error) {
+	for {
+		if err := p.nextChar(); err != nil {
+			return 0, err
+		}
+		if !p.xmlChar.IsNameChar(p.currentChar) {
+			break
+		}
+	}
+	length := p.offset - p.lastOffset - p.increment
+	p.chunk.endTags = append(p.chunk.endTags, chunkEndTag{depth: p.depth, offset: p.lastOffset, length: length})
+	p.setOffset(p.lastOffset)
+	return length, nil
+}
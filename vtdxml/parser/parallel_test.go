@@ -0,0 +1,153 @@
+package parser
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/navigation"
+	"github.com/stretchr/testify/assert"
+)
+
+var parallelOpts = []Option{WithParallelism(4), WithMinChunkSize(64)}
+
+const parallelXml = `<?xml version="1.0"?>
+<root a="1">
+	<head><id>1</id></head>
+	<!-- <skipped><b/></skipped> spans a chunk boundary, ` + "padding padding padding padding padding" + ` -->
+	<item n="1"><name>one</name><![CDATA[ <not-an-element/> ]]><value>1</value></item>
+	<?pi <not-an-element/>?>
+	<item n="2"><name>two</name><value>2</value><deep><er><est>text &amp; more</est></er></deep></item>
+	<item n="3"><name>three</name><value>3</value></item>
+	<item n="4"><name>four</name><value>4</value></item>
+	<tail/>
+</root>
+`
+
+// assertSameIndex function checks that documents parsed sequentially and in
+// parallel have the same tokens and location caches
+func assertSameIndex(t *testing.T, doc []byte, opts ...Option) {
+	expected := parseTestNav(t, doc, opts...)
+	actual := parseTestNav(t, doc, append(opts, parallelOpts...)...)
+	assertSameTokens(t, expected, actual)
+	var expectedIndex, actualIndex bytes.Buffer
+	assert.Nil(t, expected.WriteIndex(&expectedIndex))
+	assert.Nil(t, actual.WriteIndex(&actualIndex))
+	assert.Equal(t, expectedIndex.Bytes(), actualIndex.Bytes())
+	assert.Equal(t, recordElements(t, expected, false), recordElements(t, actual, false))
+	assert.Equal(t, recordElements(t, expected, true), recordElements(t, actual, true))
+}
+
+func Test_VtdParser_WithParallelism_Success(t *testing.T) {
+	testCases := []struct {
+		name string
+		doc  []byte
+		opts []Option
+	}{
+		{
+			name: "UTF-8 with markup between chunks",
+			doc:  []byte(parallelXml),
+		},
+		{
+			name: "UTF-8 with LC depth 5",
+			doc:  []byte(parallelXml),
+			opts: []Option{WithLcDepth(5)},
+		},
+		{
+			name: "UTF-8 with whitespace dropped",
+			doc:  []byte(parallelXml),
+			opts: []Option{WithWhitespacePolicy(WhitespaceDrop)},
+		},
+		{
+			name: "UTF-8 XML 1.1",
+			doc:  []byte(strings.Replace(parallelXml, `version="1.0"`, `version="1.1"`, 1)),
+		},
+		{
+			name: "UTF-16BE",
+			doc:  encodeUtf16(parallelXml, true, true),
+		},
+		{
+			name: "UTF-16LE with extended VTD",
+			doc:  encodeUtf16(parallelXml, false, true),
+			opts: []Option{WithExtendedVtd(true)},
+		},
+	}
+	for _, name := range []string{
+		"camt.004.001.08", "camt.053.001.08", "mirs.095.001.01", "mirs.095.001.01.no_snl",
+		"xml_opt1", "xml_opt2", "xml_opt3", "xml_opt4", "xml_opt5",
+	} {
+		doc, err := ioutil.ReadFile(testDataPath(name))
+		assert.Nil(t, err)
+		testCases = append(testCases, struct {
+			name string
+			doc  []byte
+			opts []Option
+		}{name: name, doc: doc})
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assertSameIndex(t, tc.doc, tc.opts...)
+		})
+	}
+}
+
+func Test_VtdParser_WithParallelism_Moves(t *testing.T) {
+	nav := parseTestNav(t, []byte(parallelXml), parallelOpts...)
+	assertMove(t, nav, navigation.LastChild, "tail")
+	assertMove(t, nav, navigation.PrevSibling, "item")
+	assertMove(t, nav, navigation.FirstChild, "name")
+	assertMove(t, nav, navigation.NextSibling, "value")
+	assertMove(t, nav, navigation.Parent, "item")
+	assertMove(t, nav, navigation.Root, "root")
+	assertMove(t, nav, navigation.FirstChild, "head")
+	assertMove(t, nav, navigation.NextSibling, "item")
+}
+
+func Test_VtdParser_WithParallelism_Error(t *testing.T) {
+	testCases := []struct {
+		name string
+		doc  string
+	}{
+		{
+			name: "start/end tag mismatch inside a chunk",
+			doc:  strings.Replace(parallelXml, "<value>3</value>", "<value>3</values>", 1),
+		},
+		{
+			name: "end tag of an element started before the chunk",
+			doc:  strings.Replace(parallelXml, "</value></item>\n\t<item n=\"4\">", "</value></items>\n\t<item n=\"4\">", 1),
+		},
+		{
+			name: "root element closed early",
+			doc:  strings.Replace(parallelXml, "<item n=\"3\">", "</root><item n=\"3\">", 1),
+		},
+		{
+			name: "duplicate attribute",
+			doc:  strings.Replace(parallelXml, "<item n=\"4\">", "<item n=\"4\" n=\"5\">", 1),
+		},
+		{
+			name: "unclosed root element",
+			doc:  strings.Replace(parallelXml, "</root>", "", 1),
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			parser, err := NewVtdParser(WithXmlDoc([]byte(tc.doc)))
+			assert.Nil(t, err)
+			expected := parser.Parse()
+			assert.NotNil(t, expected)
+
+			parser, err = NewVtdParser(append([]Option{WithXmlDoc([]byte(tc.doc))}, parallelOpts...)...)
+			assert.Nil(t, err)
+			assert.Equal(t, expected, parser.Parse())
+		})
+	}
+}
+
+func Test_VtdParser_WithMinChunkSize_InvalidArgument(t *testing.T) {
+	parser, err := NewVtdParser(WithXmlDoc([]byte("<a/>")), WithMinChunkSize(0))
+	assert.Nil(t, parser)
+	assert.EqualError(t, err, "invalid argument minChunkSize: must be positive")
+}
@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
@@ -12,93 +13,165 @@ import (
 // If namespace awareness is set to true, VTDGen conforms to XML
 // namespace 1.0 spec
 func (p *VtdParser) Parse() error {
+	return p.ParseContext(context.Background())
+}
+
+// ParseContext function works like Parse, but stops with an error wrapping
+// ctx.Err() once the context is done. Context is checked, and the progress
+// callback is invoked, every check interval bytes (see WithCheckInterval).
+func (p *VtdParser) ParseContext(ctx context.Context) error {
+	if p.xmlDoc == nil || p.reader == nil {
+		return erroring.NewInvalidArgumentError("xmlDoc", erroring.CannotBeNil, nil)
+	}
+	p.state = StateDocStart
+	if err := p.checkpoint(ctx); err != nil {
+		return err
+	}
 	if err := p.decideEncoding(); err != nil {
+		return p.locate(err)
+	}
+	if err := p.initFragmentBuffer(); err != nil {
+		return err
+	}
+	if err := p.initNsUrlBuffer(); err != nil {
 		return err
 	}
 	if err := p.writeVtd(common.TokenDocument, 0, 0, p.depth); err != nil {
 		return err
 	}
 
-	parserState := StateDocStart
+	nextCheck := p.offset + p.checkInterval
+	p.parallelStart = p.startParallel()
 
 	var ps State
 	var err error
 	for {
-		fmt.Printf("Starting process state: %d\n", parserState)
-		fmt.Printf("Offset: %d LastOffset %d CurrentChar %d Length1 %d\n", p.offset, p.lastOffset, p.currentChar,
-			p.length1)
-
-		switch parserState {
-		case StateDocType:
-			ps, err = p.processDocType()
-		case StateDocStart:
-			ps, err = p.processDocStart()
-		case StateDocEnd:
-			ps, err = p.processDocEnd()
-		case StateLtSeen:
-			ps, err = p.processLtSeen()
-		case StateTagStart:
-			ps, err = p.processStartTag()
-		case StateTagEnd:
-			ps, err = p.processEndTag()
-		case StateAttrName:
-			ps, err = p.processAttrName()
-		case StateAttrVal:
-			ps, err = p.processAttrVal()
-		case StateDecAttrName:
-			ps, err = p.processDecAttrName()
-		case StateText:
-			ps, err = p.processText()
-		case StatePiTag:
-			ps, err = p.processPiTag()
-		case StatePiVal:
-			ps, err = p.processPiVal()
-		case StatePiEnd:
-			ps, err = p.processPiEnd()
-		case StateStartComment:
-			ps, err = p.processStartComment()
-		case StateEndComment:
-			ps, err = p.processEndComment()
-		case StateCdata:
-			ps, err = p.processCdata()
-		default:
-			return erroring.NewParseError(
-				"invalid parser state", p.fmtLine(), nil,
-			)
+		if p.offset >= nextCheck {
+			if err := p.checkpoint(ctx); err != nil {
+				return err
+			}
+			nextCheck = p.offset + p.checkInterval
 		}
+		if p.parallelStart > 0 && p.state == StateLtSeen && p.offset > p.parallelStart {
+			p.parseParallel()
+		}
+		ps, err = p.processState()
 
-		if errors.As(err, &erroring.EOFErrorType) && parserState == StateDocEnd {
+		if errors.As(err, &erroring.EOFErrorType) && p.state == StateDocEnd {
 			if err := p.finishUp(); err != nil {
 				return erroring.NewInternalError("failed to finish-up document parsing", err)
 			}
+			p.parsed = true
+			p.reportProgress()
 			return nil
 		} else if err != nil {
-			return err
+			if ps, err = p.recover(p.locate(err)); err != nil {
+				return err
+			}
 		}
 
-		parserState = ps
+		if p.tracer != nil {
+			p.tracer.StateTransition(p.state, ps, p.offset)
+		}
+		p.state = ps
+	}
+}
+
+// processState function processes the current state and returns the next one
+func (p *VtdParser) processState() (State, error) {
+	switch p.state {
+	case StateDocType:
+		return p.processDocType()
+	case StateDocStart:
+		return p.processDocStart()
+	case StateDocEnd:
+		return p.processDocEnd()
+	case StateLtSeen:
+		return p.processLtSeen()
+	case StateTagStart:
+		return p.processStartTag()
+	case StateTagEnd:
+		return p.processEndTag()
+	case StateAttrName:
+		return p.processAttrName()
+	case StateAttrVal:
+		return p.processAttrVal()
+	case StateDecAttrName:
+		return p.processDecAttrName()
+	case StateText:
+		return p.processText()
+	case StatePiTag:
+		return p.processPiTag()
+	case StatePiVal:
+		return p.processPiVal()
+	case StatePiEnd:
+		return p.processPiEnd()
+	case StateStartComment:
+		return p.processStartComment()
+	case StateEndComment:
+		return p.processEndComment()
+	case StateCdata:
+		return p.processCdata()
+	default:
+		return StateInvalid, p.newParseError("invalid parser state")
+	}
+}
+
+// checkpoint function reports parsing progress and returns an error if the
+// context is done
+func (p *VtdParser) checkpoint(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		pErr := erroring.NewParseError(fmt.Sprintf("parsing stopped: %s", err), "", err)
+		p.locateParseError(pErr, p.offset)
+		return pErr
+	}
+	p.reportProgress()
+	return nil
+}
+
+// locate function fills in position of parse errors raised by the document
+// reader, which is not aware of lines
+func (p *VtdParser) locate(err error) error {
+	var pErr *erroring.ParseError
+	if errors.As(err, &pErr) && pErr.Line == 0 {
+		p.locateParseError(pErr, p.offset)
+	}
+	return err
+}
+
+func (p *VtdParser) reportProgress() {
+	if p.progress != nil {
+		p.progress(p.offset-p.docOffset, p.vtdBuffer.GetSize())
 	}
 }
 
 // finishUp function writes the remaining portion of LC info
 func (p *VtdParser) finishUp() error {
 	var err error
-	if p.shallowDepth {
+	if p.fragment && p.lastDepth == 0 {
+		// top-level element without child elements, if any
+		if p.rootIndex != 0 {
+			err = p.appendLc(0, int64((p.lastL0Index<<32)|0xFFFFFFFF))
+		}
+	} else if p.shallowDepth {
 		if p.lastDepth == 1 {
-			err = p.l1Buffer.Append(int64((p.lastL1Index << 32) | 0xFFFF))
+			err = p.appendLc(1, int64((p.lastL1Index<<32)|0xFFFFFFFF))
 		} else if p.lastDepth == 2 {
-			err = p.l2Buffer.Append(int64((p.lastL2Index << 32) | 0xFFFF))
+			err = p.appendLc(2, int64((p.lastL2Index<<32)|0xFFFFFFFF))
 		}
 	} else {
 		if p.lastDepth == 1 {
-			err = p.l1Buffer.Append(int64((p.lastL1Index << 32) | 0xFFFF))
+			err = p.appendLc(1, int64((p.lastL1Index<<32)|0xFFFFFFFF))
 		} else if p.lastDepth == 2 {
-			err = p.l2Buffer.Append(int64((p.lastL2Index << 32) | 0xFFFF))
+			err = p.appendLc(2, int64((p.lastL2Index<<32)|0xFFFFFFFF))
 		} else if p.lastDepth == 3 {
-			err = p.l3Buffer.Append(int64((p.lastL3Index << 32) | 0xFFFF))
+			err = p.appendLc(3, int64((p.lastL3Index<<32)|0xFFFFFFFF))
 		} else if p.lastDepth == 4 {
-			err = p.l4Buffer.Append(int64((p.lastL4Index << 32) | 0xFFFF))
+			err = p.appendLc(4, int64((p.lastL4Index<<32)|0xFFFFFFFF))
 		}
 	}
-	return err
+	if err != nil {
+		return err
+	}
+	return p.padNsUrls()
 }
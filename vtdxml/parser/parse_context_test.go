@@ -0,0 +1,75 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/erroring"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_VtdParser_ParseContext_Progress(t *testing.T) {
+	doc := readTestData(t, "xml_opt5", true)
+	var consumed, tokens []int
+	parser, err := NewVtdParser(
+		WithXmlDoc(doc),
+		WithCheckInterval(512),
+		WithProgress(func(bytesConsumed, tokenCount int) {
+			consumed = append(consumed, bytesConsumed)
+			tokens = append(tokens, tokenCount)
+		}),
+	)
+	assert.Nil(t, err)
+	assert.Nil(t, parser.ParseContext(context.Background()))
+
+	assert.Greater(t, len(consumed), len(doc)/512)
+	for i := 1; i < len(consumed); i++ {
+		assert.GreaterOrEqual(t, consumed[i], consumed[i-1])
+		assert.GreaterOrEqual(t, tokens[i], tokens[i-1])
+	}
+	// last report is made once parsing completes
+	assert.Equal(t, len(doc), consumed[len(consumed)-1])
+	assert.Equal(t, parser.vtdBuffer.GetSize(), tokens[len(tokens)-1])
+}
+
+func Test_VtdParser_ParseContext_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	parser, err := NewVtdParser(
+		WithXmlDoc(readTestData(t, "xml_opt5", true)),
+		WithCheckInterval(512),
+		WithProgress(func(bytesConsumed, tokenCount int) {
+			if bytesConsumed > 0 {
+				cancel()
+			}
+		}),
+	)
+	assert.Nil(t, err)
+
+	err = parser.ParseContext(ctx)
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.IsType(t, erroring.ParseErrorType, err)
+	assert.EqualError(t, err, "a parse error occurred: parsing stopped: context canceled")
+
+	_, err = parser.GetNav()
+	assert.EqualError(t, err, "an internal error occurred: document has not been parsed")
+}
+
+func Test_VtdParser_ParseContext_DeadlineExceeded(t *testing.T) {
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	parser, err := NewVtdParser(WithXmlDoc(readTestData(t, "xml_opt1", true)))
+	assert.Nil(t, err)
+
+	err = parser.ParseContext(ctx)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Equal(t, 0, parser.vtdBuffer.GetSize())
+}
+
+func Test_VtdParser_WithCheckInterval_InvalidArgument(t *testing.T) {
+	parser, err := NewVtdParser(WithXmlDoc(readTestData(t, "xml_opt1", true)), WithCheckInterval(0))
+	assert.Nil(t, parser)
+	assert.EqualError(t, err, "invalid argument checkInterval: must be positive")
+}
@@ -0,0 +1,34 @@
+package parser
+
+import (
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/dtd"
+)
+
+type subsetMode int
+
+const (
+	internalSubset subsetMode = iota
+	externalSubset
+	includeSection
+)
+
+// ParseDtd function parses external DTD subset, e.g. contents of a .dtd
+// file, and returns its declarations. The returned DTD does not declare the
+// document element name. Conditional sections and parameter entities are
+// supported, external parameter entities are not resolved.
+func ParseDtd(dtdDoc []byte) (*dtd.Dtd, error) {
+	p, err := NewVtdParser(WithXmlDoc(dtdDoc))
+	if err != nil {
+		return nil, err
+	}
+	p.externalSubset = true
+	if err := p.decideEncoding(); err != nil {
+		return nil, p.locate(err)
+	}
+	defer p.decodeUtf8()()
+	d := dtd.New("")
+	if err := p.processSubset(d, externalSubset); err != nil {
+		return nil, p.locate(err)
+	}
+	return d, nil
+}
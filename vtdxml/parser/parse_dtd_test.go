@@ -0,0 +1,135 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/dtd"
+	"github.com/stretchr/testify/assert"
+)
+
+const externalDtd = `<?xml version="1.0" encoding="UTF-8"?>
+<!-- catalog -->
+<!ENTITY % draft "INCLUDE">
+<!ENTITY % final "IGNORE">
+<!ENTITY % id.attr "id ID #REQUIRED">
+<!ENTITY % inline "b | i">
+<!ENTITY % decls "<!ELEMENT b (#PCDATA)><!ELEMENT i (#PCDATA)>">
+<!ENTITY % media SYSTEM "media.dtd">
+<!ENTITY title "The %inline; catalog">
+<!ELEMENT catalog (book)+>
+<!ELEMENT book (#PCDATA | %inline;)*>
+<!ATTLIST book %id.attr;>
+<![%draft;[
+  <!ATTLIST book status CDATA #IMPLIED>
+]]>
+<![ %final; [
+  <!ELEMENT ignored EMPTY>
+  <![INCLUDE[ <!ELEMENT nested EMPTY> ]]>
+]]>
+%decls;
+%media;
+`
+
+func Test_ParseDtd_Success(t *testing.T) {
+	d, err := ParseDtd([]byte(externalDtd))
+	assert.Nil(t, err)
+	if !assert.NotNil(t, d) {
+		return
+	}
+	assert.Equal(t, "", d.Name)
+
+	expectedDecls := []struct {
+		declType dtd.DeclarationType
+		name     string
+		content  string
+	}{
+		{dtd.DeclarationEntity, "draft", ""},
+		{dtd.DeclarationEntity, "final", ""},
+		{dtd.DeclarationEntity, "id.attr", ""},
+		{dtd.DeclarationEntity, "inline", ""},
+		{dtd.DeclarationEntity, "decls", ""},
+		{dtd.DeclarationEntity, "media", ""},
+		{dtd.DeclarationEntity, "title", ""},
+		{dtd.DeclarationElement, "catalog", "(book)+"},
+		{dtd.DeclarationElement, "book", "(#PCDATA |  b | i )*"},
+		{dtd.DeclarationAttlist, "book", "id ID #REQUIRED"},
+		{dtd.DeclarationAttlist, "book", "status CDATA #IMPLIED"},
+		{dtd.DeclarationElement, "b", "(#PCDATA)"},
+		{dtd.DeclarationElement, "i", "(#PCDATA)"},
+	}
+	if !assert.Len(t, d.Declarations, len(expectedDecls)) {
+		return
+	}
+	for i, expected := range expectedDecls {
+		decl := d.Declarations[i]
+		assert.Equal(t, expected.declType, decl.Type)
+		assert.Equal(t, expected.name, decl.Name)
+		assert.Equal(t, expected.content, decl.Content)
+	}
+
+	// declarations of parameter entity replacement text are located at the reference
+	decl := d.Declarations[11]
+	assert.Equal(t, "%decls;", externalDtd[decl.Offset:decl.Offset+decl.Length])
+
+	title, err := d.ReplacementText("title")
+	assert.Nil(t, err)
+	assert.Equal(t, "The b | i catalog", title)
+}
+
+func Test_ParseDtd_Failed(t *testing.T) {
+	testCases := []struct {
+		name   string
+		dtdDoc string
+		errMsg string
+	}{
+		{
+			name:   "undeclared parameter entity",
+			dtdDoc: "<!ELEMENT a (%b;)>",
+			errMsg: "undeclared parameter entity b",
+		},
+		{
+			name:   "external parameter entity in declaration",
+			dtdDoc: `<!ENTITY % b SYSTEM "b.ent"><!ELEMENT a (%b;)>`,
+			errMsg: "external parameter entity b is not resolved",
+		},
+		{
+			name:   "recursive parameter entity",
+			dtdDoc: `<!ENTITY % a "&#37;a;">%a;`,
+			errMsg: "recursive reference to parameter entity",
+		},
+		{
+			name:   "unknown conditional section",
+			dtdDoc: "<![MAYBE[ ]]>",
+			errMsg: "unknown conditional section MAYBE",
+		},
+		{
+			name:   "unterminated conditional section",
+			dtdDoc: "<![INCLUDE[ <!ELEMENT a EMPTY> ]>",
+			errMsg: "conditional section must end with ]]>",
+		},
+		{
+			name:   "unexpected ]",
+			dtdDoc: "<!ELEMENT a EMPTY>]",
+			errMsg: "invalid char in DTD",
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := ParseDtd([]byte(tc.dtdDoc))
+			assert.Nil(t, d)
+			if assert.NotNil(t, err) {
+				assert.Contains(t, err.Error(), tc.errMsg)
+			}
+		})
+	}
+}
+
+func Test_VtdParser_Parse_DocType_ConditionalSectionInInternalSubset(t *testing.T) {
+	parser, err := NewVtdParser(WithXmlDoc([]byte("<!DOCTYPE a [<![INCLUDE[<!ELEMENT a EMPTY>]]>]><a/>")))
+	assert.Nil(t, err)
+	err = parser.Parse()
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "conditional section in internal subset")
+	}
+}
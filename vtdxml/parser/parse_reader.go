@@ -0,0 +1,137 @@
+package parser
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/erroring"
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/navigation"
+)
+
+const (
+	// minReadBufferSize is the initial buffer size used when the input size
+	// is unknown
+	minReadBufferSize = 64 * 1024
+)
+
+// ParseReader function reads the whole document from the reader, parses it
+// with options provided and returns navigation object positioned at the root
+// element. Reading stops with an error as soon as the document exceeds the
+// maximum document size (see WithMaxDocumentSize), reading and parsing stop
+// once the context is done.
+func ParseReader(ctx context.Context, r io.Reader, opts ...Option) (*navigation.VtdNav, error) {
+	if r == nil {
+		return nil, erroring.NewInvalidArgumentError("r", erroring.CannotBeNil, nil)
+	}
+	maxSize, err := maxDocumentSizeOf(opts)
+	if err != nil {
+		return nil, err
+	}
+	xmlDoc, err := readDocument(ctx, r, sizeHint(r), maxSize)
+	if err != nil {
+		return nil, err
+	}
+	return parseDocument(ctx, xmlDoc, opts)
+}
+
+// ParseFile function reads the file, parses it with options provided and
+// returns navigation object positioned at the root element
+func ParseFile(path string, opts ...Option) (*navigation.VtdNav, error) {
+	maxSize, err := maxDocumentSizeOf(opts)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > int64(maxSize) {
+		return nil, erroring.NewInvalidArgumentError("xmlDoc", erroring.DocumentTooLarge, nil)
+	}
+	xmlDoc, err := readDocument(context.Background(), f, int(info.Size()), maxSize)
+	if err != nil {
+		return nil, err
+	}
+	return parseDocument(context.Background(), xmlDoc, opts)
+}
+
+func parseDocument(ctx context.Context, xmlDoc []byte, opts []Option) (*navigation.VtdNav, error) {
+	p, err := NewVtdParser(append(opts[:len(opts):len(opts)], WithXmlDoc(xmlDoc))...)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.ParseContext(ctx); err != nil {
+		return nil, err
+	}
+	return p.GetNav()
+}
+
+// maxDocumentSizeOf function returns the maximum document size configured by
+// the options
+func maxDocumentSizeOf(opts []Option) (int, error) {
+	p := &VtdParser{}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p.maxDocumentSize()
+}
+
+// sizeHint function returns the number of bytes the reader is expected to
+// produce, or zero when it is unknown
+func sizeHint(r io.Reader) int {
+	switch v := r.(type) {
+	case interface{ Len() int }:
+		return v.Len()
+	case interface{ Stat() (os.FileInfo, error) }:
+		if info, err := v.Stat(); err == nil && info.Mode().IsRegular() {
+			return int(info.Size())
+		}
+	}
+	return 0
+}
+
+// readDocument function reads the reader until EOF into a buffer sized by
+// the hint, doubling the buffer whenever it fills up
+func readDocument(ctx context.Context, r io.Reader, hint, maxSize int) ([]byte, error) {
+	size := hint
+	if size <= 0 {
+		size = minReadBufferSize
+	}
+	if size > maxSize {
+		size = maxSize
+	}
+	// one extra byte detects documents exceeding the maximum size
+	xmlDoc := make([]byte, 0, size+1)
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if len(xmlDoc) == cap(xmlDoc) {
+			newSize := cap(xmlDoc) * 2
+			if newSize > maxSize+1 {
+				newSize = maxSize + 1
+			}
+			grown := make([]byte, len(xmlDoc), newSize)
+			copy(grown, xmlDoc)
+			xmlDoc = grown
+		}
+		n, err := r.Read(xmlDoc[len(xmlDoc):cap(xmlDoc)])
+		xmlDoc = xmlDoc[:len(xmlDoc)+n]
+		if len(xmlDoc) > maxSize {
+			return nil, erroring.NewInvalidArgumentError("xmlDoc", erroring.DocumentTooLarge, nil)
+		}
+		if err == io.EOF {
+			return xmlDoc, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/navigation"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseReader_Success(t *testing.T) {
+	doc := readTestData(t, "xml_opt1", true)
+	testCases := []struct {
+		name string
+		wrap func(r *bytes.Reader) io.Reader
+	}{
+		{
+			name: "reader with known length",
+			wrap: func(r *bytes.Reader) io.Reader { return r },
+		},
+		{
+			name: "reader with unknown length",
+			wrap: func(r *bytes.Reader) io.Reader { return iotest.OneByteReader(r) },
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			nav, err := ParseReader(context.Background(), tc.wrap(bytes.NewReader(doc)), WithNameSpaceAware(true))
+			assert.Nil(t, err)
+			if assert.NotNil(t, nav) {
+				assertCurrentElement(t, nav, "pre:Vehicle")
+				assertMove(t, nav, navigation.LastChild, "engine")
+			}
+		})
+	}
+}
+
+func Test_ParseReader_Failed(t *testing.T) {
+	doc := readTestData(t, "xml_opt1", true)
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	testCases := []struct {
+		name           string
+		ctx            context.Context
+		opts           []Option
+		expectedErrMsg string
+	}{
+		{
+			name:           "document exceeds maximum size",
+			ctx:            context.Background(),
+			opts:           []Option{WithMaxDocumentSize(len(doc) - 1)},
+			expectedErrMsg: "invalid argument xmlDoc: document exceeds maximum size",
+		},
+		{
+			name:           "negative maximum size",
+			ctx:            context.Background(),
+			opts:           []Option{WithMaxDocumentSize(-1)},
+			expectedErrMsg: "invalid argument maxDocumentSize: array index out of range",
+		},
+		{
+			name:           "maximum size above namespace aware limit",
+			ctx:            context.Background(),
+			opts:           []Option{WithNameSpaceAware(true), WithMaxDocumentSize(MaxNsAwareDocumentSize + 1)},
+			expectedErrMsg: "invalid argument maxDocumentSize: array index out of range",
+		},
+		{
+			name:           "context cancelled",
+			ctx:            cancelled,
+			expectedErrMsg: "context canceled",
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			nav, err := ParseReader(tc.ctx, bytes.NewReader(doc), tc.opts...)
+			assert.Nil(t, nav)
+			assert.EqualError(t, err, tc.expectedErrMsg)
+		})
+	}
+
+	nav, err := ParseReader(context.Background(), iotest.ErrReader(os.ErrClosed))
+	assert.Nil(t, nav)
+	assert.ErrorIs(t, err, os.ErrClosed)
+}
+
+func Test_ParseFile_Success(t *testing.T) {
+	nav, err := ParseFile(testDataPath("camt.004.001.08"), WithLcDepth(5))
+	assert.Nil(t, err)
+	if assert.NotNil(t, nav) {
+		assertCurrentElement(t, nav, "Document")
+		assertMove(t, nav, navigation.FirstChild, "RtrAcct")
+	}
+}
+
+func Test_ParseFile_Failed(t *testing.T) {
+	nav, err := ParseFile(testDataPath("missing"))
+	assert.Nil(t, nav)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	nav, err = ParseFile(testDataPath("xml_opt1"), WithMaxDocumentSize(16))
+	assert.Nil(t, nav)
+	assert.EqualError(t, err, "invalid argument xmlDoc: document exceeds maximum size")
+}
+
+func Test_readDocument_Growth(t *testing.T) {
+	doc := strings.Repeat("<a>text</a>", 100)
+	xmlDoc, err := readDocument(context.Background(), iotest.HalfReader(strings.NewReader(doc)), 16, len(doc))
+	assert.Nil(t, err)
+	assert.Equal(t, doc, string(xmlDoc))
+
+	_, err = readDocument(context.Background(), strings.NewReader(doc), 16, len(doc)-1)
+	assert.EqualError(t, err, "invalid argument xmlDoc: document exceeds maximum size")
+}
+
+func Test_VtdParser_NewVtdParser_DocumentTooLarge(t *testing.T) {
+	parser, err := NewVtdParser(WithXmlDoc([]byte("<a/>")), WithMaxDocumentSize(3))
+	assert.Nil(t, parser)
+	assert.EqualError(t, err, "invalid argument xmlDoc: document exceeds maximum size")
+}
+
+func testDataPath(name string) string {
+	return filepath.Join("..", "..", "testdata", "xml_valid", name+".golden")
+}
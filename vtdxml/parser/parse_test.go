@@ -81,7 +81,7 @@ func Test_VtdParser_Parse_WithoutNsAware_InvalidDocument(t *testing.T) {
 		{
 			name:           "Illegal declarative attribute value (encoding)",
 			filename:       "illegal_dec_attr_encoding_val",
-			expectedErrMsg: "a parse error occurred: invalid document encoding",
+			expectedErrMsg: "unsupported encoding: UPS-8",
 		},
 		{
 			name:           "Illegal declarative attribute name (standalone)",
@@ -0,0 +1,130 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/erroring"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_VtdParser_Parse_ParseErrorPosition(t *testing.T) {
+	testCases := []struct {
+		name               string
+		doc                []byte
+		expectedMsg        string
+		expectedLine       int
+		expectedColumn     int
+		expectedOffset     int
+		expectedState      State
+		expectedSnippet    string
+		expectedLineNumber string
+	}{
+		{
+			name:               "unquoted attribute value",
+			doc:                []byte("<root>\n  <a>1</a>\n  <b x=1/>\n</root>"),
+			expectedMsg:        "invalid character should be ' or \" ",
+			expectedLine:       3,
+			expectedColumn:     9,
+			expectedOffset:     26,
+			expectedState:      StateAttrName,
+			expectedSnippet:    "  <b x=1/>",
+			expectedLineNumber: "\nLine number: 3 Offset: 9",
+		},
+		{
+			name:               "tag mismatch after CRLF",
+			doc:                []byte("<root>\r\n<a></b>\r\n</root>"),
+			expectedMsg:        "start/end tag mismatch",
+			expectedLine:       2,
+			expectedColumn:     7,
+			expectedOffset:     14,
+			expectedState:      StateTagEnd,
+			expectedSnippet:    "<a></b>",
+			expectedLineNumber: "\nLine number: 2 Offset: 7",
+		},
+		{
+			name:               "UTF-16LE tag mismatch",
+			doc:                encodeUtf16("<root>\n<a></b>\n</root>", false, true),
+			expectedMsg:        "start/end tag mismatch",
+			expectedLine:       2,
+			expectedColumn:     7,
+			expectedOffset:     28,
+			expectedState:      StateTagEnd,
+			expectedSnippet:    "<a></b>",
+			expectedLineNumber: "\nLine number: 2 Offset: 7",
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			parser, err := NewVtdParser(WithXmlDoc(tc.doc))
+			assert.Nil(t, err)
+
+			err = parser.Parse()
+			var pErr *erroring.ParseError
+			if assert.True(t, errors.As(err, &pErr)) {
+				assert.Equal(t, tc.expectedMsg, pErr.Msg)
+				assert.Equal(t, tc.expectedLine, pErr.Line)
+				assert.Equal(t, tc.expectedColumn, pErr.Column)
+				assert.Equal(t, tc.expectedOffset, pErr.Offset)
+				assert.Equal(t, tc.expectedState, pErr.State)
+				assert.Equal(t, tc.expectedSnippet, pErr.Snippet)
+				assert.Equal(t, tc.expectedLineNumber, pErr.LineNumber)
+			}
+		})
+	}
+}
+
+func Test_VtdParser_ParseContext_CancelledErrorPosition(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	parser, err := NewVtdParser(WithXmlDoc([]byte("<root/>")))
+	assert.Nil(t, err)
+
+	err = parser.ParseContext(ctx)
+	var pErr *erroring.ParseError
+	if assert.True(t, errors.As(err, &pErr)) {
+		assert.Equal(t, 1, pErr.Line)
+		assert.Equal(t, 1, pErr.Column)
+		assert.Equal(t, StateDocStart, pErr.State)
+	}
+}
+
+func Test_VtdNav_GetTokenPosition(t *testing.T) {
+	testCases := []struct {
+		name     string
+		doc      []byte
+		expected [][2]int
+	}{
+		{
+			name:     "UTF-8",
+			doc:      []byte("<root>\n  <e a='1'>x</e>\r\n<b/></root>"),
+			expected: [][2]int{{1, 1}, {1, 2}, {2, 4}, {2, 6}, {2, 9}, {2, 12}, {3, 2}},
+		},
+		{
+			name:     "UTF-16BE with surrogate pair",
+			doc:      encodeUtf16("<root>\n  <é a='😀'>x</é>\r\n<b/></root>", true, true),
+			expected: [][2]int{{1, 1}, {1, 2}, {2, 4}, {2, 6}, {2, 9}, {2, 12}, {3, 2}},
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			parser, err := NewVtdParser(WithXmlDoc(tc.doc))
+			assert.Nil(t, err)
+			assert.Nil(t, parser.Parse())
+			nav, err := parser.GetNav()
+			assert.Nil(t, err)
+
+			assert.Equal(t, len(tc.expected), nav.GetVtdBufferSize())
+			for i, expected := range tc.expected {
+				line, column, err := nav.GetTokenPosition(i)
+				assert.Nil(t, err)
+				assert.Equal(t, expected, [2]int{line, column}, "token %d", i)
+			}
+			_, _, err = nav.GetTokenPosition(len(tc.expected))
+			assert.NotNil(t, err)
+		})
+	}
+}
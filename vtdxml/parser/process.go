@@ -14,13 +14,11 @@ func (p *VtdParser) validateSeq(seq string) error {
 			return err
 		}
 		if c != uint32(seqChar) {
-			return erroring.NewParseError(fmt.Sprintf("invalid char sequence in %s", seq),
-				p.fmtLine(), nil)
+			return p.newParseError(fmt.Sprintf("invalid char sequence in %s", seq))
 		}
 	}
 	if p.depth < 0 {
-		return erroring.NewParseError(fmt.Sprintf("wrong place for %s", seq),
-			p.fmtLine(), nil)
+		return p.newParseError(fmt.Sprintf("wrong place for %s", seq))
 	}
 	return nil
 }
@@ -35,13 +33,9 @@ func (p *VtdParser) getNextProcessStateFromChar(ch uint32) (State, error) {
 		return StateLtSeen, nil
 	}
 	if ch == '&' {
-		ch, err := p.entityIdentifier()
-		if err != nil {
+		if err := p.entityIdentifier(false); err != nil {
 			return StateInvalid, err
 		}
-		if !p.xmlChar.IsValidChar(ch) {
-			return StateInvalid, erroring.NewParseError(erroring.InvalidChar, p.fmtLine(), nil)
-		}
 		return StateText, nil
 	}
 	if ch == ']' {
@@ -49,12 +43,12 @@ func (p *VtdParser) getNextProcessStateFromChar(ch uint32) (State, error) {
 		for p.skipChar(']') {
 		}
 		if p.skipChar('>') {
-			return StateInvalid, erroring.NewParseError("]]> sequence in text content", p.fmtLine(), nil)
+			return StateInvalid, p.newParseError("]]> sequence in text content")
 		}
 		return StateText, nil
 	}
 	if p.xmlChar.IsContentChar(ch) {
 		return StateText, nil
 	}
-	return StateInvalid, erroring.NewParseError(erroring.InvalidChar, p.fmtLine(), nil)
+	return StateInvalid, p.newParseError(erroring.InvalidChar)
 }
@@ -38,14 +38,19 @@ func (p *VtdParser) processAttrName() (State, error) {
 		return StateInvalid, err
 	}
 	p.length1 = offset - p.lastOffset
+	if p.nsAware {
+		if err := p.checkQName(p.lastOffset, p.length1); err != nil {
+			return StateInvalid, err
+		}
+	}
 
 	if p.isNs && p.nsAware && !p.defaultNs {
 		if (p.increment == 1 && (p.length1-p.length2 == 6)) ||
 			(p.increment == 2 && (p.length1-p.length2 == 12)) {
 			byteOffset := p.lastOffset + p.length2 + p.increment
 			if p.checkXmlnsPrefix(byteOffset, -1, false) {
-				return StateInvalid, erroring.NewParseError("XMLNS as namespace cannot be re-declared",
-					p.fmtCustomLine(byteOffset), nil)
+				return StateInvalid, p.newParseErrorAt(byteOffset,
+					fmt.Sprintf("%s: xmlns cannot be declared", erroring.NsPrefixReserved))
 			}
 		}
 		if (p.increment == 1 && (p.length1-p.length2 == 4)) ||
@@ -54,16 +59,22 @@ func (p *VtdParser) processAttrName() (State, error) {
 			p.isXml = p.checkXmlPrefix(byteOffset, -1, false)
 		}
 	}
+	if err := p.checkAttributeLimit(); err != nil {
+		return StateInvalid, err
+	}
 	if err := p.checkAttrUniqueness(); err != nil {
 		return StateInvalid, err
 	}
+	p.checkSpaceAttrName()
 
 	tokenType := common.TokenAttrName
 	errMsg := erroring.AttrNamePrefixQnameTooLong
 	if p.isNs {
 		tokenType = common.TokenAttrNs
 		errMsg = erroring.AttrNsPrefixQnameTooLong
-		if p.nsAware && p.length2 != 0 && !p.isXml {
+		// the xml prefix is bound without declaration, other prefixes and
+		// the default namespace are in scope until the element ends
+		if p.nsAware && !p.isXml {
 			val := int64((p.length2<<16|p.length1)<<32 | p.lastOffset)
 			if err := p.nsBuffer3.Append(val); err != nil {
 				return StateInvalid, err
@@ -73,14 +84,14 @@ func (p *VtdParser) processAttrName() (State, error) {
 
 	if p.singleByteEncoding {
 		if p.length2 > maxPrefixLength || p.length1 > maxQnameLength {
-			return StateInvalid, erroring.NewParseError(errMsg, p.fmtLine(), nil)
+			return StateInvalid, p.newParseError(errMsg)
 		}
 		if err := p.writeVtd(tokenType, p.lastOffset, (p.length2<<11)|p.length1, p.depth); err != nil {
 			return StateInvalid, err
 		}
 	} else {
 		if p.length2 > maxPrefixLength<<1 || p.length1 > maxQnameLength<<1 {
-			return StateInvalid, erroring.NewParseError(errMsg, p.fmtLine(), nil)
+			return StateInvalid, p.newParseError(errMsg)
 		}
 		if err := p.writeVtd(tokenType, p.lastOffset>>1, (p.length2<<10)|(p.length1>>1), p.depth); err != nil {
 			return StateInvalid, err
@@ -94,13 +105,13 @@ func (p *VtdParser) processAttrName() (State, error) {
 		}
 	}
 	if p.currentChar != '=' {
-		return StateInvalid, erroring.NewParseError(erroring.InvalidChar, p.fmtLine(), nil)
+		return StateInvalid, p.newParseError(erroring.InvalidChar)
 	}
 	if err := p.nextCharAfterWs(); err != nil {
 		return StateInvalid, err
 	}
 	if p.currentChar != '"' && p.currentChar != '\'' {
-		return StateInvalid, erroring.NewParseError(fmt.Sprintf("%s should be ' or \" ", erroring.InvalidChar), p.fmtLine(), nil)
+		return StateInvalid, p.newParseError(fmt.Sprintf("%s should be ' or \" ", erroring.InvalidChar))
 	}
 	p.lastOffset = p.offset
 	return StateAttrVal, nil
@@ -111,9 +122,8 @@ func (p *VtdParser) checkAttrUniqueness() error {
 	unique = true
 	for i := 0; i < p.attrCount; i++ {
 		uniqual = false
-		prevLen := int(int32(p.attrNameSlice[i]))
+		prevOffset, prevLen := unpackName(p.attrNameSlice[i])
 		if p.length1 == prevLen {
-			prevOffset := int(int32(p.attrNameSlice[i] >> 32))
 			for j := 0; j < prevLen; j++ {
 				if p.xmlDoc[prevOffset+j] != p.xmlDoc[p.lastOffset+j] {
 					uniqual = true
@@ -127,16 +137,23 @@ func (p *VtdParser) checkAttrUniqueness() error {
 	}
 	// TODO possibly simplify to if !unique {}
 	if !unique && p.attrCount != 0 {
-		return erroring.NewParseError(erroring.AttrNotUnique, p.fmtLine(), nil)
+		return p.newParseError(erroring.AttrNotUnique)
+	}
+	if p.attrCount == len(p.attrNameSlice) {
+		p.attrNameSlice = append(p.attrNameSlice, 0)
 	}
-	p.attrNameSlice[p.attrCount] = (p.lastOffset << 32) | p.length1
+	p.attrNameSlice[p.attrCount] = packName(p.lastOffset, p.length1)
 	p.attrCount++
 	if p.nsAware && !p.isNs && p.length2 != 0 {
-		isXml := p.checkXmlPrefix(p.lastOffset, -1, false)
-		if (p.increment == 1 && p.length2 == 3 && isXml) || (p.increment == 2 && p.length2 == 6 && isXml) {
-			return nil
+		if p.prefixedAttCount == len(p.prefixedAttrNameSlice) {
+			p.prefixedAttrNameSlice = append(p.prefixedAttrNameSlice, 0)
+		}
+		if p.prefixedAttCount == len(p.prefixedAttrIndexSlice) {
+			p.prefixedAttrIndexSlice = append(p.prefixedAttrIndexSlice, 0)
 		}
 		p.prefixedAttrNameSlice[p.prefixedAttCount] = int64((p.lastOffset << 32) | (p.length2 << 16) | p.length1)
+		// attribute name is the next VTD record
+		p.prefixedAttrIndexSlice[p.prefixedAttCount] = p.vtdBuffer.GetSize()
 		p.prefixedAttCount++
 	}
 	return nil
@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"bytes"
 	"fmt"
 
 	"github.com/alexZaicev/go-vtd-xml/vtdxml/common"
@@ -35,52 +36,23 @@ func (p *VtdParser) processAttrVal() (State, error) {
 				break
 			}
 			if p.currentChar == '&' {
-				if ch, err := p.entityIdentifier(); err != nil {
+				if err := p.entityIdentifier(true); err != nil {
 					return StateInvalid, err
-				} else if !p.xmlChar.IsValidChar(ch) {
-					return StateInvalid, erroring.NewParseError(erroring.InvalidChar, p.fmtLine(), nil)
 				}
 			}
 		} else {
-			return StateInvalid, erroring.NewParseError(erroring.InvalidChar, p.fmtLine(), nil)
+			return StateInvalid, p.newParseError(erroring.InvalidChar)
 		}
 	}
 	p.length1 = p.offset - p.lastOffset - p.increment
+	if err := p.checkAttrValueLengthLimit(p.length1); err != nil {
+		return StateInvalid, err
+	}
+	p.checkSpaceAttrVal()
 	if p.nsAware && p.isNs {
-		if !p.defaultNs && p.length1 == 0 {
-			return StateInvalid, erroring.NewParseError(erroring.NonDefaultNsEmpty, p.fmtLine(), nil)
-		}
-		nsUrlType, err := p.identifyNsUrl()
-		if err != nil {
+		if err := p.checkNsDeclaration(); err != nil {
 			return StateInvalid, err
 		}
-		if p.isXml && nsUrlType != NsUrl2000 {
-			return StateInvalid, erroring.NewParseError(
-				fmt.Sprintf("xmlns:xml cant only point to %s", XMLNS1998),
-				p.fmtLine(),
-				nil,
-			)
-		} else {
-			if !p.defaultNs {
-				if err := p.nsBuffer2.Append(int64(p.lastOffset<<32 | p.length1)); err != nil {
-					return StateInvalid, err
-				}
-			}
-			if nsUrlType != DefaultNsUrl {
-				if nsUrlType == NsUrl1998 {
-					return StateInvalid, erroring.NewParseError(
-						fmt.Sprintf("namespace declation cannot point to %s", XMLNS1998),
-						p.fmtLine(),
-						nil,
-					)
-				}
-				return StateInvalid, erroring.NewParseError(
-					fmt.Sprintf("namespace declation cannot point to %s", XMLNS2000),
-					p.fmtLine(),
-					nil,
-				)
-			}
-		}
 	}
 	if err := p.writeVtdWithLengthCheck(common.TokenAttrVal, erroring.AttrValueTooLong); err != nil {
 		return StateInvalid, err
@@ -94,7 +66,11 @@ func (p *VtdParser) processAttrVal() (State, error) {
 			return StateInvalid, err
 		}
 		if p.xmlChar.IsNameStartChar(p.currentChar) {
-			p.lastOffset = p.offset - p.increment
+			offset, err := p.getPrevOffset()
+			if err != nil {
+				return StateInvalid, err
+			}
+			p.lastOffset = offset
 			return StateAttrName, nil
 		}
 	}
@@ -109,30 +85,14 @@ func (p *VtdParser) processAttrVal() (State, error) {
 	}
 	if p.currentChar == '>' {
 		if p.nsAware {
-			if err := p.nsBuffer1.Append(int32(p.nsBuffer3.GetSize() - 1)); err != nil {
+			if err := p.qualifyNames(); err != nil {
 				return StateInvalid, err
 			}
-			if p.prefixedAttCount > 0 {
-				if err := p.qualifyAttributes(); err != nil {
-					return StateInvalid, err
-				}
-			}
-			if p.prefixedAttCount > 0 {
-				if err := p.checkQualifiedAttributeUniqueness(); err != nil {
-					return StateInvalid, err
-				}
-			}
-			if p.currentElementRecord != 0 {
-				if err := p.qualifyElement(); err != nil {
-					return StateInvalid, err
-				}
-			}
-			p.prefixedAttCount = 0
 		}
 		p.attrCount = 0
 		return p.processElementTail()
 	}
-	return StateInvalid, erroring.NewParseError(erroring.InvalidChar, p.fmtLine(), nil)
+	return StateInvalid, p.newParseError(erroring.InvalidChar)
 }
 
 func (p *VtdParser) identifyNsUrl() (NsUrlType, error) {
@@ -140,143 +100,111 @@ func (p *VtdParser) identifyNsUrl() (NsUrlType, error) {
 	//  create a struct that would bring meaningful explanation about the functionality
 	// lastOffset, length1
 	g := p.lastOffset + p.length1
-	offset, tmpOffset := p.lastOffset, 0
-	if p.length1 < 29 || (p.increment == 2 && p.length1 < 58) {
+	if p.length1 < 29*p.increment {
 		return DefaultNsUrl, nil
 	}
-
-	for i := 0; i < 18 && offset < g; i++ {
-		ch, err := p.getCharResolved(offset)
-		if err != nil {
-			return InvalidNsUrl, err
-		}
-		if int64(URL2[i]) != ch {
-			return DefaultNsUrl, nil
+	// matchSeq returns offset past the sequence if the value continues
+	// with it at the offset given
+	matchSeq := func(offset int, seq string) (int, bool, error) {
+		for i := 0; i < len(seq); i++ {
+			if offset >= g {
+				return offset, false, nil
+			}
+			ch, err := p.getCharResolved(offset)
+			if err != nil {
+				return offset, false, err
+			}
+			if int32(seq[i]) != int32(ch) {
+				return offset, false, nil
+			}
+			offset += int(ch >> 32)
 		}
-		offset += int(ch) >> 32
+		return offset, true, nil
 	}
-	tmpOffset = offset
-	for i := 0; i < 11 && offset < g; i++ {
-		ch, err := p.getCharResolved(offset)
-		if err != nil {
-			return InvalidNsUrl, err
-		}
-		if int64(URL1[i]) != ch {
-			return DefaultNsUrl, nil
-		}
-		offset += int(ch) >> 32
+
+	offset, match, err := matchSeq(p.lastOffset, URL2[:18])
+	if err != nil {
+		return InvalidNsUrl, err
 	}
-	if offset == g {
-		return NsUrl1998, nil
+	if !match {
+		return DefaultNsUrl, nil
 	}
-	offset = tmpOffset
-	for i := 18; i < 36 && offset < g; i++ {
-		ch, err := p.getCharResolved(offset)
+	for _, url := range []struct {
+		seq     string
+		urlType NsUrlType
+	}{{URL1, NsUrl2000}, {URL2[18:], NsUrl1998}} {
+		end, match, err := matchSeq(offset, url.seq)
 		if err != nil {
 			return InvalidNsUrl, err
 		}
-		if int64(URL2[i]) != ch {
-			return DefaultNsUrl, nil
+		if match && end == g {
+			return url.urlType, nil
 		}
-		offset += int(ch) >> 32
-	}
-	if offset == g {
-		return NsUrl2000, nil
 	}
 	return DefaultNsUrl, nil
 }
 
+// qualifyAttributes function resolves prefixes of the prefixed attributes
+// of the current element into indexes of the namespace declarations in scope
 func (p *VtdParser) qualifyAttributes() error {
-	nsBuffer3Count := p.nsBuffer3.GetSize() - 1
 	for j := 0; j < p.prefixedAttCount; j++ {
-		preLen := int(int32((p.prefixedAttrNameSlice[j] & 0xFFFF0000) >> 16))
-		preOs := int(int32(p.prefixedAttrNameSlice[j] >> 32))
+		preLen := int(p.prefixedAttrNameSlice[j]>>16) & 0xFFFF
+		preOs := int(p.prefixedAttrNameSlice[j] >> 32)
 
-		i := nsBuffer3Count
-		for ; i >= 0; i-- {
-			upper, err := p.nsBuffer3.Upper32At(i)
-			if err != nil {
+		i := xmlNs
+		if !p.checkXmlPrefix(preOs, preLen+p.increment, true) {
+			var err error
+			if i, err = p.resolvePrefix(preOs, preLen); err != nil {
 				return err
 			}
-			if (int(upper)&0xFFFF)-(int(upper)>>16) == preLen+p.increment {
-				// doing byte comparison here
-				lower, err := p.nsBuffer3.Lower32At(i)
-				if err != nil {
-					return err
-				}
-				offset := int(lower) + (int(upper) >> 16) + p.increment
-				var k int
-				for ; k < preLen; k++ {
-					if p.xmlDoc[offset+k] != p.xmlDoc[preOs+k] {
-						break
-					}
-				}
-				if k == preLen {
-					// match found break
-					break
-				}
+			if i == unboundNs {
+				return p.newParseErrorAt(preOs,
+					fmt.Sprintf("%s: %s", erroring.NsPrefixNotDeclared, p.nameString(preOs, preLen)))
 			}
 		}
-		if i < 0 {
-			return erroring.NewParseError("prefixed attribute not qualified", p.fmtLine(), nil)
-		} else {
-			p.prefixUrlSlice = append(p.prefixUrlSlice, i)
+		if j == len(p.prefixUrlSlice) {
+			p.prefixUrlSlice = append(p.prefixUrlSlice, 0)
 		}
+		p.prefixUrlSlice[j] = i
 	}
 	return nil
 }
 
+// checkQualifiedAttributeUniqueness function checks no two prefixed
+// attributes of the current element have the same local name and prefixes
+// bound to the same namespace URL
 func (p *VtdParser) checkQualifiedAttributeUniqueness() error {
 	for i := 0; i < p.prefixedAttCount; i++ {
-		preLen := int(int32((p.prefixedAttrNameSlice[i] & 0xFFFF0000) >> 16))
-		postLen := int(int32(p.prefixedAttrNameSlice[i]&0xFFFF)) - preLen - p.increment
-
-		offset := int(int32(p.prefixedAttrNameSlice[i]>>32)) + preLen + p.increment
-
-		urlLen, err := p.nsBuffer2.Lower32At(p.prefixUrlSlice[i])
-		if err != nil {
-			return err
+		if p.prefixUrlSlice[i] == xmlNs {
+			// the same xml prefixed names are not unique attribute names
+			continue
 		}
-		urlOffset, err := p.nsBuffer2.Upper32At(p.prefixUrlSlice[i])
+		offset, _, postLen := p.localName(p.prefixedAttrNameSlice[i])
+		urlA, err := p.nsUrl(p.prefixUrlSlice[i])
 		if err != nil {
 			return err
 		}
-		urlA := Url{int(urlOffset), int(urlLen)}
 
 		for j := i + 1; j < p.prefixedAttCount; j++ {
-			preLen2 := int(int32((p.prefixedAttrNameSlice[j] & 0xFFFF0000) >> 16))
-			postLen2 := int(int32(p.prefixedAttrNameSlice[j]&0xFFFF)) - preLen2 - p.increment
-
-			offset2 := int(int32(p.prefixedAttrNameSlice[j]>>32)) + preLen2 + p.increment
-
-			if postLen == postLen2 {
-				var k int
-				for ; k < postLen; k++ {
-					if p.xmlDoc[offset+k] != p.xmlDoc[offset2+k] {
-						break
-					}
-				}
-				if k == postLen {
-					urlLen2, err := p.nsBuffer2.Lower32At(p.prefixUrlSlice[j])
-					if err != nil {
-						return err
-					}
-					urlOffset2, err := p.nsBuffer2.Upper32At(p.prefixUrlSlice[j])
-					if err != nil {
-						return err
-					}
-					urlB := Url{int(urlOffset2), int(urlLen2)}
-
-					match, err := p.matchUrl(urlA, urlB)
-					if err != nil {
-						return err
-					}
-					if match {
-						return erroring.NewParseError("qualified attribute names collide",
-							p.fmtCustomLine(offset2),
-							nil)
-					}
-				}
+			if p.prefixUrlSlice[j] == xmlNs {
+				continue
+			}
+			offset2, preLen2, postLen2 := p.localName(p.prefixedAttrNameSlice[j])
+			if postLen != postLen2 || !bytes.Equal(p.xmlDoc[offset:offset+postLen], p.xmlDoc[offset2:offset2+postLen2]) {
+				continue
+			}
+			urlB, err := p.nsUrl(p.prefixUrlSlice[j])
+			if err != nil {
+				return err
+			}
+			match, err := p.matchUrl(urlA, urlB)
+			if err != nil {
+				return err
+			}
+			if match {
+				nameOffset := offset2 - preLen2 - p.increment
+				return p.newParseErrorAt(nameOffset, fmt.Sprintf("%s: %s", erroring.NsAttrNotUnique,
+					p.nameString(nameOffset, preLen2+p.increment+postLen2)))
 			}
 		}
 	}
@@ -301,7 +229,7 @@ func (p *VtdParser) getCharResolved(offset int) (int64, error) {
 
 	var val int32
 	inc := 2 << (p.increment - 1)
-	ch64, err := p.reader.GetLongCharAt(int32(offset))
+	ch64, err := p.reader.GetLongCharAt(offset)
 	if err != nil {
 		return 0, err
 	}
@@ -361,37 +289,37 @@ func (p *VtdParser) getCharResolved(offset int) (int64, error) {
 		}
 	case 'a':
 		{
-			ch2, err := p.getChar()
+			ch2, err := p.getCharUnit(offset)
 			if err != nil {
 				return 0, err
 			}
-			if p.encoding < common.FormatUtf16BE {
+			if !p.encoding.IsUtf16() {
 				if ch2 == 'm' {
 					// checks that the sequence matcher &amp;
-					if err := checkSeq("p;", offset, 1); err != nil {
+					if err := checkSeq("p;", offset+p.increment, 1); err != nil {
 						return 0, err
 					}
 					inc = 5
 					val = '&'
 				} else if ch2 == 'p' {
 					// checks that the sequence matcher &apos;
-					if err := checkSeq("os;", offset, 1); err != nil {
+					if err := checkSeq("os;", offset+p.increment, 1); err != nil {
 						return 0, err
 					}
-					inc = 5
+					inc = 6
 					val = '\''
 				}
 			} else {
 				if ch2 == 'm' {
 					// checks that the sequence matcher &amp;
-					if err := checkSeq("p;", offset, 2); err != nil {
+					if err := checkSeq("p;", offset+p.increment, 2); err != nil {
 						return 0, err
 					}
 					inc = 10
 					val = '&'
 				} else if ch2 == 'p' {
 					// checks that the sequence matcher &apos;
-					if err := checkSeq("os;", offset, 2); err != nil {
+					if err := checkSeq("os;", offset+p.increment, 2); err != nil {
 						return 0, err
 					}
 					inc = 12
@@ -402,7 +330,7 @@ func (p *VtdParser) getCharResolved(offset int) (int64, error) {
 		}
 	case 'q':
 		{
-			if p.encoding < common.FormatUtf16BE {
+			if !p.encoding.IsUtf16() {
 				if err := checkSeq("uot;", offset, 1); err != nil {
 					return 0, err
 				}
@@ -418,7 +346,7 @@ func (p *VtdParser) getCharResolved(offset int) (int64, error) {
 		}
 	case 'g', 'l':
 		{
-			if p.encoding < common.FormatUtf16BE {
+			if !p.encoding.IsUtf16() {
 				if err := checkSeq("t;", offset, 1); err != nil {
 					return 0, err
 				}
@@ -441,24 +369,47 @@ func (p *VtdParser) getCharResolved(offset int) (int64, error) {
 }
 
 func (p *VtdParser) getCharUnit(offset int) (int32, error) {
-	if p.encoding <= 2 {
+	switch p.encoding {
+	case common.FormatAscii, common.FormatIso88591, common.FormatUtf8:
 		return int32(p.xmlDoc[offset] & 0xff), nil
-	} else if p.encoding < common.FormatUtf16BE {
-		ch, err := p.reader.GetCharAt(int32(offset))
+	case common.FormatUtf16BE:
+		return int32(p.xmlDoc[offset])<<8 | int32(p.xmlDoc[offset+1]), nil
+	case common.FormatUtf16LE:
+		return int32(p.xmlDoc[offset+1])<<8 | int32(p.xmlDoc[offset]), nil
+	default:
+		ch, err := p.reader.GetCharAt(offset)
 		if err != nil {
 			return 0, err
 		}
 		return int32(ch), nil
-	} else if p.encoding == common.FormatUtf16BE {
-		return int32(p.xmlDoc[offset])<<8 | int32(p.xmlDoc[offset+1]), nil
-	} else {
-		return int32(p.xmlDoc[offset+1])<<8 | int32(p.xmlDoc[offset]), nil
 	}
 }
 
+// localName function returns offset of the local name of the prefixed
+// attribute name entry, length of its prefix and of the local name
+func (p *VtdParser) localName(entry int64) (int, int, int) {
+	preLen := int(entry>>16) & 0xFFFF
+	return int(entry>>32) + preLen + p.increment, preLen, int(entry&0xFFFF) - preLen - p.increment
+}
+
+// nsUrl function returns namespace URL of the namespace declaration given
+func (p *VtdParser) nsUrl(ns int) (Url, error) {
+	urlOffset, err := p.nsBuffer2.Upper32At(ns)
+	if err != nil {
+		return Url{}, err
+	}
+	urlLen, err := p.nsBuffer2.Lower32At(ns)
+	if err != nil {
+		return Url{}, err
+	}
+	return Url{int(urlOffset), int(urlLen)}, nil
+}
+
+// matchUrl function returns true if both URLs are the same once entity and
+// character references are resolved
 func (p *VtdParser) matchUrl(a, b Url) (bool, error) {
-	for a.offset < a.offset+a.length &&
-		b.offset < b.offset+b.length {
+	aEnd, bEnd := a.offset+a.length, b.offset+b.length
+	for a.offset < aEnd && b.offset < bEnd {
 		chA, err := p.getCharResolved(a.offset)
 		if err != nil {
 			return false, err
@@ -467,15 +418,11 @@ func (p *VtdParser) matchUrl(a, b Url) (bool, error) {
 		if err != nil {
 			return false, err
 		}
-		if chA != chB {
-			return false, err
+		if int32(chA) != int32(chB) {
+			return false, nil
 		}
 		a.offset += int(chA >> 32)
 		b.offset += int(chB >> 32)
 	}
-	if a.offset == a.offset+a.length &&
-		b.offset == b.offset+b.length {
-		return true, nil
-	}
-	return false, nil
+	return a.offset == aEnd && b.offset == bEnd, nil
 }
@@ -11,7 +11,7 @@ func (p *VtdParser) processCdata() (State, error) {
 			return StateInvalid, err
 		}
 		if !p.xmlChar.IsValidChar(p.currentChar) {
-			return StateInvalid, erroring.NewParseError(erroring.InvalidChar, p.fmtLine(), nil)
+			return StateInvalid, p.newParseError(erroring.InvalidChar)
 		}
 		if p.currentChar == ']' {
 			// skip all ] chars
@@ -1,11 +1,27 @@
 package parser
 
-import "github.com/alexZaicev/go-vtd-xml/vtdxml/erroring"
+import (
+	"errors"
+
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/erroring"
+)
 
 func (p *VtdParser) processDocEnd() (State, error) {
+	start := p.offset
 	if err := p.nextCharAfterWs(); err != nil {
 		return StateInvalid, err
 	}
+	if p.stream && p.currentChar == '<' && p.endAtNextDocument() {
+		return StateInvalid, erroring.NewEOFError("document ends where the next one starts")
+	}
+	if p.fragment {
+		state, err := p.processFragmentItem(start)
+		if errors.As(err, &erroring.EOFErrorType) {
+			// document ends inside the top-level item
+			return StateInvalid, p.newParseError(erroring.XmlIncomplete)
+		}
+		return state, err
+	}
 	if p.currentChar == '<' {
 		if p.skipChar('?') {
 			p.lastOffset = p.offset
@@ -15,5 +31,5 @@ func (p *VtdParser) processDocEnd() (State, error) {
 			return StateEndComment, nil
 		}
 	}
-	return StateInvalid, erroring.NewParseError("XML not terminated properly", p.fmtLine(), nil)
+	return StateInvalid, p.newParseError("XML not terminated properly")
 }
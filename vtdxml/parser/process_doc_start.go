@@ -1,8 +1,7 @@
 package parser
 
-import "github.com/alexZaicev/go-vtd-xml/vtdxml/erroring"
-
 func (p *VtdParser) processDocStart() (State, error) {
+	start := p.offset
 	if err := p.nextChar(); err != nil {
 		return StateInvalid, err
 	}
@@ -22,7 +21,7 @@ func (p *VtdParser) processDocStart() (State, error) {
 				p.lastOffset = p.offset
 				return StateDecAttrName, nil
 			} else if p.skipChar('?') {
-				return StateInvalid, erroring.NewParseError("premature ending", p.fmtLine(), nil)
+				return StateInvalid, p.newParseError("premature ending")
 			}
 		}
 		p.offset = p.lastOffset
@@ -35,5 +34,10 @@ func (p *VtdParser) processDocStart() (State, error) {
 			return StateLtSeen, nil
 		}
 	}
+	if p.fragment {
+		// fragment starting with text
+		p.lastOffset = start
+		return p.processFragmentText()
+	}
 	return StateInvalid, nil
 }
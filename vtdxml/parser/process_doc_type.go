@@ -1,40 +1,590 @@
 package parser
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+
 	"github.com/alexZaicev/go-vtd-xml/vtdxml/common"
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/dtd"
 	"github.com/alexZaicev/go-vtd-xml/vtdxml/erroring"
 )
 
+// processDocType function tokenizes the document type declaration. The whole
+// declaration is recorded as a single DTD value token, while the document
+// element name, external identifier and internal subset declarations are
+// collected into the parser DTD.
 func (p *VtdParser) processDocType() (State, error) {
-	z := 1
-	for {
-		ch, err := p.getChar()
-		if err != nil {
+	defer p.decodeUtf8()()
+	if err := p.nextChar(); err != nil {
+		return StateInvalid, err
+	}
+	if err := p.requireDtdWs(); err != nil {
+		return StateInvalid, err
+	}
+	name, err := p.readDtdName()
+	if err != nil {
+		return StateInvalid, err
+	}
+	d := dtd.New(name)
+	if err := p.skipDtdWs(); err != nil {
+		return StateInvalid, err
+	}
+	if p.currentChar == 'S' || p.currentChar == 'P' {
+		if d.PublicID, d.SystemID, err = p.readExternalID(); err != nil {
 			return StateInvalid, err
 		}
-		if !p.xmlChar.IsValidChar(ch) {
-			return StateInvalid, erroring.NewParseError("invalid char in DOCTYPE", p.fmtLine(), nil)
+		if err := p.skipDtdWs(); err != nil {
+			return StateInvalid, err
 		}
-		if ch == '>' {
-			z--
-		} else if ch == '<' {
-			z++
+	}
+	if p.currentChar == '[' {
+		if err := p.processSubset(d, internalSubset); err != nil {
+			return StateInvalid, err
 		}
-
-		if z == 0 {
-			break
+		if err := p.nextCharAfterWs(); err != nil {
+			return StateInvalid, err
 		}
 	}
+	if p.currentChar != '>' {
+		return StateInvalid, p.newParseError("invalid char in DOCTYPE")
+	}
+	p.dtd = d
 
 	p.length1 = p.offset - p.lastOffset - p.increment
 	if err := p.writeVtdWithLengthCheck(common.TokenDtdVal, "DTD value too long >0xFFFFF"); err != nil {
 		return StateInvalid, err
 	}
+	if p.fragment {
+		return StateDocEnd, nil
+	}
 	if err := p.nextCharAfterWs(); err != nil {
 		return StateInvalid, err
 	}
 	if p.currentChar != '<' {
-		return StateInvalid, erroring.NewParseError(erroring.InvalidChar, p.fmtLine(), nil)
+		return StateInvalid, p.newParseError(erroring.InvalidChar)
 	}
 	return StateLtSeen, nil
 }
+
+// processSubset function tokenizes markup declarations of a DTD subset.
+// Internal subset ends at ], current character is ] on return. External
+// subset ends with the document, while conditional section ends at ]]> and
+// current character is > on return. Comments and processing instructions,
+// including the text declaration, are skipped. Parameter entity references
+// between declarations are skipped in the internal subset as their
+// replacement text is not parsed, in the external subset the replacement
+// text of internal parameter entities is parsed in place.
+func (p *VtdParser) processSubset(d *dtd.Dtd, mode subsetMode) error {
+	for {
+		if err := p.nextCharAfterWs(); err != nil {
+			if mode == externalSubset && errors.As(err, &erroring.EOFErrorType) {
+				return nil
+			}
+			return err
+		}
+		start := p.offset - p.increment
+		switch p.currentChar {
+		case ']':
+			switch mode {
+			case internalSubset:
+				return nil
+			case includeSection:
+				if !p.skipCharSeq("]>") {
+					return p.newParseError("conditional section must end with ]]>")
+				}
+				p.currentChar = '>'
+				return nil
+			}
+			return p.newParseError("invalid char in DTD")
+		case '%':
+			if err := p.nextChar(); err != nil {
+				return err
+			}
+			name, err := p.readDtdName()
+			if err != nil {
+				return err
+			}
+			if p.currentChar != ';' {
+				return p.newParseError("parameter entity reference must end with ;")
+			}
+			if p.externalSubset {
+				if err := p.includeParameterEntity(d, name, start); err != nil {
+					return err
+				}
+			}
+		case '<':
+			if err := p.nextChar(); err != nil {
+				return err
+			}
+			if p.currentChar == '?' {
+				if err := p.skipDtdSeq("?>"); err != nil {
+					return err
+				}
+				continue
+			}
+			if p.currentChar != '!' {
+				return p.newParseError("invalid markup declaration in DOCTYPE")
+			}
+			if p.skipChar('-') {
+				if !p.skipChar('-') {
+					return p.newParseError("invalid comment in DOCTYPE")
+				}
+				if err := p.skipDtdSeq("-->"); err != nil {
+					return err
+				}
+				continue
+			}
+			if p.skipChar('[') {
+				if !p.externalSubset {
+					return p.newParseError("conditional section in internal subset")
+				}
+				if err := p.processConditionalSection(d); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := p.processMarkupDeclaration(d, start); err != nil {
+				return err
+			}
+		default:
+			return p.newParseError("invalid char in DOCTYPE")
+		}
+	}
+}
+
+// processConditionalSection function processes INCLUDE or IGNORE section
+// following <![, the keyword may be given by a parameter entity reference.
+// Current character is > of the closing ]]> on return.
+func (p *VtdParser) processConditionalSection(d *dtd.Dtd) error {
+	if err := p.nextChar(); err != nil {
+		return err
+	}
+	if err := p.skipDtdWs(); err != nil {
+		return err
+	}
+	var keyword string
+	if p.currentChar == '%' {
+		e, err := p.readParameterEntityReference(d)
+		if err != nil {
+			return err
+		}
+		keyword = strings.TrimSpace(e.Value)
+		if err := p.nextChar(); err != nil {
+			return err
+		}
+	} else {
+		var err error
+		if keyword, err = p.readDtdName(); err != nil {
+			return err
+		}
+	}
+	if err := p.skipDtdWs(); err != nil {
+		return err
+	}
+	if p.currentChar != '[' {
+		return p.newParseError("invalid conditional section")
+	}
+	switch keyword {
+	case "INCLUDE":
+		return p.processSubset(d, includeSection)
+	case "IGNORE":
+		return p.skipIgnoreSection()
+	}
+	return p.newParseError(fmt.Sprintf("unknown conditional section %s", keyword))
+}
+
+// skipIgnoreSection function skips contents of IGNORE section, including
+// nested sections. Current character is > of the closing ]]> on return.
+func (p *VtdParser) skipIgnoreSection() error {
+	var prev, last uint32
+	for nested := 0; ; {
+		if err := p.nextChar(); err != nil {
+			return err
+		}
+		ch := p.currentChar
+		switch {
+		case !p.xmlChar.IsValidChar(ch):
+			return p.newParseError("invalid char in DTD")
+		case prev == '<' && last == '!' && ch == '[':
+			nested++
+			ch = 0
+		case prev == ']' && last == ']' && ch == '>':
+			if nested == 0 {
+				return nil
+			}
+			nested--
+			ch = 0
+		}
+		prev, last = last, ch
+	}
+}
+
+// includeParameterEntity function parses replacement text of the parameter
+// entity referenced between declarations of the external subset. As the text
+// is not part of the document, its declarations are located at the
+// reference, which started at the byte offset. References to external
+// parameter entities are skipped.
+func (p *VtdParser) includeParameterEntity(d *dtd.Dtd, name string, start int) error {
+	e, ok := d.GetParameterEntity(name)
+	if !ok {
+		return p.newParseError(fmt.Sprintf("undeclared parameter entity %s", name))
+	}
+	if e.IsExternal() || strings.TrimSpace(e.Value) == "" {
+		return nil
+	}
+	for _, s := range p.peStack {
+		if s == name {
+			return p.newParseError(fmt.Sprintf("recursive reference to parameter entity %s", name))
+		}
+	}
+
+	sub, err := NewVtdParser(WithXmlDoc([]byte(e.Value)))
+	if err != nil {
+		return err
+	}
+	sub.externalSubset = true
+	sub.peStack = append(append([]string(nil), p.peStack...), name)
+	if err := sub.decideEncoding(); err != nil {
+		return err
+	}
+	first := len(d.Declarations)
+	if err := sub.processSubset(d, externalSubset); err != nil {
+		return err
+	}
+	offset, length := p.unitRange(start)
+	for i := first; i < len(d.Declarations); i++ {
+		d.Declarations[i].Offset, d.Declarations[i].Length = offset, length
+	}
+	return nil
+}
+
+// readParameterEntityReference function reads parameter entity reference
+// starting at the current % character and returns the entity it refers to.
+// Current character is ; on return.
+func (p *VtdParser) readParameterEntityReference(d *dtd.Dtd) (*dtd.Entity, error) {
+	if err := p.nextChar(); err != nil {
+		return nil, err
+	}
+	name, err := p.readDtdName()
+	if err != nil {
+		return nil, err
+	}
+	if p.currentChar != ';' {
+		return nil, p.newParseError("parameter entity reference must end with ;")
+	}
+	e, ok := d.GetParameterEntity(name)
+	if !ok {
+		return nil, p.newParseError(fmt.Sprintf("undeclared parameter entity %s", name))
+	}
+	if e.IsExternal() {
+		return nil, p.newParseError(fmt.Sprintf("external parameter entity %s is not resolved", name))
+	}
+	return e, nil
+}
+
+// unitRange function returns offset and length, in the units of VTD token
+// offsets, of the text that started at the byte offset and ends with the
+// current character
+func (p *VtdParser) unitRange(start int) (int, int) {
+	offset, length := start, p.offset-start
+	if !p.singleByteEncoding {
+		offset, length = offset>>1, length>>1
+	}
+	return offset, length
+}
+
+// processMarkupDeclaration function tokenizes declaration following <! that
+// started at the byte offset. Current character is > on return.
+func (p *VtdParser) processMarkupDeclaration(d *dtd.Dtd, start int) error {
+	if err := p.nextChar(); err != nil {
+		return err
+	}
+	keyword, err := p.readDtdName()
+	if err != nil {
+		return err
+	}
+	decl := dtd.Declaration{}
+	switch keyword {
+	case "ELEMENT":
+		decl.Type = dtd.DeclarationElement
+	case "ATTLIST":
+		decl.Type = dtd.DeclarationAttlist
+	case "ENTITY":
+		decl.Type = dtd.DeclarationEntity
+	case "NOTATION":
+		decl.Type = dtd.DeclarationNotation
+	default:
+		return p.newParseError(fmt.Sprintf("unknown markup declaration %s", keyword))
+	}
+	if err := p.requireDtdWs(); err != nil {
+		return err
+	}
+
+	if decl.Type == dtd.DeclarationEntity {
+		e, err := p.readEntityDeclaration(d)
+		if err != nil {
+			return err
+		}
+		decl.Name = e.Name
+		d.AddEntity(e)
+	} else {
+		if decl.Name, err = p.readDtdName(); err != nil {
+			return err
+		}
+		if decl.Content, err = p.readDeclarationContent(d); err != nil {
+			return err
+		}
+	}
+
+	decl.Offset, decl.Length = p.unitRange(start)
+	d.AddDeclaration(decl)
+	return nil
+}
+
+// readEntityDeclaration function reads entity declaration following the
+// ENTITY keyword. Current character is > on return.
+func (p *VtdParser) readEntityDeclaration(d *dtd.Dtd) (*dtd.Entity, error) {
+	e := &dtd.Entity{}
+	if p.currentChar == '%' {
+		e.Parameter = true
+		if err := p.nextChar(); err != nil {
+			return nil, err
+		}
+		if err := p.requireDtdWs(); err != nil {
+			return nil, err
+		}
+	}
+	var err error
+	if e.Name, err = p.readDtdName(); err != nil {
+		return nil, err
+	}
+	if err := p.requireDtdWs(); err != nil {
+		return nil, err
+	}
+
+	if p.currentChar == '"' || p.currentChar == '\'' {
+		if e.Value, err = p.readEntityValue(d); err != nil {
+			return nil, err
+		}
+	} else {
+		if e.PublicID, e.SystemID, err = p.readExternalID(); err != nil {
+			return nil, err
+		}
+		if p.xmlChar.IsSpaceChar(p.currentChar) {
+			if err := p.skipDtdWs(); err != nil {
+				return nil, err
+			}
+			if !e.Parameter && p.currentChar == 'N' {
+				if !p.skipCharSeq("DATA") {
+					return nil, p.newParseError("invalid NDATA declaration")
+				}
+				if err := p.nextChar(); err != nil {
+					return nil, err
+				}
+				if err := p.requireDtdWs(); err != nil {
+					return nil, err
+				}
+				if e.Notation, err = p.readDtdName(); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	if err := p.skipDtdWs(); err != nil {
+		return nil, err
+	}
+	if p.currentChar != '>' {
+		return nil, p.newParseError("invalid char in entity declaration")
+	}
+	return e, nil
+}
+
+// readEntityValue function reads quoted literal value of an entity starting
+// at the current quote character. Character references are replaced while
+// general entity references are kept, the character following the closing
+// quote becomes current. Parameter entity references, allowed in the
+// external subset only, are replaced with the entity value.
+func (p *VtdParser) readEntityValue(d *dtd.Dtd) (string, error) {
+	quote := p.currentChar
+	var sb strings.Builder
+	for {
+		ch, err := p.getChar()
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case ch == quote:
+			return sb.String(), p.nextChar()
+		case ch == '%':
+			if !p.externalSubset {
+				return "", p.newParseError("parameter entity reference in internal subset declaration")
+			}
+			p.currentChar = ch
+			e, err := p.readParameterEntityReference(d)
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(e.Value)
+		case ch == '&':
+			if p.skipChar('#') {
+				value, err := p.charReference()
+				if err != nil {
+					return "", err
+				}
+				sb.WriteRune(rune(value))
+				continue
+			}
+			if err := p.nextChar(); err != nil {
+				return "", err
+			}
+			name, err := p.readDtdName()
+			if err != nil {
+				return "", err
+			}
+			if p.currentChar != ';' {
+				return "", p.newParseError("entity reference must end with ;")
+			}
+			sb.WriteString("&" + name + ";")
+		case p.xmlChar.IsValidChar(ch):
+			sb.WriteRune(rune(ch))
+		default:
+			return "", p.newParseError("invalid char in entity value")
+		}
+	}
+}
+
+// readExternalID function reads SYSTEM or PUBLIC external identifier
+// starting at the current character. The character following the identifier
+// becomes current.
+func (p *VtdParser) readExternalID() (string, string, error) {
+	keyword, err := p.readDtdName()
+	if err != nil {
+		return "", "", err
+	}
+	var publicID string
+	switch keyword {
+	case "PUBLIC":
+		if err := p.requireDtdWs(); err != nil {
+			return "", "", err
+		}
+		if publicID, err = p.readDtdLiteral(); err != nil {
+			return "", "", err
+		}
+	case "SYSTEM":
+	default:
+		return "", "", p.newParseError("SYSTEM or PUBLIC identifier expected")
+	}
+	if err := p.requireDtdWs(); err != nil {
+		return "", "", err
+	}
+	systemID, err := p.readDtdLiteral()
+	if err != nil {
+		return "", "", err
+	}
+	return publicID, systemID, nil
+}
+
+// readDeclarationContent function reads declaration text up to the closing >
+// that is not quoted. In the external subset, unquoted parameter entity
+// references are replaced with the entity value padded with spaces. Current
+// character is > on return.
+func (p *VtdParser) readDeclarationContent(d *dtd.Dtd) (string, error) {
+	var sb strings.Builder
+	var quote uint32
+	for quote != 0 || p.currentChar != '>' {
+		if !p.xmlChar.IsValidChar(p.currentChar) {
+			return "", p.newParseError("invalid char in DOCTYPE")
+		}
+		if quote == 0 && p.currentChar == '%' && p.externalSubset {
+			e, err := p.readParameterEntityReference(d)
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(" " + e.Value + " ")
+		} else {
+			if p.currentChar == quote {
+				quote = 0
+			} else if quote == 0 && (p.currentChar == '"' || p.currentChar == '\'') {
+				quote = p.currentChar
+			}
+			sb.WriteRune(rune(p.currentChar))
+		}
+		if err := p.nextChar(); err != nil {
+			return "", err
+		}
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
+
+// readDtdLiteral function reads quoted literal starting at the current quote
+// character. The character following the closing quote becomes current.
+func (p *VtdParser) readDtdLiteral() (string, error) {
+	quote := p.currentChar
+	if quote != '"' && quote != '\'' {
+		return "", p.newParseError("quoted literal expected in DOCTYPE")
+	}
+	var sb strings.Builder
+	for {
+		ch, err := p.getChar()
+		if err != nil {
+			return "", err
+		}
+		if ch == quote {
+			return sb.String(), p.nextChar()
+		}
+		if !p.xmlChar.IsValidChar(ch) {
+			return "", p.newParseError("invalid char in DOCTYPE")
+		}
+		sb.WriteRune(rune(ch))
+	}
+}
+
+// readDtdName function reads name starting at the current character. The
+// character following the name becomes current.
+func (p *VtdParser) readDtdName() (string, error) {
+	if !p.xmlChar.IsNameStartChar(p.currentChar) {
+		return "", p.newParseError("invalid name in DOCTYPE")
+	}
+	var sb strings.Builder
+	for p.xmlChar.IsNameChar(p.currentChar) {
+		sb.WriteRune(rune(p.currentChar))
+		if err := p.nextChar(); err != nil {
+			return "", err
+		}
+	}
+	return sb.String(), nil
+}
+
+// requireDtdWs function skips whitespace that must precede the next token
+func (p *VtdParser) requireDtdWs() error {
+	if !p.xmlChar.IsSpaceChar(p.currentChar) {
+		return p.newParseError("whitespace expected in DOCTYPE")
+	}
+	return p.skipDtdWs()
+}
+
+// skipDtdWs function makes the first non-whitespace character current
+func (p *VtdParser) skipDtdWs() error {
+	if p.xmlChar.IsSpaceChar(p.currentChar) {
+		return p.nextCharAfterWs()
+	}
+	return nil
+}
+
+// skipDtdSeq function skips characters until the sequence has been read.
+// Last character of the sequence becomes current.
+func (p *VtdParser) skipDtdSeq(seq string) error {
+	for {
+		if err := p.nextChar(); err != nil {
+			return err
+		}
+		if !p.xmlChar.IsValidChar(p.currentChar) {
+			return p.newParseError("invalid char in DOCTYPE")
+		}
+		if p.currentChar == uint32(seq[0]) && p.skipCharSeq(seq[1:]) {
+			p.currentChar = uint32(seq[len(seq)-1])
+			return nil
+		}
+	}
+}
@@ -3,13 +3,22 @@ package parser
 import "github.com/alexZaicev/go-vtd-xml/vtdxml/common"
 
 func (p *VtdParser) processElementTail() (State, error) {
+	if !p.helper {
+		// empty element tag, depth is already decremented
+		offset, length := unpackName(p.tagStack[p.depth+1])
+		if err := p.notifyEndElement(offset, length, p.depth+1); err != nil {
+			return StateInvalid, err
+		}
+	}
 	if p.depth != -1 {
 		p.lastOffset = p.offset
 		if err := p.nextCharAfterWs(); err != nil {
 			return StateInvalid, err
 		}
 		if p.currentChar == '<' {
-			if p.ws {
+			// whitespace followed by the end tag of the element just started
+			// is its text content and is written below
+			if p.ws && !p.helper {
 				if err := p.recordWhiteSpace(); err != nil {
 					return StateInvalid, err
 				}
@@ -30,6 +39,11 @@ func (p *VtdParser) processElementTail() (State, error) {
 				}
 				return StateTagEnd, nil
 			}
+			if p.ws && p.helper {
+				if err := p.recordWhiteSpace(); err != nil {
+					return StateInvalid, err
+				}
+			}
 			return StateLtSeen, nil
 		} else if p.xmlChar.IsContentChar(p.currentChar) {
 			return StateText, nil
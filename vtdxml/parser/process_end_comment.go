@@ -11,15 +11,18 @@ func (p *VtdParser) processEndComment() (State, error) {
 			return StateInvalid, err
 		}
 		if !p.xmlChar.IsValidChar(p.currentChar) {
-			return StateInvalid, erroring.NewParseError(erroring.InvalidChar, p.fmtLine(), nil)
+			return StateInvalid, p.newParseError(erroring.InvalidChar)
 		}
 		if p.currentChar == '-' && p.skipChar('-') {
 			p.length1 = p.offset - p.lastOffset - (p.increment << 1)
 			break
 		}
 	}
+	if err := p.nextChar(); err != nil {
+		return StateInvalid, err
+	}
 	if p.currentChar != '>' {
-		return StateInvalid, erroring.NewParseError("invalid terminating sequence, --> expected", p.fmtLine(), nil)
+		return StateInvalid, p.newParseError("invalid terminating sequence, --> expected")
 	}
 	if p.singleByteEncoding {
 		if err := p.writeVtdText(common.TokenComment, p.lastOffset, p.length1, p.depth); err != nil {
@@ -3,9 +3,19 @@ package parser
 import "github.com/alexZaicev/go-vtd-xml/vtdxml/erroring"
 
 func (p *VtdParser) processEndTag() (State, error) {
+	if p.depth < 0 {
+		return StateInvalid, p.newParseError("end tag without start tag")
+	}
 	p.lastOffset = p.offset
-	sOffset := int(int32(p.tagStack[p.depth]))
-	sLength := int(int32(p.tagStack[p.depth] >> 32))
+	sOffset, sLength := unpackName(p.tagStack[p.depth])
+	if p.chunk != nil && p.tagStack[p.depth] == 0 {
+		// element started before the chunk, name is checked on joining
+		length, err := p.chunkEndTagName()
+		if err != nil {
+			return StateInvalid, err
+		}
+		sOffset, sLength = p.lastOffset, length
+	}
 
 	p.setOffset(p.lastOffset + sLength)
 	if p.offset >= p.endOffset {
@@ -14,15 +24,29 @@ func (p *VtdParser) processEndTag() (State, error) {
 
 	for i := 0; i < sLength; i++ {
 		if p.xmlDoc[sOffset+i] != p.xmlDoc[p.lastOffset+i] {
-			return StateInvalid, erroring.NewParseError("start/end tag mismatch", p.fmtLine(), nil)
+			if p.maxErrors > 0 {
+				// end tag of an ancestor auto-closes the elements opened
+				// after it
+				closed, err := p.closeToAncestor(p.lastOffset)
+				if err != nil {
+					return StateInvalid, err
+				}
+				if closed {
+					return p.processEndTag()
+				}
+			}
+			return StateInvalid, p.newParseError("start/end tag mismatch")
 		}
 	}
+	if err := p.notifyEndElement(sOffset, sLength, p.depth); err != nil {
+		return StateInvalid, err
+	}
 	p.depth--
 	if err := p.nextCharAfterWs(); err != nil {
 		return StateInvalid, err
 	}
 	if p.currentChar != '>' {
-		return StateInvalid, erroring.NewParseError("invalid char in ending", p.fmtLine(), nil)
+		return StateInvalid, p.newParseError("invalid char in ending")
 	}
 
 	if p.depth != -1 {
@@ -1,6 +1,10 @@
 package parser
 
-import "github.com/alexZaicev/go-vtd-xml/vtdxml/erroring"
+import (
+	"fmt"
+
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/erroring"
+)
 
 func (p *VtdParser) processLtSeen() (State, error) {
 	p.lastOffset = p.offset
@@ -19,7 +23,7 @@ func (p *VtdParser) processLtSeen() (State, error) {
 		case '?':
 			return p.processQmSeen()
 		default:
-			return StateInvalid, erroring.NewParseError("invalid character after <", p.fmtLine(), nil)
+			return StateInvalid, p.newParseError("invalid character after <")
 		}
 	}
 }
@@ -36,8 +40,7 @@ func (p *VtdParser) processExSeen() (State, error) {
 				p.lastOffset = p.offset
 				return StateStartComment, nil
 			} else {
-				return StateInvalid, erroring.NewParseError("invalid char sequence to start a comment",
-					p.fmtLine(), nil)
+				return StateInvalid, p.newParseError("invalid char sequence to start a comment")
 			}
 		}
 	case '[':
@@ -47,13 +50,17 @@ func (p *VtdParser) processExSeen() (State, error) {
 		p.lastOffset = p.offset
 		return StateCdata, nil
 	case 'D':
-		if err := p.validateSeq(docType); err != nil {
-			return StateInvalid, err
+		if !p.skipCharSeq(docType[1:]) {
+			return StateInvalid, p.newParseError(fmt.Sprintf("invalid char sequence in %s", docType))
+		}
+		// single DOCTYPE is allowed before the root element only
+		if p.depth != -1 || p.rootIndex != 0 || p.dtd != nil {
+			return StateInvalid, p.newParseError(fmt.Sprintf("wrong place for %s", docType))
 		}
 		p.lastOffset = p.offset
 		return StateDocType, nil
 	default:
-		return StateInvalid, erroring.NewParseError("unrecognized char after <!", p.fmtLine(), nil)
+		return StateInvalid, p.newParseError("unrecognized char after <!")
 	}
 }
 
@@ -72,8 +79,7 @@ func (p *VtdParser) processQmSeen() (State, error) {
 				return StateInvalid, err
 			}
 			if ch == '?' || p.xmlChar.IsSpaceChar(ch) {
-				return StateInvalid, erroring.NewParseError("[xX][mM][lL] not a valid PI target name",
-					p.fmtLine(), nil)
+				return StateInvalid, p.newParseError("[xX][mM][lL] not a valid PI target name")
 			}
 			offset, err := p.getPrevOffset()
 			if err != nil {
@@ -83,5 +89,5 @@ func (p *VtdParser) processQmSeen() (State, error) {
 		}
 		return StatePiTag, nil
 	}
-	return StateInvalid, erroring.NewParseError("invalid first char after <?", p.fmtLine(), nil)
+	return StateInvalid, p.newParseError("invalid first char after <?")
 }
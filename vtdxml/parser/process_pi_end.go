@@ -1,16 +1,13 @@
 package parser
 
-import (
-	"github.com/alexZaicev/go-vtd-xml/vtdxml/common"
-	"github.com/alexZaicev/go-vtd-xml/vtdxml/erroring"
-)
+import "github.com/alexZaicev/go-vtd-xml/vtdxml/common"
 
 func (p *VtdParser) processPiEnd() (State, error) {
 	if err := p.nextChar(); err != nil {
 		return StateInvalid, err
 	}
 	if !p.xmlChar.IsNameStartChar(p.currentChar) {
-		return StateInvalid, erroring.NewParseError("invalid char in PI target", p.fmtLine(), nil)
+		return StateInvalid, p.newParseError("invalid char in PI target")
 	}
 	if (p.currentChar == 'x' || p.currentChar == 'X') &&
 		(p.skipChar('m') || p.skipChar('M')) &&
@@ -19,7 +16,7 @@ func (p *VtdParser) processPiEnd() (State, error) {
 			return StateInvalid, err
 		}
 		if p.xmlChar.IsSpaceChar(p.currentChar) || p.currentChar == '?' {
-			return StateInvalid, erroring.NewParseError("[xX][mM][lL] not a valid PI target", p.fmtLine(), nil)
+			return StateInvalid, p.newParseError("[xX][mM][lL] not a valid PI target")
 		}
 	}
 	for {
@@ -31,7 +28,11 @@ func (p *VtdParser) processPiEnd() (State, error) {
 		}
 	}
 
-	p.length1 = p.offset - p.lastOffset - p.increment
+	offset, err := p.getPrevOffset()
+	if err != nil {
+		return StateInvalid, err
+	}
+	p.length1 = offset - p.lastOffset
 	if err := p.writeVtdWithLengthCheck(common.TokenPiName, "PI name too long (>0xFFFF)"); err != nil {
 		return StateInvalid, err
 	}
@@ -42,7 +43,7 @@ func (p *VtdParser) processPiEnd() (State, error) {
 		}
 		for {
 			if !p.xmlChar.IsValidChar(p.currentChar) {
-				return StateInvalid, erroring.NewParseError("invalid char in PI value", p.fmtLine(), nil)
+				return StateInvalid, p.newParseError("invalid char in PI value")
 			}
 			if p.currentChar == '?' && p.skipChar('>') {
 				break
@@ -65,8 +66,8 @@ func (p *VtdParser) processPiEnd() (State, error) {
 				return StateInvalid, err
 			}
 		}
-		if p.currentChar != '?' || p.skipChar('>') {
-			return StateInvalid, erroring.NewParseError("invalid termination sequence", p.fmtLine(), nil)
+		if p.currentChar != '?' || !p.skipChar('>') {
+			return StateInvalid, p.newParseError("invalid termination sequence")
 		}
 	}
 	return StateDocEnd, nil
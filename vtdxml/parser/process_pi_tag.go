@@ -14,7 +14,20 @@ func (p *VtdParser) processPiTag() (State, error) {
 			break
 		}
 	}
-	p.length1 = p.offset - p.lastOffset - p.increment
+	offset, err := p.getPrevOffset()
+	if err != nil {
+		return StateInvalid, err
+	}
+	p.length1 = offset - p.lastOffset
+	if p.nsAware {
+		colons, _, err := p.countColons(p.lastOffset, p.length1)
+		if err != nil {
+			return StateInvalid, err
+		}
+		if colons != 0 {
+			return StateInvalid, p.newParseErrorAt(p.lastOffset, erroring.NsColonInPiTarget)
+		}
+	}
 	if err := p.writeVtdWithLengthCheck(common.TokenPiName, "PI name too long >0xFFFFF"); err != nil {
 		return StateInvalid, err
 	}
@@ -30,12 +43,15 @@ func (p *VtdParser) processPiTag() (State, error) {
 		}
 		if p.skipChar('>') {
 			p.lastOffset = p.offset
+			if p.atFragmentTop() {
+				return StateDocEnd, nil
+			}
 			if err := p.nextCharAfterWs(); err != nil {
 				return StateInvalid, err
 			}
 			return p.getNextProcessStateFromChar(p.currentChar)
 		} else {
-			return StateInvalid, erroring.NewParseError("invalid PI termination sequence", p.fmtLine(), nil)
+			return StateInvalid, p.newParseError("invalid PI termination sequence")
 		}
 	}
 	return StatePiVal, nil
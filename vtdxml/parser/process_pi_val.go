@@ -1,13 +1,10 @@
 package parser
 
-import (
-	"github.com/alexZaicev/go-vtd-xml/vtdxml/common"
-	"github.com/alexZaicev/go-vtd-xml/vtdxml/erroring"
-)
+import "github.com/alexZaicev/go-vtd-xml/vtdxml/common"
 
 func (p *VtdParser) processPiVal() (State, error) {
 	if !p.xmlChar.IsSpaceChar(p.currentChar) {
-		return StateInvalid, erroring.NewParseError("invalid termination sequence", p.fmtLine(), nil)
+		return StateInvalid, p.newParseError("invalid termination sequence")
 	}
 	p.lastOffset = p.offset
 	for {
@@ -15,7 +12,7 @@ func (p *VtdParser) processPiVal() (State, error) {
 			return StateInvalid, err
 		}
 		if !p.xmlChar.IsValidChar(p.currentChar) {
-			return StateInvalid, erroring.NewParseError("invalid char in PI value", p.fmtLine(), nil)
+			return StateInvalid, p.newParseError("invalid char in PI value")
 		}
 		if p.currentChar == '?' && p.skipChar('>') {
 			break
@@ -26,6 +23,9 @@ func (p *VtdParser) processPiVal() (State, error) {
 		return StateInvalid, err
 	}
 	p.lastOffset = p.offset
+	if p.atFragmentTop() {
+		return StateDocEnd, nil
+	}
 	if err := p.nextCharAfterWs(); err != nil {
 		return StateInvalid, err
 	}
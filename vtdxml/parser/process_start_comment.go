@@ -11,7 +11,7 @@ func (p *VtdParser) processStartComment() (State, error) {
 			return StateInvalid, err
 		}
 		if !p.xmlChar.IsValidChar(p.currentChar) {
-			return StateInvalid, erroring.NewParseError(erroring.InvalidChar, p.fmtLine(), nil)
+			return StateInvalid, p.newParseError(erroring.InvalidChar)
 		}
 		if p.currentChar == '-' && p.skipChar('-') {
 			p.length1 = p.offset - p.lastOffset - (p.increment << 1)
@@ -22,7 +22,7 @@ func (p *VtdParser) processStartComment() (State, error) {
 		return StateInvalid, err
 	}
 	if p.currentChar != '>' {
-		return StateInvalid, erroring.NewParseError("invalid terminating sequence", p.fmtLine(), nil)
+		return StateInvalid, p.newParseError("invalid terminating sequence")
 	}
 	if p.singleByteEncoding {
 		if err := p.writeVtdText(common.TokenComment, p.lastOffset, p.length1, p.depth); err != nil {
@@ -34,6 +34,9 @@ func (p *VtdParser) processStartComment() (State, error) {
 		}
 	}
 	p.lastOffset = p.offset
+	if p.atFragmentTop() {
+		return StateDocEnd, nil
+	}
 	if err := p.nextCharAfterWs(); err != nil {
 		return StateInvalid, err
 	}
@@ -1,11 +1,15 @@
 package parser
 
 import (
+	"fmt"
+
 	"github.com/alexZaicev/go-vtd-xml/vtdxml/common"
 	"github.com/alexZaicev/go-vtd-xml/vtdxml/erroring"
 )
 
 func (p *VtdParser) processStartTag() (State, error) {
+	// starting tag is not written until its name is checked
+	p.currentElementIndex = -1
 	for {
 		if err := p.nextChar(); err != nil {
 			return StateInvalid, err
@@ -16,18 +20,31 @@ func (p *VtdParser) processStartTag() (State, error) {
 		if p.currentChar == ':' {
 			p.length2 = p.offset - p.lastOffset - p.increment
 			if p.nsAware && p.checkXmlnsPrefix(p.lastOffset, p.length2, true) {
-				return StateInvalid, erroring.NewParseError("XMLNS cannot be element prefix", p.fmtLine(), nil)
+				return StateInvalid, p.newParseErrorAt(p.lastOffset,
+					fmt.Sprintf("%s: xmlns cannot be element prefix", erroring.NsPrefixReserved))
 			}
 		}
 	}
 
-	p.length1 = p.offset - p.lastOffset - p.increment
+	offset, err := p.getPrevOffset()
+	if err != nil {
+		return StateInvalid, err
+	}
+	p.length1 = offset - p.lastOffset
+	if p.nsAware {
+		if err := p.checkQName(p.lastOffset, p.length1); err != nil {
+			return StateInvalid, err
+		}
+	}
 	if p.depth > maxDepth {
-		return StateInvalid, erroring.NewParseError(erroring.MaximumDepthExceeded, p.fmtLine(), nil)
+		return StateInvalid, p.newParseError(erroring.MaximumDepthExceeded)
+	}
+	if err := p.checkDepthLimit(); err != nil {
+		return StateInvalid, err
 	}
 
-	x := (p.length1 << 32) + p.lastOffset
-	p.tagStack[p.depth] = int64(x)
+	p.tagStack[p.depth] = packName(p.lastOffset, p.length1)
+	p.enterSpaceScope()
 
 	if p.depth > p.vtdDepth {
 		p.vtdDepth = p.depth
@@ -35,23 +52,23 @@ func (p *VtdParser) processStartTag() (State, error) {
 
 	if p.singleByteEncoding {
 		if p.length2 > maxPrefixLength || p.length1 > maxQnameLength {
-			return StateInvalid, erroring.NewParseError(erroring.TagPrefixQnameTooLong, p.fmtLine(), nil)
+			return StateInvalid, p.newParseError(erroring.TagPrefixQnameTooLong)
 		}
 		if p.shallowDepth {
-			if err := p.writeVtd(common.TokenStartingTag, p.lastOffset, (p.length2<<1)|p.length1, p.depth); err != nil {
+			if err := p.writeVtdL3(common.TokenStartingTag, p.lastOffset, (p.length2<<11)|p.length1, p.depth); err != nil {
 				return StateInvalid, err
 			}
 		} else {
-			if err := p.writeVtdL5(common.TokenStartingTag, p.lastOffset, (p.length2<<1)|p.length1, p.depth); err != nil {
+			if err := p.writeVtdL5(common.TokenStartingTag, p.lastOffset, (p.length2<<11)|p.length1, p.depth); err != nil {
 				return StateInvalid, err
 			}
 		}
 	} else {
 		if p.length2 > (maxPrefixLength<<1) || p.length1 > (maxQnameLength<<1) {
-			return StateInvalid, erroring.NewParseError(erroring.TagPrefixQnameTooLong, p.fmtLine(), nil)
+			return StateInvalid, p.newParseError(erroring.TagPrefixQnameTooLong)
 		}
 		if p.shallowDepth {
-			if err := p.writeVtd(common.TokenStartingTag, p.lastOffset>>1, (p.length2<<10)|(p.length1>>1),
+			if err := p.writeVtdL3(common.TokenStartingTag, p.lastOffset>>1, (p.length2<<10)|(p.length1>>1),
 				p.depth); err != nil {
 				return StateInvalid, err
 			}
@@ -63,6 +80,11 @@ func (p *VtdParser) processStartTag() (State, error) {
 		}
 	}
 
+	if p.chunk == nil {
+		// chunks of parallel parsing collect tokens without VTD buffer
+		p.currentElementIndex = p.vtdBuffer.GetSize() - 1
+	}
+
 	if p.nsAware {
 		if p.length2 != 0 {
 			p.length2 += p.increment
@@ -73,12 +95,18 @@ func (p *VtdParser) processStartTag() (State, error) {
 
 		if p.depth <= p.nsBuffer1.GetSize()-1 {
 			p.nsBuffer1.SetSize(p.depth)
-			t, err := p.nsBuffer1.IntAt(p.depth - 1)
-			if err != nil {
-				return StateInvalid, err
+			// next top-level element of a fragment starts without
+			// namespace declarations in scope
+			t := int32(-1)
+			if p.depth > 0 {
+				var err error
+				if t, err = p.nsBuffer1.IntAt(p.depth - 1); err != nil {
+					return StateInvalid, err
+				}
 			}
 			p.nsBuffer2.SetSize(int(t + 1))
 			p.nsBuffer3.SetSize(int(t + 1))
+			p.nsBuffer4.SetSize(int(t + 1))
 		}
 	}
 	p.length2 = 0
@@ -107,16 +135,11 @@ func (p *VtdParser) processStartTag() (State, error) {
 	}
 	if p.currentChar == '>' {
 		if p.nsAware {
-			if err := p.nsBuffer1.Append(int32(p.nsBuffer3.GetSize() - 1)); err != nil {
+			if err := p.qualifyNames(); err != nil {
 				return StateInvalid, err
 			}
-			if p.currentElementRecord != 0 {
-				if err := p.qualifyElement(); err != nil {
-					return StateInvalid, err
-				}
-			}
 		}
 		return p.processElementTail()
 	}
-	return StateInvalid, erroring.NewParseError("invalid char in starting tag", p.fmtLine(), nil)
+	return StateInvalid, p.newParseError("invalid char in starting tag")
 }
@@ -1,13 +1,10 @@
 package parser
 
-import (
-	"github.com/alexZaicev/go-vtd-xml/vtdxml/common"
-	"github.com/alexZaicev/go-vtd-xml/vtdxml/erroring"
-)
+import "github.com/alexZaicev/go-vtd-xml/vtdxml/common"
 
 func (p *VtdParser) processText() (State, error) {
 	if p.depth < 0 {
-		return StateInvalid, erroring.NewParseError("text content at the wrong place", p.fmtLine(), nil)
+		return StateInvalid, p.newParseError("text content at the wrong place")
 	}
 	for {
 		if err := p.nextChar(); err != nil {
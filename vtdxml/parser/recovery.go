@@ -0,0 +1,265 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/erroring"
+)
+
+// WithRecovery option makes parsing lenient: a well-formedness error is
+// recorded instead of stopping the parse, and parsing resumes at the next <
+// of the document. Start tag of an element the error was found in is skipped
+// up to its > first, so that the element keeps its place. An end tag closing
+// an ancestor of the current element auto-closes the elements opened after
+// the ancestor, elements still open at the end of the document are
+// auto-closed too, and each of them is recorded as an error. Parse succeeds
+// if the root element was found and no more than maxErrors errors were
+// recorded, the index then holds every token read and the errors are listed
+// by Errors. Parsing stops with the error exceeding maxErrors. Limits,
+// decoding of the document and context cancellation still stop parsing at
+// once. Zero maxErrors, the default, keeps parsing strict.
+func WithRecovery(maxErrors int) Option {
+	return func(p *VtdParser) {
+		p.maxErrors = maxErrors
+	}
+}
+
+// Errors function returns errors recorded by the last parse in recovery mode,
+// each located in the document
+func (p *VtdParser) Errors() []*erroring.ParseError {
+	return p.recovered
+}
+
+// recover function records the error raised by the current state and returns
+// the state parsing resumes in, or the error if it cannot be recovered from
+func (p *VtdParser) recover(err error) (State, error) {
+	if p.maxErrors == 0 {
+		return StateInvalid, err
+	}
+	if errors.As(err, &erroring.EOFErrorType) {
+		return p.recoverAtEnd(err)
+	}
+	var pErr *erroring.ParseError
+	switch e := err.(type) {
+	case *erroring.ParseError:
+		pErr = e
+	case *erroring.EntityError:
+		pErr = p.newParseError(e.Msg)
+		pErr.Err = e
+	case *erroring.DecodingError:
+		pErr = p.newParseError(e.Msg)
+		pErr.Err = e
+	default:
+		return StateInvalid, err
+	}
+	if err := p.recordError(pErr); err != nil {
+		return StateInvalid, err
+	}
+	// start tag whose element token was written is finished, so that the
+	// element is closed by its end tag or by / of an empty element tag
+	inStartTag := p.state == StateAttrName || p.state == StateAttrVal ||
+		(p.state == StateTagStart && p.currentElementIndex >= 0)
+	if err := p.leaveBrokenTag(); err != nil {
+		return StateInvalid, err
+	}
+	if inStartTag {
+		finished, err := p.finishBrokenTag()
+		if err != nil {
+			return StateInvalid, err
+		}
+		if finished {
+			p.state = StateText
+			ps, err := p.processElementTail()
+			if err != nil {
+				return p.recover(p.locate(err))
+			}
+			return ps, nil
+		}
+	}
+	return p.resync(pErr)
+}
+
+// recordError function records the error found in recovery mode. It returns
+// the error once more than maxErrors errors were found.
+func (p *VtdParser) recordError(err *erroring.ParseError) error {
+	p.recovered = append(p.recovered, err)
+	if len(p.recovered) > p.maxErrors {
+		return err
+	}
+	return nil
+}
+
+// leaveBrokenTag function resets state of the start tag the error was raised
+// in. Element whose token was written stays open, otherwise the depth it
+// was given is taken back.
+func (p *VtdParser) leaveBrokenTag() error {
+	p.attrCount, p.prefixedAttCount = 0, 0
+	p.length2 = 0
+	p.isNs, p.isXml, p.defaultNs = false, false, false
+	if p.state == StateTagStart && p.currentElementIndex < 0 {
+		p.depth--
+	}
+	if p.nsAware {
+		// namespace declarations in scope of the open element
+		for p.nsBuffer1.GetSize() <= p.depth {
+			if err := p.nsBuffer1.Append(int32(p.nsBuffer3.GetSize() - 1)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// finishBrokenTag function skips the rest of the start tag the error was
+// raised in up to its >, and takes back the depth of an empty element tag.
+// It returns false if another markup or the end of the document comes first,
+// the element then stays open.
+func (p *VtdParser) finishBrokenTag() (bool, error) {
+	start := p.offset - p.increment
+	for offset := start; offset < p.endOffset; offset += p.increment {
+		ch, err := p.getCharUnit(offset)
+		if err != nil {
+			return false, err
+		}
+		if ch == '<' {
+			return false, nil
+		}
+		if ch != '>' {
+			continue
+		}
+		p.helper = true
+		if offset > start {
+			prev, err := p.getCharUnit(offset - p.increment)
+			if err != nil {
+				return false, err
+			}
+			p.helper = prev != '/'
+		}
+		if !p.helper {
+			p.depth--
+		}
+		p.setOffset(offset + p.increment)
+		p.currentChar = '>'
+		return true, nil
+	}
+	return false, nil
+}
+
+// resync function moves the parser to the next < following the error. When
+// there is none, parsing ends at the end of the document.
+func (p *VtdParser) resync(err *erroring.ParseError) (State, error) {
+	// < just read starts the markup the error was found in, unless the
+	// error is found right after it. Another < found there starts the next
+	// markup.
+	from := p.offset - p.increment
+	if (p.state == StateLtSeen || p.state == StateDocEnd) && p.currentChar != '<' {
+		from = p.offset
+	}
+	if from < p.resyncOffset {
+		from = p.resyncOffset
+	}
+	if from < p.docOffset {
+		from = p.docOffset
+	}
+	lt, chErr := p.nextLt(from)
+	if chErr != nil {
+		return StateInvalid, chErr
+	}
+	if lt < 0 {
+		return p.recoverAtEnd(err)
+	}
+	p.resyncOffset = lt + p.increment
+	if p.depth == -1 && p.rootIndex != 0 {
+		// only comments and processing instructions follow the root
+		// element, fragments are read on by processDocEnd too
+		p.setOffset(lt)
+		return StateDocEnd, nil
+	}
+	p.setOffset(lt + p.increment)
+	p.currentChar = '<'
+	return StateLtSeen, nil
+}
+
+// nextLt function returns offset of the first < at or after the offset
+// given, or -1 if there is none
+func (p *VtdParser) nextLt(offset int) (int, error) {
+	offset -= (offset - p.docOffset) % p.increment
+	for ; offset < p.endOffset; offset += p.increment {
+		ch, err := p.getCharUnit(offset)
+		if err != nil {
+			return -1, err
+		}
+		if ch == '<' {
+			return offset, nil
+		}
+	}
+	return -1, nil
+}
+
+// recoverAtEnd function ends parsing at the end of the document, reached
+// before the document was complete or while looking for markup following an
+// error. Elements still open are auto-closed. Error is returned if the
+// document has no root element.
+func (p *VtdParser) recoverAtEnd(err error) (State, error) {
+	if p.rootIndex == 0 {
+		return StateInvalid, err
+	}
+	if errors.As(err, &erroring.EOFErrorType) {
+		if err := p.recordError(p.newParseErrorAt(p.endOffset, erroring.XmlIncomplete)); err != nil {
+			return StateInvalid, err
+		}
+	}
+	if err := p.leaveBrokenTag(); err != nil {
+		return StateInvalid, err
+	}
+	if err := p.autoClose(-1, p.endOffset); err != nil {
+		return StateInvalid, err
+	}
+	p.setOffset(p.endOffset)
+	return StateDocEnd, nil
+}
+
+// autoClose function closes open elements deeper than the depth given,
+// recording each of them as not closed at the offset given
+func (p *VtdParser) autoClose(depth, offset int) error {
+	for ; p.depth > depth; p.depth-- {
+		nameOffset, length := unpackName(p.tagStack[p.depth])
+		msg := fmt.Sprintf("%s: %s", erroring.ElementNotClosed, p.nameString(nameOffset, length))
+		if err := p.recordError(p.newParseErrorAt(offset, msg)); err != nil {
+			return err
+		}
+		if err := p.notifyEndElement(nameOffset, length, p.depth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// closeToAncestor function looks for an ancestor of the current element
+// named as the end tag being read. When there is one, elements opened after
+// it are auto-closed and the parser is put back at the start of the end tag
+// name, so that the end tag closes the ancestor.
+func (p *VtdParser) closeToAncestor(nameOffset int) (bool, error) {
+	for depth := p.depth - 1; depth >= 0; depth-- {
+		offset, length := unpackName(p.tagStack[depth])
+		if nameOffset+length >= p.endOffset ||
+			!bytes.Equal(p.xmlDoc[offset:offset+length], p.xmlDoc[nameOffset:nameOffset+length]) {
+			continue
+		}
+		ch, err := p.getCharUnit(nameOffset + length)
+		if err != nil {
+			return false, err
+		}
+		if ch != '>' && !p.xmlChar.IsSpaceChar(uint32(ch)) {
+			continue
+		}
+		if err := p.autoClose(depth, nameOffset); err != nil {
+			return false, err
+		}
+		p.setOffset(nameOffset)
+		return true, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,173 @@
+package parser
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/common"
+	"github.com/stretchr/testify/assert"
+)
+
+// recoveredErrors function returns errors recorded by the parser as
+// line:column message strings
+func recoveredErrors(parser *VtdParser) []string {
+	var errs []string
+	for _, err := range parser.Errors() {
+		errs = append(errs, fmt.Sprintf("%d:%d %s", err.Line, err.Column, err.Msg))
+	}
+	return errs
+}
+
+func Test_VtdParser_Recovery_Success(t *testing.T) {
+	testCases := []struct {
+		name             string
+		doc              string
+		opts             []Option
+		expectedErrors   []string
+		expectedElements []string
+	}{
+		{
+			name:             "well-formed document",
+			doc:              `<a><b x="1"/></a>`,
+			expectedElements: []string{"0 a", "1 b"},
+		},
+		{
+			name:             "unquoted attribute value",
+			doc:              "<a>\n<b x=1/>\n<c/>\n</a>",
+			expectedErrors:   []string{"2:7 invalid character should be ' or \" "},
+			expectedElements: []string{"0 a", "1 b", "1 c"},
+		},
+		{
+			name: "several errors",
+			doc:  "<a>\n<b x=1/>\n<c>&bad;</c>\n<d y='2' y='3'/>\n</a>",
+			expectedErrors: []string{
+				"2:7 invalid character should be ' or \" ",
+				"3:9 illegal build-in entity reference",
+				"4:12 attribute name not unique",
+			},
+			expectedElements: []string{"0 a", "1 b", "1 c", "1 d"},
+		},
+		{
+			name:             "< after <",
+			doc:              "<a><c><<d/></c></a>",
+			expectedErrors:   []string{"1:9 invalid character after <"},
+			expectedElements: []string{"0 a", "1 c", "2 d"},
+		},
+		{
+			name:             "attribute error in empty element tag",
+			doc:              `<a><b x='1' x='2'/><d/></a>`,
+			expectedErrors:   []string{"1:15 attribute name not unique"},
+			expectedElements: []string{"0 a", "1 b", "1 d"},
+		},
+		{
+			name:             "attribute error in start tag",
+			doc:              `<a><b x=1 y='2'>text<c/></b><d/></a>`,
+			expectedErrors:   []string{"1:10 invalid character should be ' or \" "},
+			expectedElements: []string{"0 a", "1 b", "2 c", "1 d"},
+		},
+		{
+			name:             "end tag of an ancestor",
+			doc:              "<a>\n<b><c>text</a>",
+			expectedErrors:   []string{"2:13 element not closed: c", "2:13 element not closed: b"},
+			expectedElements: []string{"0 a", "1 b", "2 c"},
+		},
+		{
+			name:             "end tag of no open element",
+			doc:              "<a><b></x><c/></b></a>",
+			expectedErrors:   []string{"1:10 start/end tag mismatch"},
+			expectedElements: []string{"0 a", "1 b", "2 c"},
+		},
+		{
+			name:             "unclosed elements at end of document",
+			doc:              "<a>\n<b><c/>",
+			expectedErrors:   []string{"2:8 XML document incomplete", "2:8 element not closed: b", "2:8 element not closed: a"},
+			expectedElements: []string{"0 a", "1 b", "2 c"},
+		},
+		{
+			name:             "garbage after the root element",
+			doc:              "<a/>\ntext<!-- c -->",
+			expectedErrors:   []string{"2:2 XML not terminated properly"},
+			expectedElements: []string{"0 a"},
+		},
+		{
+			name:             "undeclared namespace prefix",
+			doc:              `<a xmlns:p="u"><q:b/><p:c/></a>`,
+			opts:             []Option{WithNameSpaceAware(true)},
+			expectedErrors:   []string{"1:17 namespace prefix not declared: q"},
+			expectedElements: []string{"0 a", "1 q:b", "1 p:c"},
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			for _, doc := range [][]byte{[]byte(tc.doc), encodeUtf16(tc.doc, false, true)} {
+				parser, err := NewVtdParser(append([]Option{WithXmlDoc(doc), WithRecovery(5)}, tc.opts...)...)
+				assert.Nil(t, err)
+				assert.Nil(t, parser.Parse())
+				assert.Equal(t, tc.expectedErrors, recoveredErrors(parser))
+
+				// partial index is navigable
+				nav, err := parser.GetNav()
+				assert.Nil(t, err)
+				var elements []string
+				for i := 0; i < nav.GetVtdBufferSize(); i++ {
+					tokenType, err := nav.GetTokenType(i)
+					assert.Nil(t, err)
+					if tokenType != int32(common.TokenStartingTag) {
+						continue
+					}
+					name, err := nav.ToRawStringAtIndex(i)
+					assert.Nil(t, err)
+					depth, err := nav.GetTokenDepth(i)
+					assert.Nil(t, err)
+					elements = append(elements, fmt.Sprintf("%d %s", depth, name))
+				}
+				assert.Equal(t, tc.expectedElements, elements)
+			}
+		})
+	}
+}
+
+func Test_VtdParser_Recovery_Failed(t *testing.T) {
+	testCases := []struct {
+		name           string
+		doc            string
+		maxErrors      int
+		expectedErr    string
+		expectedErrors []string
+	}{
+		{
+			name:           "too many errors",
+			doc:            "<a>\n<b x=1/>\n<c y=2/>\n</a>",
+			maxErrors:      1,
+			expectedErr:    "a parse error occurred: invalid character should be ' or \" ",
+			expectedErrors: []string{"2:7 invalid character should be ' or \" ", "3:7 invalid character should be ' or \" "},
+		},
+		{
+			name:           "no root element",
+			doc:            "<!-- c -->text",
+			maxErrors:      1,
+			expectedErr:    "a parse error occurred: text content at the wrong place",
+			expectedErrors: []string{"1:12 text content at the wrong place"},
+		},
+		{
+			name:        "strict parsing",
+			doc:         "<a><b x=1/></a>",
+			expectedErr: "a parse error occurred: invalid character should be ' or \" ",
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			parser, err := NewVtdParser(WithXmlDoc([]byte(tc.doc)), WithRecovery(tc.maxErrors))
+			assert.Nil(t, err)
+			assert.EqualError(t, parser.Parse(), tc.expectedErr)
+			assert.Equal(t, tc.expectedErrors, recoveredErrors(parser))
+		})
+	}
+}
+
+func Test_VtdParser_Recovery_InvalidMaxErrors(t *testing.T) {
+	_, err := NewVtdParser(WithXmlDoc([]byte("<a/>")), WithRecovery(-1))
+	assert.EqualError(t, err, "invalid argument maxErrors: cannot be negative")
+}
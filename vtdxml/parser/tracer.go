@@ -0,0 +1,81 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/common"
+)
+
+var stateNames = map[State]string{
+	StateLtSeen:       "LtSeen",
+	StateTagStart:     "TagStart",
+	StateTagEnd:       "TagEnd",
+	StateAttrName:     "AttrName",
+	StateAttrVal:      "AttrVal",
+	StateText:         "Text",
+	StateDocStart:     "DocStart",
+	StateDocEnd:       "DocEnd",
+	StatePiTag:        "PiTag",
+	StatePiVal:        "PiVal",
+	StateDecAttrName:  "DecAttrName",
+	StateStartComment: "StartComment",
+	StateEndComment:   "EndComment",
+	StateCdata:        "Cdata",
+	StateDocType:      "DocType",
+	StatePiEnd:        "PiEnd",
+	StateInvalid:      "Invalid",
+}
+
+// String function returns the name of the parser state
+func (s State) String() string {
+	if name, ok := stateNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("State(%d)", int(s))
+}
+
+// Tracer receives parser events while parsing. Tracing is disabled unless a
+// tracer is registered with WithTracer.
+type Tracer interface {
+	// StateTransition is called every time a parser state finishes
+	// processing, offset is the document offset reached
+	StateTransition(from, to State, offset int)
+	// TokenWritten is called for every token appended to the VTD buffer at
+	// the given index
+	TokenWritten(index int, tokenType common.Token, offset, length, depth int)
+	// LcAppended is called for every entry appended to the location cache
+	// of the given level
+	LcAppended(level int, entry int64)
+}
+
+// WithTracer option registers tracer receiving parser events
+func WithTracer(tracer Tracer) Option {
+	return func(p *VtdParser) {
+		p.tracer = tracer
+	}
+}
+
+// WriterTracer is a Tracer writing one line per event, useful when debugging
+// malformed documents
+type WriterTracer struct {
+	w io.Writer
+}
+
+func NewWriterTracer(w io.Writer) *WriterTracer {
+	return &WriterTracer{
+		w: w,
+	}
+}
+
+func (t *WriterTracer) StateTransition(from, to State, offset int) {
+	fmt.Fprintf(t.w, "state %s -> %s offset=%d\n", from, to, offset)
+}
+
+func (t *WriterTracer) TokenWritten(index int, tokenType common.Token, offset, length, depth int) {
+	fmt.Fprintf(t.w, "token #%d type=%d offset=%d length=%d depth=%d\n", index, tokenType, offset, length, depth)
+}
+
+func (t *WriterTracer) LcAppended(level int, entry int64) {
+	fmt.Fprintf(t.w, "lc l%d upper=%d lower=%d\n", level, entry>>32, int32(entry))
+}
@@ -0,0 +1,74 @@
+package parser
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/common"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingTracer struct {
+	transitions [][2]State
+	tokens      []common.Token
+	lcEntries   map[int]int
+}
+
+func (t *recordingTracer) StateTransition(from, to State, offset int) {
+	t.transitions = append(t.transitions, [2]State{from, to})
+}
+
+func (t *recordingTracer) TokenWritten(index int, tokenType common.Token, offset, length, depth int) {
+	t.tokens = append(t.tokens, tokenType)
+}
+
+func (t *recordingTracer) LcAppended(level int, entry int64) {
+	t.lcEntries[level]++
+}
+
+func Test_VtdParser_WithTracer_Success(t *testing.T) {
+	for _, lcDepth := range []int{3, 5} {
+		tracer := &recordingTracer{lcEntries: map[int]int{}}
+		parser, err := NewVtdParser(
+			WithXmlDoc(readTestData(t, "xml_opt1", true)),
+			WithLcDepth(lcDepth),
+			WithTracer(tracer),
+		)
+		assert.Nil(t, err)
+		assert.Nil(t, parser.Parse())
+
+		assert.Equal(t, [2]State{StateDocStart, StateDecAttrName}, tracer.transitions[0])
+		for i := 1; i < len(tracer.transitions); i++ {
+			assert.Equal(t, tracer.transitions[i-1][1], tracer.transitions[i][0])
+		}
+		assert.Equal(t, StateDocEnd, tracer.transitions[len(tracer.transitions)-1][1])
+
+		assert.Equal(t, parser.vtdBuffer.GetSize(), len(tracer.tokens))
+		assert.Equal(t, common.TokenDocument, tracer.tokens[0])
+		assert.Equal(t, parser.l1Buffer.GetSize(), tracer.lcEntries[1])
+		assert.Equal(t, parser.l2Buffer.GetSize(), tracer.lcEntries[2])
+		assert.Equal(t, parser.l3Buffer.GetSize(), tracer.lcEntries[3])
+	}
+}
+
+func Test_WriterTracer_Success(t *testing.T) {
+	var out bytes.Buffer
+	parser, err := NewVtdParser(
+		WithXmlDoc([]byte("<?xml version=\"1.0\"?><a><b/></a>")),
+		WithTracer(NewWriterTracer(&out)),
+	)
+	assert.Nil(t, err)
+	assert.Nil(t, parser.Parse())
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Equal(t, "token #0 type=13 offset=0 length=0 depth=-1", lines[0])
+	assert.Equal(t, "state DocStart -> DecAttrName offset=7", lines[1])
+	assert.Contains(t, out.String(), "lc l1 upper=4 lower=-1\n")
+}
+
+func Test_State_String(t *testing.T) {
+	assert.Equal(t, "LtSeen", StateLtSeen.String())
+	assert.Equal(t, "Invalid", StateInvalid.String())
+	assert.Equal(t, "State(42)", State(42).String())
+}
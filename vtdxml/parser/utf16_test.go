@@ -0,0 +1,132 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+	utf16enc "unicode/utf16"
+
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/common"
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/navigation"
+	"github.com/stretchr/testify/assert"
+)
+
+const utf16Xml = "<?xml version=\"1.0\" encoding=\"UTF-16\"?>\r\n" +
+	"<pre:Vehicle xmlns:pre='urn:example-org:Transport' type='car'>\r\n" +
+	"\t<seats> 4 </seats>\r\n" +
+	"\t<colour>Blanc cassé &amp; gris 𝄞</colour>\r\n" +
+	"</pre:Vehicle>"
+
+func Test_VtdParser_Parse_Utf16_Success(t *testing.T) {
+	testCases := []struct {
+		name             string
+		bigEndian        bool
+		bom              bool
+		declaredEncoding string
+		expectedEncoding common.FormatEncoding
+	}{
+		{
+			name:             "UTF-16BE with BOM",
+			bigEndian:        true,
+			bom:              true,
+			declaredEncoding: "UTF-16",
+			expectedEncoding: common.FormatUtf16BE,
+		},
+		{
+			name:             "UTF-16LE with BOM",
+			bom:              true,
+			declaredEncoding: "UTF-16",
+			expectedEncoding: common.FormatUtf16LE,
+		},
+		{
+			name:             "UTF-16BE without BOM",
+			bigEndian:        true,
+			declaredEncoding: "UTF-16BE",
+			expectedEncoding: common.FormatUtf16BE,
+		},
+		{
+			name:             "UTF-16LE without BOM",
+			declaredEncoding: "utf-16le",
+			expectedEncoding: common.FormatUtf16LE,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			doc := encodeUtf16(strings.Replace(utf16Xml, "UTF-16", tc.declaredEncoding, 1), tc.bigEndian, tc.bom)
+			parser, err := NewVtdParser([]Option{
+				WithXmlDoc(doc),
+				WithNameSpaceAware(true),
+			}...)
+			assert.Nil(t, err)
+			assert.Nil(t, parser.Parse())
+
+			nav, err := parser.GetNav()
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectedEncoding, nav.GetEncoding())
+
+			assertCurrentElement(t, nav, "pre:Vehicle")
+			assertMove(t, nav, navigation.LastChild, "colour")
+			index, err := nav.GetCurrentIndex()
+			assert.Nil(t, err)
+			text, err := nav.ToStringAtIndex(int(index) + 1)
+			assert.Nil(t, err)
+			assert.Equal(t, "Blanc cassé & gris 𝄞", text)
+			raw, err := nav.ToRawStringAtIndex(int(index) + 1)
+			assert.Nil(t, err)
+			assert.Equal(t, "Blanc cassé &amp; gris 𝄞", raw)
+		})
+	}
+}
+
+func Test_VtdParser_Parse_Utf16_InvalidDocument(t *testing.T) {
+	testCases := []struct {
+		name           string
+		doc            []byte
+		expectedErrMsg string
+	}{
+		{
+			name:           "Broken UTF-16BE BOM",
+			doc:            []byte{0xFE, 0x00, 0x00, '<'},
+			expectedErrMsg: "unknown character encoding: should be 0xFE 0xFF",
+		},
+		{
+			name:           "Broken UTF-16LE BOM",
+			doc:            []byte{0xFF, 0x00, '<', 0x00},
+			expectedErrMsg: "unknown character encoding: not UTF-16LE",
+		},
+		{
+			name:           "UTF-16 declared without BOM",
+			doc:            encodeUtf16(utf16Xml, true, false),
+			expectedErrMsg: "a parse error occurred: BOM not detected for UTF-16",
+		},
+		{
+			name:           "UTF-8 declared in UTF-16 document",
+			doc:            encodeUtf16(strings.Replace(utf16Xml, "UTF-16", "UTF-8", 1), false, true),
+			expectedErrMsg: "unknown character encoding: declared encoding UTF-8 contradicts UTF-16LE BOM",
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			parser, err := NewVtdParser(WithXmlDoc(tc.doc))
+			assert.Nil(t, err)
+			assert.EqualError(t, parser.Parse(), tc.expectedErrMsg)
+		})
+	}
+}
+
+func encodeUtf16(s string, bigEndian, bom bool) []byte {
+	units := utf16enc.Encode([]rune(s))
+	if bom {
+		units = append([]uint16{0xFEFF}, units...)
+	}
+	doc := make([]byte, 0, len(units)*2)
+	for _, u := range units {
+		if bigEndian {
+			doc = append(doc, byte(u>>8), byte(u))
+		} else {
+			doc = append(doc, byte(u), byte(u>>8))
+		}
+	}
+	return doc
+}
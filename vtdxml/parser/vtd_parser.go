@@ -3,6 +3,7 @@ package parser
 import (
 	"github.com/alexZaicev/go-vtd-xml/vtdxml/buffer"
 	"github.com/alexZaicev/go-vtd-xml/vtdxml/common"
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/dtd"
 	"github.com/alexZaicev/go-vtd-xml/vtdxml/erroring"
 	"github.com/alexZaicev/go-vtd-xml/vtdxml/reader"
 )
@@ -12,9 +13,14 @@ const (
 	DefaultDepth        = -1
 	DefaultLcDepth      = 3
 	DefaultIncrement    = 1
+	DefaultEncoding     = common.FormatUtf8
 
 	DefaultBufferReuse = false
 
+	// DefaultCheckInterval is the number of document bytes parsed between
+	// cancellation checks and progress reports
+	DefaultCheckInterval = 64 * 1024
+
 	DefaultDepth3L1BufferSize = 8
 	DefaultDepth3L2BufferSize = 9
 	DefaultDepth3L3BufferSize = 11
@@ -28,34 +34,73 @@ const (
 	DefaultTagArraySize  = 256
 	DefaultAttrArraySize = 256
 	DefaultUrArraySize   = 256
+
+	// MaxDocumentSize is the largest document the parser can index. Documents
+	// whose token offsets do not fit the VTD record are parsed in extended VTD
+	// mode, namespace aware parsing is limited to MaxNsAwareDocumentSize
+	MaxDocumentSize        = (1 << 38) - 1
+	MaxNsAwareDocumentSize = (1 << 31) - 1
 )
 
 type Option func(*VtdParser)
 
-// VtdParser VTD generator implementation supporting build-in entities only.
-// Handles DTD parsing, but does not resolve declared entities.
+// ProgressFunc receives the number of document bytes consumed and the number
+// of VTD tokens written so far
+type ProgressFunc func(bytesConsumed, tokenCount int)
+
+// VtdParser VTD generator implementation. Tokenizes the internal DTD subset
+// and resolves built-in entities as well as internal general entities it
+// declares, external entities are not resolved.
 type VtdParser struct {
 	xmlDoc                                                              []byte
 	offset, docOffset, lastOffset, endOffset                            int
 	length, length1, length2, docLength                                 int
 	depth, vtdDepth, lcDepth, lastDepth                                 int
 	increment                                                           int
-	rootIndex, lastL1Index, lastL2Index, lastL3Index, lastL4Index       int
+	rootIndex, lastL0Index, lastL1Index, lastL2Index, lastL3Index       int
+	lastL4Index                                                         int
 	attrCount, prefixedAttCount                                         int
-	attrNameSlice, prefixUrlSlice                                       []int
+	prefixUrlSlice, prefixedAttrIndexSlice                              []int
 	currentChar, lastChar                                               uint32
 	currentElementRecord                                                int64
+	currentElementIndex                                                 int
 	nsAware, defaultNs, isNs                                            bool
 	singleByteEncoding, bomDetected, mustUtf8, shallowDepth, helper, ws bool
 	isXml                                                               bool
-	bufferReuse                                                         bool
+	bufferReuse, parsed                                                 bool
+	extended, forceExtended                                             bool
+	fragment, stream                                                    bool
+	parallelism, minChunkSize, parallelStart                            int
+	chunk                                                               *chunk
+	maxDocSize                                                          int
+	mappedPath                                                          string
+	mappedFile                                                          *buffer.MappedByteBuffer
+	checkInterval                                                       int
+	progress                                                            ProgressFunc
+	tracer                                                              Tracer
+	handler                                                             Handler
+	pendingName                                                         Event
+	state                                                               State
+	lineIndex                                                           *reader.LineIndex
+	dtd                                                                 *dtd.Dtd
+	externalSubset                                                      bool
+	peStack                                                             []string
+	limits                                                              Limits
+	maxErrors, resyncOffset                                             int
+	recovered                                                           []*erroring.ParseError
+	whitespacePolicy                                                    WhitespacePolicy
+	preserveSpace                                                       []bool
+	spaceAttr                                                           bool
+	textTokenCount, entityExpansion                                     int
 	encoding                                                            common.FormatEncoding
-	xmlChar                                                             *common.XmlChar
+	xmlChar, spareXmlChar                                               *common.XmlChar
+	xml11                                                               bool
 	vtdBuffer, l1Buffer, l2Buffer, l3Buffer, l4Buffer, l5Buffer         buffer.LongBuffer
-	nsBuffer1                                                           buffer.IntBuffer
-	nsBuffer2, nsBuffer3                                                buffer.LongBuffer
+	l0Buffer, offsetBuffer                                              buffer.LongBuffer
+	nsBuffer1, nsBuffer4                                                buffer.IntBuffer
+	nsBuffer2, nsBuffer3, nsUrlBuffer                                   buffer.LongBuffer
 	reader                                                              reader.Reader
-	tagStack, prefixedAttrNameSlice                                     []int64
+	tagStack, attrNameSlice, prefixedAttrNameSlice                      []int64
 }
 
 func WithXmlDoc(xmlDoc []byte) Option {
@@ -89,6 +134,41 @@ func WithBufferReuse(br bool) Option {
 	}
 }
 
+// WithMaxDocumentSize option limits the size of documents accepted by the
+// parser. Zero keeps the default limit of MaxDocumentSize, or
+// MaxNsAwareDocumentSize for namespace aware parsing, capped by the largest
+// int of the platform.
+func WithMaxDocumentSize(size int) Option {
+	return func(p *VtdParser) {
+		p.maxDocSize = size
+	}
+}
+
+// WithProgress option registers a callback invoked periodically while
+// parsing and once parsing completes
+func WithProgress(fn ProgressFunc) Option {
+	return func(p *VtdParser) {
+		p.progress = fn
+	}
+}
+
+// WithCheckInterval option sets the number of document bytes parsed between
+// cancellation checks and progress reports
+func WithCheckInterval(size int) Option {
+	return func(p *VtdParser) {
+		p.checkInterval = size
+	}
+}
+
+// WithExtendedVtd option forces extended VTD mode, where token offsets are
+// kept in a separate offset buffer, for documents small enough for the
+// standard mode. Larger documents are always parsed in extended mode.
+func WithExtendedVtd(extended bool) Option {
+	return func(p *VtdParser) {
+		p.forceExtended = extended
+	}
+}
+
 func WithLcDepth(depth int) Option {
 	return func(p *VtdParser) {
 		if depth == 3 {
@@ -103,39 +183,120 @@ func WithLcDepth(depth int) Option {
 // options provided, error otherwise
 func NewVtdParser(opts ...Option) (*VtdParser, error) {
 	g := &VtdParser{
-		xmlChar:               common.NewXmlChar(),
-		singleByteEncoding:    true,
-		shallowDepth:          true,
-		depth:                 DefaultDepth,
-		lcDepth:               DefaultLcDepth,
-		bufferReuse:           DefaultBufferReuse,
-		increment:             DefaultIncrement,
-		tagStack:              make([]int64, DefaultTagArraySize, DefaultTagArraySize),
-		attrNameSlice:         make([]int, DefaultAttrArraySize, DefaultAttrArraySize),
-		prefixedAttrNameSlice: make([]int64, DefaultAttrArraySize, DefaultAttrArraySize),
-		prefixUrlSlice:        make([]int, DefaultAttrArraySize, DefaultAttrArraySize),
+		xmlChar:                common.NewXmlChar(),
+		singleByteEncoding:     true,
+		shallowDepth:           true,
+		depth:                  DefaultDepth,
+		lcDepth:                DefaultLcDepth,
+		bufferReuse:            DefaultBufferReuse,
+		checkInterval:          DefaultCheckInterval,
+		minChunkSize:           DefaultMinChunkSize,
+		increment:              DefaultIncrement,
+		encoding:               DefaultEncoding,
+		tagStack:               make([]int64, DefaultTagArraySize, DefaultTagArraySize),
+		preserveSpace:          make([]bool, DefaultTagArraySize, DefaultTagArraySize),
+		attrNameSlice:          make([]int64, DefaultAttrArraySize, DefaultAttrArraySize),
+		prefixedAttrNameSlice:  make([]int64, DefaultAttrArraySize, DefaultAttrArraySize),
+		prefixUrlSlice:         make([]int, DefaultAttrArraySize, DefaultAttrArraySize),
+		prefixedAttrIndexSlice: make([]int, DefaultAttrArraySize, DefaultAttrArraySize),
 	}
 
 	for _, opt := range opts {
 		opt(g)
 	}
 
-	if g.xmlDoc == nil {
-		return nil, erroring.NewInvalidArgumentError("xmlDoc", erroring.CannotBeNil, nil)
-	}
-	if len(g.xmlDoc) == 0 {
-		return nil, erroring.NewInvalidArgumentError("xmlDoc", "document cannot be empty", nil)
-	}
-	if g.offset < 0 {
-		return nil, erroring.NewInvalidArgumentError("offset", erroring.IndexOutOfRange, nil)
+	if err := g.openMappedFile(); err != nil {
+		return nil, err
 	}
-	if g.length == 0 || g.offset+g.length > len(g.xmlDoc) {
-		return nil, erroring.NewInvalidArgumentError("length", erroring.InvalidSliceLength, nil)
+	if err := g.validate(); err != nil {
+		_ = g.Close()
+		return nil, err
 	}
 	// perform additional initialization
 	if err := g.init(); err != nil {
+		_ = g.Close()
 		return nil, err
 	}
 
 	return g, nil
 }
+
+// Reset function applies options provided and prepares the parser for parsing
+// a new document, e.g. Reset(WithXmlDoc(doc)). Parser buffers are reused the
+// same way Clear reuses them.
+func (p *VtdParser) Reset(opts ...Option) error {
+	for _, opt := range opts {
+		opt(p)
+	}
+	if err := p.openMappedFile(); err != nil {
+		return err
+	}
+	if err := p.validate(); err != nil {
+		return err
+	}
+	return p.Clear()
+}
+
+// GetDtd function returns document type declaration of the parsed document,
+// or nil if the document does not have one
+func (p *VtdParser) GetDtd() *dtd.Dtd {
+	return p.dtd
+}
+
+// validate function checks the document and its boundaries set by options
+func (p *VtdParser) validate() error {
+	if p.xmlDoc == nil {
+		return erroring.NewInvalidArgumentError("xmlDoc", erroring.CannotBeNil, nil)
+	}
+	if len(p.xmlDoc) == 0 {
+		return erroring.NewInvalidArgumentError("xmlDoc", "document cannot be empty", nil)
+	}
+	if p.offset < 0 {
+		return erroring.NewInvalidArgumentError("offset", erroring.IndexOutOfRange, nil)
+	}
+	if p.length == 0 || p.offset+p.length > len(p.xmlDoc) {
+		return erroring.NewInvalidArgumentError("length", erroring.InvalidSliceLength, nil)
+	}
+	if p.checkInterval <= 0 {
+		return erroring.NewInvalidArgumentError("checkInterval", "must be positive", nil)
+	}
+	if p.minChunkSize <= 0 {
+		return erroring.NewInvalidArgumentError("minChunkSize", "must be positive", nil)
+	}
+	if p.maxErrors < 0 {
+		return erroring.NewInvalidArgumentError("maxErrors", erroring.CannotBeNegative, nil)
+	}
+	if err := p.limits.validate(); err != nil {
+		return err
+	}
+	if err := p.whitespacePolicy.validate(); err != nil {
+		return err
+	}
+	maxSize, err := p.maxDocumentSize()
+	if err != nil {
+		return err
+	}
+	if p.length > maxSize {
+		return erroring.NewInvalidArgumentError("xmlDoc", erroring.DocumentTooLarge, nil)
+	}
+	return nil
+}
+
+// maxDocumentSize function returns the largest document size accepted with
+// current options
+func (p *VtdParser) maxDocumentSize() (int, error) {
+	limit := int64(MaxDocumentSize)
+	if p.nsAware {
+		limit = MaxNsAwareDocumentSize
+	}
+	if maxInt := int64(^uint(0) >> 1); limit > maxInt {
+		limit = maxInt
+	}
+	if p.maxDocSize < 0 || int64(p.maxDocSize) > limit {
+		return 0, erroring.NewInvalidArgumentError("maxDocumentSize", erroring.IndexOutOfRange, nil)
+	}
+	if p.maxDocSize > 0 {
+		return p.maxDocSize, nil
+	}
+	return int(limit), nil
+}
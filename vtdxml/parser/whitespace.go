@@ -0,0 +1,118 @@
+package parser
+
+import (
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/common"
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/erroring"
+)
+
+// WhitespacePolicy decides whether whitespace-only text between markup, e.g.
+// indentation, becomes character data tokens. Text holding anything besides
+// whitespace is always recorded.
+type WhitespacePolicy int
+
+const (
+	// WhitespaceDrop does not record whitespace-only text, which keeps the
+	// index small for query-only use
+	WhitespaceDrop WhitespacePolicy = iota
+	// WhitespaceKeep records all whitespace-only text, so that the document
+	// can be written back from its tokens
+	WhitespaceKeep
+	// WhitespacePreserve records whitespace-only text only inside elements
+	// in the scope of xml:space="preserve"
+	WhitespacePreserve
+)
+
+const (
+	xmlSpace         = "xml:space"
+	xmlSpacePreserve = "preserve"
+	xmlSpaceDefault  = "default"
+)
+
+// WithWhitespacePolicy option sets whether whitespace-only text is recorded,
+// whitespace is dropped by default
+func WithWhitespacePolicy(policy WhitespacePolicy) Option {
+	return func(p *VtdParser) {
+		p.whitespacePolicy = policy
+		p.ws = policy != WhitespaceDrop
+	}
+}
+
+// validate function checks the policy is one of the known policies
+func (w WhitespacePolicy) validate() error {
+	if w < WhitespaceDrop || w > WhitespacePreserve {
+		return erroring.NewInvalidArgumentError("whitespacePolicy", erroring.IndexOutOfRange, nil)
+	}
+	return nil
+}
+
+// enterSpaceScope function makes the element being started inherit xml:space
+// of its parent
+func (p *VtdParser) enterSpaceScope() {
+	if p.whitespacePolicy == WhitespacePreserve {
+		p.preserveSpace[p.depth] = p.depth > 0 && p.preserveSpace[p.depth-1]
+	}
+}
+
+// checkSpaceAttrName function remembers whether the attribute whose name was
+// just read is xml:space
+func (p *VtdParser) checkSpaceAttrName() {
+	p.spaceAttr = p.whitespacePolicy == WhitespacePreserve &&
+		p.matchAscii(p.lastOffset, p.length1, xmlSpace)
+}
+
+// checkSpaceAttrVal function applies value of the xml:space attribute just
+// read to the current element. Values other than preserve and default leave
+// the inherited scope unchanged.
+func (p *VtdParser) checkSpaceAttrVal() {
+	if !p.spaceAttr {
+		return
+	}
+	p.spaceAttr = false
+	if p.matchAscii(p.lastOffset, p.length1, xmlSpacePreserve) {
+		p.preserveSpace[p.depth] = true
+	} else if p.matchAscii(p.lastOffset, p.length1, xmlSpaceDefault) {
+		p.preserveSpace[p.depth] = false
+	}
+}
+
+// keepWhiteSpace function returns true if whitespace-only text of the current
+// element is recorded
+func (p *VtdParser) keepWhiteSpace() bool {
+	switch p.whitespacePolicy {
+	case WhitespaceKeep:
+		return true
+	case WhitespacePreserve:
+		return p.preserveSpace[p.depth]
+	}
+	return false
+}
+
+// matchAscii function returns true if document bytes starting at offset and
+// spanning length bytes hold ASCII string s
+func (p *VtdParser) matchAscii(offset, length int, s string) bool {
+	size := 1
+	if !p.singleByteEncoding {
+		size = 2
+	}
+	if length != len(s)*size {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		o := offset + i*size
+		switch p.encoding {
+		case common.FormatUtf16BE:
+			if p.xmlDoc[o] != 0 || p.xmlDoc[o+1] != s[i] {
+				return false
+			}
+		case common.FormatUtf16LE:
+			if p.xmlDoc[o] != s[i] || p.xmlDoc[o+1] != 0 {
+				return false
+			}
+		default:
+			if p.xmlDoc[o] != s[i] {
+				return false
+			}
+		}
+	}
+	return true
+}
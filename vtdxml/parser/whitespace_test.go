@@ -0,0 +1,107 @@
+package parser
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/common"
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/erroring"
+	"github.com/stretchr/testify/assert"
+)
+
+const whitespaceXml = "<r>\n" +
+	" <a xml:space=\"preserve\">\n" +
+	"  <b/>\n" +
+	"  <c xml:space=\"default\">\n" +
+	"   <d/>\n" +
+	"  </c>\n" +
+	"  <e>\n" +
+	"   <f/>\n" +
+	"  </e>\n" +
+	" </a>\n" +
+	" <g> </g>\n" +
+	"</r>"
+
+// textTokens function returns character data tokens of the document as
+// "depth text" lines
+func textTokens(t *testing.T, doc []byte, opts ...Option) []string {
+	nav := parseTestNav(t, doc, opts...)
+	var texts []string
+	for i := 0; i < nav.GetVtdBufferSize(); i++ {
+		tokenType, err := nav.GetTokenType(i)
+		assert.Nil(t, err)
+		if common.Token(tokenType) != common.TokenCharacterData {
+			continue
+		}
+		depth, err := nav.GetTokenDepth(i)
+		assert.Nil(t, err)
+		text, err := nav.ToRawStringAtIndex(i)
+		assert.Nil(t, err)
+		texts = append(texts, fmt.Sprintf("%d %q", depth, text))
+	}
+	return texts
+}
+
+func Test_VtdParser_WithWhitespacePolicy_Success(t *testing.T) {
+	testCases := []struct {
+		name     string
+		policy   WhitespacePolicy
+		expected []string
+	}{
+		{
+			name:   "Drop",
+			policy: WhitespaceDrop,
+			// text of an element without other content is kept regardless
+			expected: []string{`1 " "`},
+		},
+		{
+			name:   "Keep",
+			policy: WhitespaceKeep,
+			expected: []string{
+				`0 "\n "`,
+				`1 "\n  "`, `1 "\n  "`,
+				`2 "\n   "`, `2 "\n  "`,
+				`1 "\n  "`,
+				`2 "\n   "`, `2 "\n  "`,
+				`1 "\n "`,
+				`0 "\n "`,
+				`1 " "`,
+				`0 "\n"`,
+			},
+		},
+		{
+			name:   "Preserve",
+			policy: WhitespacePreserve,
+			expected: []string{
+				`1 "\n  "`, `1 "\n  "`,
+				`1 "\n  "`,
+				`2 "\n   "`, `2 "\n  "`,
+				`1 "\n "`,
+				`1 " "`,
+			},
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, textTokens(t, []byte(whitespaceXml), WithWhitespacePolicy(tc.policy)))
+			assert.Equal(t, tc.expected, textTokens(t, encodeUtf16(whitespaceXml, false, true),
+				WithWhitespacePolicy(tc.policy), WithNameSpaceAware(true)))
+		})
+	}
+}
+
+func Test_VtdParser_WithWhitespacePolicy_Default(t *testing.T) {
+	assert.Equal(t, textTokens(t, []byte(whitespaceXml), WithWhitespacePolicy(WhitespaceDrop)),
+		textTokens(t, []byte(whitespaceXml)))
+}
+
+func Test_VtdParser_WithWhitespacePolicy_InvalidArgument(t *testing.T) {
+	for _, policy := range []WhitespacePolicy{-1, WhitespacePreserve + 1} {
+		parser, err := NewVtdParser(WithXmlDoc([]byte("<a/>")), WithWhitespacePolicy(policy))
+		assert.Nil(t, parser)
+		if assert.EqualError(t, err, "invalid argument whitespacePolicy: array index out of range") {
+			assert.IsType(t, erroring.InvalidArgumentErrorType, err)
+		}
+	}
+}
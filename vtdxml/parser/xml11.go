@@ -0,0 +1,36 @@
+package parser
+
+import (
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/common"
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/reader"
+)
+
+// startXml11 function switches the parser to XML 1.1 once the XML declaration
+// of an XML 1.1 document is read. UTF-8 documents are read by characters from
+// then on rather than by bytes, so that NEL and LSEP are recognized.
+func (p *VtdParser) startXml11() error {
+	p.setXml11(true)
+	if p.encoding != common.FormatUtf8 {
+		return nil
+	}
+	r, err := reader.NewDecodingUtf8Reader(p.xmlDoc, p.offset, p.endOffset)
+	if err != nil {
+		return err
+	}
+	p.reader = r
+	return nil
+}
+
+// setXml11 function switches the parser to character tables of the XML
+// version the document declares. XML 1.1 tables are created by the first
+// XML 1.1 document and kept for the documents parsed after it.
+func (p *VtdParser) setXml11(xml11 bool) {
+	if p.xml11 == xml11 {
+		return
+	}
+	p.xml11 = xml11
+	if p.spareXmlChar == nil {
+		p.spareXmlChar = common.NewXmlChar11()
+	}
+	p.xmlChar, p.spareXmlChar = p.spareXmlChar, p.xmlChar
+}
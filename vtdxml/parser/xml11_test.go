@@ -0,0 +1,144 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/erroring"
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/navigation"
+	"github.com/stretchr/testify/assert"
+)
+
+const xml11Doc = "<?xml version=\"1.1\"?>\u0085<a\u0085x=\"&#x1;\"\u2028y=\"2\">&#x7;&#x85;one\u0085two\r\u0085three<b/></a>"
+
+// resolvedTexts function returns attribute values and text of the document
+// with references resolved and line ends normalized
+func resolvedTexts(t *testing.T, nav *navigation.VtdNav) []string {
+	var texts []string
+	for _, index := range []int{5, 7, 8} {
+		text, err := nav.ToStringAtIndex(index)
+		assert.Nil(t, err)
+		texts = append(texts, text)
+	}
+	return texts
+}
+
+func Test_VtdParser_Parse_Xml11_Success(t *testing.T) {
+	testCases := []struct {
+		name string
+		doc  []byte
+	}{
+		{
+			name: "UTF-8",
+			doc:  []byte(xml11Doc),
+		},
+		{
+			name: "UTF-16BE",
+			doc:  encodeUtf16(xml11Doc, true, true),
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			nav := parseTestNav(t, tc.doc)
+			assert.True(t, nav.IsXml11())
+			assert.Equal(t, []string{"\x01", "2", "\x07\u0085one\ntwo\nthree"}, resolvedTexts(t, nav))
+			assertMove(t, nav, navigation.FirstChild, "b")
+
+			line, column, err := nav.GetTokenPosition(9)
+			assert.Nil(t, err)
+			assert.Equal(t, 6, line)
+			assert.Equal(t, 7, column)
+
+			var index bytes.Buffer
+			assert.Nil(t, nav.WriteIndex(&index))
+			loaded, err := navigation.LoadIndex(tc.doc, &index)
+			assert.Nil(t, err)
+			assert.True(t, loaded.IsXml11())
+			assert.Equal(t, resolvedTexts(t, nav), resolvedTexts(t, loaded))
+		})
+	}
+}
+
+func Test_VtdParser_Parse_Xml11_Names(t *testing.T) {
+	doc := []byte("<?xml version=\"1.1\"?><é\u0085ä=\"1\"\u2028b=\"2\"><?pï\u0085v?><c\u0085/></é>")
+	nav := parseTestNav(t, doc)
+	var names []string
+	for _, index := range []int{3, 4, 6, 8, 9, 10} {
+		name, err := nav.ToRawStringAtIndex(index)
+		assert.Nil(t, err)
+		names = append(names, name)
+	}
+	assert.Equal(t, []string{"é", "ä", "b", "pï", "v", "c"}, names)
+}
+
+func Test_VtdParser_Parse_Xml10_Unchanged(t *testing.T) {
+	nav := parseTestNav(t, []byte("<?xml version=\"1.0\"?><a x=\"&#x1;\">\x01\u0085</a>"))
+	assert.False(t, nav.IsXml11())
+	text, err := nav.ToStringAtIndex(6)
+	assert.Nil(t, err)
+	assert.Equal(t, "\x01\u0085", text)
+
+	parser, err := NewVtdParser(WithXmlDoc([]byte("<?xml version=\"1.0\"?><a\u0085x=\"1\"/>")))
+	assert.Nil(t, err)
+	assert.NotNil(t, parser.Parse())
+}
+
+func Test_VtdParser_Parse_Xml11_Failed(t *testing.T) {
+	testCases := []struct {
+		name         string
+		doc          string
+		expectedLine int
+	}{
+		{
+			name:         "restricted character in text",
+			doc:          "<?xml version=\"1.1\"?>\n<a>\u2028\x01</a>",
+			expectedLine: 3,
+		},
+		{
+			name:         "restricted character in attribute value",
+			doc:          "<?xml version=\"1.1\"?><a\u0085x=\"\x7F\"/>",
+			expectedLine: 2,
+		},
+		{
+			name:         "restricted character in comment",
+			doc:          "<?xml version=\"1.1\"?><a/>\u0085<!--\u0086-->",
+			expectedLine: 2,
+		},
+		{
+			name:         "NEL in XML declaration",
+			doc:          "<?xml version=\"1.1\"\u0085?><a/>",
+			expectedLine: 1,
+		},
+		{
+			name:         "reference to NUL",
+			doc:          "<?xml version=\"1.1\"?><a>&#0;</a>",
+			expectedLine: 1,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			parser, err := NewVtdParser(WithXmlDoc([]byte(tc.doc)))
+			assert.Nil(t, err)
+			err = parser.Parse()
+			var pErr *erroring.ParseError
+			if assert.NotNil(t, err) && errors.As(err, &pErr) {
+				assert.Equal(t, tc.expectedLine, pErr.Line)
+			}
+		})
+	}
+}
+
+func Test_VtdParser_Parse_Xml11_BufferReuse(t *testing.T) {
+	parser, err := NewVtdParser(WithXmlDoc([]byte(xml11Doc)), WithBufferReuse(true))
+	assert.Nil(t, err)
+	assert.Nil(t, parser.Parse())
+
+	// XML 1.0 tables are restored for the next document
+	assert.Nil(t, parser.Reset(WithXmlDoc([]byte("<a\u0085x=\"1\"/>"))))
+	assert.NotNil(t, parser.Parse())
+	assert.Nil(t, parser.Reset(WithXmlDoc([]byte(xml11Doc))))
+	assert.Nil(t, parser.Parse())
+}
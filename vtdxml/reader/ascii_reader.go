@@ -19,7 +19,7 @@ func NewAsciiReader(xmlDoc []byte, offset, endOffset int) (*AsciiReader, error)
 	if offset < 0 {
 		return nil, erroring.NewInvalidArgumentError("offset", erroring.IndexOutOfRange, nil)
 	}
-	if endOffset < 0 || endOffset >= len(xmlDoc) {
+	if endOffset < 0 || endOffset > len(xmlDoc) {
 		return nil, erroring.NewInvalidArgumentError("endOffset", erroring.IndexOutOfRange, nil)
 	}
 	return &AsciiReader{
@@ -41,7 +41,7 @@ func (r *AsciiReader) GetChar() (uint32, error) {
 	return uint32(ch), nil
 }
 
-func (r *AsciiReader) GetLongCharAt(offset int32) (uint64, error) {
+func (r *AsciiReader) GetLongCharAt(offset int) (uint64, error) {
 	ch := r.xmlDoc[offset]
 	if ch == byte('\r') && r.xmlDoc[offset+1] == byte('\n') {
 		return (2 << 32) | '\n', nil
@@ -67,7 +67,7 @@ func (r *AsciiReader) SkipCharSeq(seq string) bool {
 	return true
 }
 
-func (r *AsciiReader) GetCharAt(offset int32) (uint32, error) {
+func (r *AsciiReader) GetCharAt(offset int) (uint32, error) {
 	return uint32(r.xmlDoc[offset]), nil
 }
 
@@ -0,0 +1,218 @@
+package reader
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/common"
+)
+
+const byteOrderMark = 0xFEFF
+
+// LineIndex maps byte offsets of a document to line and column numbers. Line
+// starts are indexed once, so lookups cost a binary search plus a scan of
+// the line up to the offset.
+type LineIndex struct {
+	xmlDoc     []byte
+	start, end int
+	encoding   common.FormatEncoding
+	xml11      bool
+	lineStarts []int
+}
+
+// NewLineIndex function indexes line starts of the document region
+// [start, end). Both LF and a CR not followed by LF end a line. Byte order
+// mark does not occupy a column of the first line.
+func NewLineIndex(xmlDoc []byte, start, end int, encoding common.FormatEncoding) *LineIndex {
+	return newLineIndex(xmlDoc, start, end, encoding, false)
+}
+
+// NewXml11LineIndex function indexes line starts of the XML 1.1 document
+// region [start, end). Besides line ends of XML 1.0, NEL, LSEP and a CR
+// followed by NEL end a line.
+func NewXml11LineIndex(xmlDoc []byte, start, end int, encoding common.FormatEncoding) *LineIndex {
+	return newLineIndex(xmlDoc, start, end, encoding, true)
+}
+
+func newLineIndex(xmlDoc []byte, start, end int, encoding common.FormatEncoding, xml11 bool) *LineIndex {
+	li := &LineIndex{
+		xmlDoc:   xmlDoc,
+		start:    start,
+		end:      end,
+		encoding: encoding,
+		xml11:    xml11,
+	}
+	if start < end {
+		if ch, size := li.decode(start); ch == byteOrderMark {
+			start += size
+		}
+	}
+	li.lineStarts = []int{start}
+	for os := start; os < end; {
+		ch, size := li.decode(os)
+		next := os + size
+		if li.endsLine(ch, next) {
+			li.lineStarts = append(li.lineStarts, next)
+		}
+		os = next
+	}
+	return li
+}
+
+// endsLine function returns true if the character followed by the byte
+// offset given ends a line. CR ends a line unless the line end continues.
+func (li *LineIndex) endsLine(ch rune, next int) bool {
+	switch {
+	case ch == '\n':
+		return true
+	case ch == '\r':
+		if next >= li.end {
+			return true
+		}
+		following, _ := li.decode(next)
+		return following != '\n' && !(li.xml11 && following == 0x85)
+	default:
+		return li.xml11 && (ch == 0x85 || ch == 0x2028)
+	}
+}
+
+// GetEncoding function returns the encoding the index was built for
+func (li *LineIndex) GetEncoding() common.FormatEncoding {
+	return li.encoding
+}
+
+// IsXml11 function returns true if the index was built for an XML 1.1
+// document
+func (li *LineIndex) IsXml11() bool {
+	return li.xml11
+}
+
+// Position function returns 1-based line and column numbers of the byte
+// offset. Columns count characters, so multi-byte characters and surrogate
+// pairs occupy a single column.
+func (li *LineIndex) Position(offset int) (int, int) {
+	line := li.line(offset)
+	column := 1
+	for os := li.lineStarts[line]; os < offset && os < li.end; {
+		_, size := li.decode(os)
+		os += size
+		column++
+	}
+	return line + 1, column
+}
+
+// Snippet function returns up to width characters on each side of the byte
+// offset taken from the line containing it
+func (li *LineIndex) Snippet(offset, width int) string {
+	line := li.line(offset)
+	lineStart, lineEnd := li.lineStarts[line], li.end
+	if line+1 < len(li.lineStarts) {
+		lineEnd = li.lineStarts[line+1]
+	}
+
+	from := offset
+	if from > lineEnd {
+		from = lineEnd
+	} else if from < lineStart {
+		from = lineStart
+	}
+	for n := 0; n < width && from > lineStart; n++ {
+		from = li.prev(from, lineStart)
+	}
+
+	var sb strings.Builder
+	for os, n := from, 0; os < lineEnd && (os < offset || n < width); {
+		ch, size := li.decode(os)
+		if ch != '\r' && !li.endsLine(ch, os+size) {
+			sb.WriteRune(ch)
+		}
+		if os >= offset {
+			n++
+		}
+		os += size
+	}
+	return sb.String()
+}
+
+// line function returns 0-based line of the byte offset
+func (li *LineIndex) line(offset int) int {
+	line := sort.Search(len(li.lineStarts), func(i int) bool {
+		return li.lineStarts[i] > offset
+	}) - 1
+	if line < 0 {
+		return 0
+	}
+	return line
+}
+
+// prev function returns byte offset of the character preceding the offset,
+// not going below the lower bound
+func (li *LineIndex) prev(offset, lower int) int {
+	switch li.encoding {
+	case common.FormatAscii, common.FormatUtf8:
+		offset--
+		for offset > lower && li.xmlDoc[offset]&0xC0 == 0x80 {
+			offset--
+		}
+	case common.FormatUtf16BE, common.FormatUtf16LE:
+		offset -= 2
+		if offset-2 >= lower {
+			unit, high := li.unitAt(offset), li.unitAt(offset-2)
+			if unit >= 0xDC00 && unit <= 0xDFFF && high >= 0xD800 && high <= 0xDBFF {
+				offset -= 2
+			}
+		}
+	default:
+		offset--
+	}
+	if offset < lower {
+		return lower
+	}
+	return offset
+}
+
+// unitAt function returns the code unit at the byte offset
+func (li *LineIndex) unitAt(offset int) rune {
+	switch li.encoding {
+	case common.FormatUtf16BE:
+		if offset+1 >= li.end {
+			return utf8.RuneError
+		}
+		return rune(li.xmlDoc[offset])<<8 | rune(li.xmlDoc[offset+1])
+	case common.FormatUtf16LE:
+		if offset+1 >= li.end {
+			return utf8.RuneError
+		}
+		return rune(li.xmlDoc[offset+1])<<8 | rune(li.xmlDoc[offset])
+	default:
+		return rune(li.xmlDoc[offset])
+	}
+}
+
+// decode function returns the character at the byte offset and its size in
+// bytes. Undecodable bytes are returned as utf8.RuneError of size one unit.
+func (li *LineIndex) decode(offset int) (rune, int) {
+	switch li.encoding {
+	case common.FormatAscii, common.FormatUtf8:
+		return utf8.DecodeRune(li.xmlDoc[offset:li.end])
+	case common.FormatUtf16BE, common.FormatUtf16LE:
+		unit := li.unitAt(offset)
+		if unit >= 0xD800 && unit <= 0xDBFF && offset+3 < li.end {
+			low := li.unitAt(offset + 2)
+			if low >= 0xDC00 && low <= 0xDFFF {
+				return ((unit-0xD800)<<10 | (low - 0xDC00)) + 0x10000, 4
+			}
+		}
+		if offset+1 >= li.end {
+			return utf8.RuneError, 1
+		}
+		return unit, 2
+	default:
+		ch, err := DecodeSingleByte(li.encoding, li.xmlDoc[offset])
+		if err != nil {
+			return utf8.RuneError, 1
+		}
+		return rune(ch), 1
+	}
+}
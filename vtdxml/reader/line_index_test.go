@@ -0,0 +1,194 @@
+package reader
+
+import (
+	"testing"
+	utf16enc "unicode/utf16"
+
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/common"
+	"github.com/stretchr/testify/assert"
+)
+
+const lineIndexXml = "<a>\r\n  <b>é😀x</b>\r<c/>\n"
+
+func Test_LineIndex_Position(t *testing.T) {
+	testCases := []struct {
+		name           string
+		docBytes       []byte
+		encoding       common.FormatEncoding
+		offset         int
+		expectedLine   int
+		expectedColumn int
+	}{
+		{
+			name:           "document start",
+			docBytes:       []byte(lineIndexXml),
+			encoding:       common.FormatUtf8,
+			expectedLine:   1,
+			expectedColumn: 1,
+		},
+		{
+			name:           "after multi-byte characters",
+			docBytes:       []byte(lineIndexXml),
+			encoding:       common.FormatUtf8,
+			offset:         16,
+			expectedLine:   2,
+			expectedColumn: 8,
+		},
+		{
+			name:           "carriage return ending the line",
+			docBytes:       []byte(lineIndexXml),
+			encoding:       common.FormatUtf8,
+			offset:         21,
+			expectedLine:   2,
+			expectedColumn: 13,
+		},
+		{
+			name:           "line ended by carriage return",
+			docBytes:       []byte(lineIndexXml),
+			encoding:       common.FormatUtf8,
+			offset:         22,
+			expectedLine:   3,
+			expectedColumn: 1,
+		},
+		{
+			name:           "UTF-16LE surrogate pair",
+			docBytes:       encodeUtf16("a\n😀z", false),
+			encoding:       common.FormatUtf16LE,
+			offset:         8,
+			expectedLine:   2,
+			expectedColumn: 2,
+		},
+		{
+			name:           "UTF-16BE",
+			docBytes:       encodeUtf16("a\nbc", true),
+			encoding:       common.FormatUtf16BE,
+			offset:         6,
+			expectedLine:   2,
+			expectedColumn: 2,
+		},
+		{
+			name:           "ISO-8859-1",
+			docBytes:       []byte("\xE9\n\xE9\xE9x"),
+			encoding:       common.FormatIso88591,
+			offset:         4,
+			expectedLine:   2,
+			expectedColumn: 3,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			li := NewLineIndex(tc.docBytes, 0, len(tc.docBytes), tc.encoding)
+			line, column := li.Position(tc.offset)
+			assert.Equal(t, tc.expectedLine, line)
+			assert.Equal(t, tc.expectedColumn, column)
+		})
+	}
+}
+
+func Test_LineIndex_Snippet(t *testing.T) {
+	testCases := []struct {
+		name            string
+		docBytes        []byte
+		encoding        common.FormatEncoding
+		offset, width   int
+		expectedSnippet string
+	}{
+		{
+			name:            "characters on both sides",
+			docBytes:        []byte(lineIndexXml),
+			encoding:        common.FormatUtf8,
+			offset:          16,
+			width:           2,
+			expectedSnippet: "é😀x<",
+		},
+		{
+			name:            "limited to the line",
+			docBytes:        []byte(lineIndexXml),
+			encoding:        common.FormatUtf8,
+			offset:          22,
+			width:           20,
+			expectedSnippet: "<c/>",
+		},
+		{
+			name:            "UTF-16LE surrogate pair",
+			docBytes:        encodeUtf16("a\n😀z", false),
+			encoding:        common.FormatUtf16LE,
+			offset:          8,
+			width:           1,
+			expectedSnippet: "😀z",
+		},
+		{
+			name:            "ISO-8859-1",
+			docBytes:        []byte("\xE9\n\xE9\xE9x"),
+			encoding:        common.FormatIso88591,
+			offset:          3,
+			width:           5,
+			expectedSnippet: "ééx",
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			li := NewLineIndex(tc.docBytes, 0, len(tc.docBytes), tc.encoding)
+			assert.Equal(t, tc.expectedSnippet, li.Snippet(tc.offset, tc.width))
+		})
+	}
+}
+
+func Test_LineIndex_Xml11(t *testing.T) {
+	doc := "<a>\u0085<b/>\r\u0085<c/>\u2028<d/>"
+	testCases := []struct {
+		name          string
+		docBytes      []byte
+		encoding      common.FormatEncoding
+		offsets       []int
+		expectedLines []int
+	}{
+		{
+			name:          "UTF-8",
+			docBytes:      []byte(doc),
+			encoding:      common.FormatUtf8,
+			offsets:       []int{0, 5, 12, 19},
+			expectedLines: []int{1, 2, 3, 4},
+		},
+		{
+			name:          "UTF-16BE",
+			docBytes:      encodeUtf16(doc, true),
+			encoding:      common.FormatUtf16BE,
+			offsets:       []int{0, 8, 20, 30},
+			expectedLines: []int{1, 2, 3, 4},
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			li := NewXml11LineIndex(tc.docBytes, 0, len(tc.docBytes), tc.encoding)
+			assert.True(t, li.IsXml11())
+			for i, offset := range tc.offsets {
+				line, column := li.Position(offset)
+				assert.Equal(t, tc.expectedLines[i], line)
+				assert.Equal(t, 1, column)
+			}
+			assert.Equal(t, "<b/>", li.Snippet(tc.offsets[1], 10))
+
+			li = NewLineIndex(tc.docBytes, 0, len(tc.docBytes), tc.encoding)
+			assert.False(t, li.IsXml11())
+			line, _ := li.Position(tc.offsets[3])
+			assert.Equal(t, 2, line)
+		})
+	}
+}
+
+func encodeUtf16(s string, bigEndian bool) []byte {
+	units := utf16enc.Encode([]rune(s))
+	doc := make([]byte, 0, len(units)*2)
+	for _, u := range units {
+		if bigEndian {
+			doc = append(doc, byte(u>>8), byte(u))
+		} else {
+			doc = append(doc, byte(u), byte(u>>8))
+		}
+	}
+	return doc
+}
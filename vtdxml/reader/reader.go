@@ -2,10 +2,10 @@ package reader
 
 type Reader interface {
 	GetChar() (uint32, error)
-	GetLongCharAt(offset int32) (uint64, error)
+	GetLongCharAt(offset int) (uint64, error)
 	SkipChar(ch uint32) bool
 	SkipCharSeq(seq string) bool
-	GetCharAt(offset int32) (uint32, error)
+	GetCharAt(offset int) (uint32, error)
 	GetOffset() int
 	SetOffset(offset int)
 }
@@ -0,0 +1,158 @@
+package reader
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/common"
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/erroring"
+)
+
+// undefinedChar marks code page bytes that have no Unicode mapping
+const undefinedChar = utf8.RuneError
+
+// codePage holds Unicode code points for bytes 0x80-0xFF of a single-byte
+// encoding
+type codePage [128]rune
+
+var codePages = map[common.FormatEncoding]*codePage{
+	common.FormatIso88591:  &iso88591Table,
+	common.FormatIso88592:  &iso88592Table,
+	common.FormatIso88593:  &iso88593Table,
+	common.FormatIso88594:  &iso88594Table,
+	common.FormatIso88595:  &iso88595Table,
+	common.FormatIso88596:  &iso88596Table,
+	common.FormatIso88597:  &iso88597Table,
+	common.FormatIso88598:  &iso88598Table,
+	common.FormatIso88599:  &iso88599Table,
+	common.FormatIso885910: &iso885910Table,
+	common.FormatIso885911: &iso885911Table,
+	common.FormatIso885913: &iso885913Table,
+	common.FormatIso885914: &iso885914Table,
+	common.FormatIso885915: &iso885915Table,
+	common.FormatIso885916: &iso885916Table,
+	common.FormatWin1250:   &win1250Table,
+	common.FormatWin1251:   &win1251Table,
+	common.FormatWin1252:   &win1252Table,
+	common.FormatWin1253:   &win1253Table,
+	common.FormatWin1254:   &win1254Table,
+	common.FormatWin1255:   &win1255Table,
+	common.FormatWin1256:   &win1256Table,
+	common.FormatWin1257:   &win1257Table,
+	common.FormatWin1258:   &win1258Table,
+}
+
+// DecodeSingleByte function returns Unicode code point of the byte in the
+// given single-byte encoding. ISO-8859-12 was never published, so it is not
+// supported.
+func DecodeSingleByte(encoding common.FormatEncoding, b byte) (uint32, error) {
+	table, ok := codePages[encoding]
+	if !ok {
+		return 0, erroring.NewEncodingError(fmt.Sprintf("unsupported single-byte encoding %s", encoding))
+	}
+	return decodeByte(table, b)
+}
+
+func decodeByte(table *codePage, b byte) (uint32, error) {
+	if b < utf8.RuneSelf {
+		return uint32(b), nil
+	}
+	ch := table[b-utf8.RuneSelf]
+	if ch == undefinedChar {
+		return 0, erroring.NewDecodingError(fmt.Sprintf("byte 0x%02X is not defined in code page", b))
+	}
+	return uint32(ch), nil
+}
+
+// SingleByteReader reads documents encoded with one of the ISO-8859-x or
+// Windows-125x code pages. Characters are returned as Unicode code points.
+type SingleByteReader struct {
+	xmlDoc    []byte
+	offset    int
+	endOffset int
+	table     *codePage
+}
+
+func NewSingleByteReader(xmlDoc []byte, offset, endOffset int, encoding common.FormatEncoding) (*SingleByteReader, error) {
+	if xmlDoc == nil {
+		return nil, erroring.NewInvalidArgumentError("xmlDoc", erroring.CannotBeNil, nil)
+	}
+	if offset < 0 {
+		return nil, erroring.NewInvalidArgumentError("offset", erroring.IndexOutOfRange, nil)
+	}
+	if endOffset < 0 || endOffset > len(xmlDoc) {
+		return nil, erroring.NewInvalidArgumentError("endOffset", erroring.IndexOutOfRange, nil)
+	}
+	table, ok := codePages[encoding]
+	if !ok {
+		return nil, erroring.NewEncodingError(fmt.Sprintf("unsupported single-byte encoding %s", encoding))
+	}
+	return &SingleByteReader{
+		xmlDoc:    xmlDoc,
+		offset:    offset,
+		endOffset: endOffset,
+		table:     table,
+	}, nil
+}
+
+func (r *SingleByteReader) GetChar() (uint32, error) {
+	if r.offset >= r.endOffset {
+		return 0, erroring.NewEOFError(erroring.XmlIncomplete)
+	}
+	ch, err := decodeByte(r.table, r.xmlDoc[r.offset])
+	if err != nil {
+		return 0, err
+	}
+	r.offset++
+	return ch, nil
+}
+
+func (r *SingleByteReader) GetLongCharAt(offset int) (uint64, error) {
+	if offset >= r.endOffset {
+		return 0, erroring.NewEOFError(erroring.XmlIncomplete)
+	}
+	b := r.xmlDoc[offset]
+	if b == '\r' && offset+1 < r.endOffset && r.xmlDoc[offset+1] == '\n' {
+		return (2 << 32) | '\n', nil
+	}
+	ch, err := decodeByte(r.table, b)
+	if err != nil {
+		return 0, err
+	}
+	return (1 << 32) | uint64(ch), nil
+}
+
+func (r *SingleByteReader) GetCharAt(offset int) (uint32, error) {
+	if offset >= r.endOffset {
+		return 0, erroring.NewEOFError(erroring.XmlIncomplete)
+	}
+	return decodeByte(r.table, r.xmlDoc[offset])
+}
+
+func (r *SingleByteReader) SkipChar(ch uint32) bool {
+	if r.offset >= r.endOffset {
+		return false
+	}
+	if c, err := decodeByte(r.table, r.xmlDoc[r.offset]); err == nil && c == ch {
+		r.offset++
+		return true
+	}
+	return false
+}
+
+func (r *SingleByteReader) SkipCharSeq(seq string) bool {
+	for _, ch := range seq {
+		if !r.SkipChar(uint32(ch)) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *SingleByteReader) GetOffset() int {
+	return r.offset
+}
+
+func (r *SingleByteReader) SetOffset(offset int) {
+	r.offset = offset
+}
@@ -0,0 +1,131 @@
+package reader
+
+import (
+	"testing"
+
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SingleByteReader_NewSingleByteReader_InvalidArg(t *testing.T) {
+	testCases := []struct {
+		name           string
+		docBytes       []byte
+		endOffset      int
+		encoding       common.FormatEncoding
+		expectedErrMsg string
+	}{
+		{
+			name:           "nil doc bytes",
+			encoding:       common.FormatWin1252,
+			expectedErrMsg: "invalid argument xmlDoc: cannot be nil",
+		},
+		{
+			name:           "invalid end offset bigger than doc length",
+			docBytes:       []byte(XML),
+			endOffset:      len(XML) + 1,
+			encoding:       common.FormatWin1252,
+			expectedErrMsg: "invalid argument endOffset: array index out of range",
+		},
+		{
+			name:           "multi-byte encoding",
+			docBytes:       []byte(XML),
+			endOffset:      len(XML),
+			encoding:       common.FormatUtf8,
+			expectedErrMsg: "unknown character encoding: unsupported single-byte encoding UTF-8",
+		},
+		{
+			name:           "ISO-8859-12",
+			docBytes:       []byte(XML),
+			endOffset:      len(XML),
+			encoding:       common.FormatIso885912,
+			expectedErrMsg: "unknown character encoding: unsupported single-byte encoding ISO-8859-12",
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := NewSingleByteReader(tc.docBytes, 0, tc.endOffset, tc.encoding)
+			assert.Nil(t, r)
+			assert.EqualError(t, err, tc.expectedErrMsg)
+		})
+	}
+}
+
+func Test_SingleByteReader_GetChar_Success(t *testing.T) {
+	doc := []byte("<a>\xA3\xF3d\xBC</a>\r\n")
+	r, err := NewSingleByteReader(doc, 0, len(doc), common.FormatIso88592)
+	assert.Nil(t, err)
+
+	var chars []rune
+	for i := 0; i < 7; i++ {
+		ch, err := r.GetChar()
+		assert.Nil(t, err)
+		chars = append(chars, rune(ch))
+	}
+	assert.Equal(t, "<a>Łódź", string(chars))
+	assert.Equal(t, 7, r.GetOffset())
+
+	assert.True(t, r.SkipCharSeq("</a>"))
+	ch, err := r.GetLongCharAt(r.GetOffset())
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2<<32|'\n'), ch)
+
+	r.SetOffset(3)
+	assert.False(t, r.SkipChar(0xA3))
+	assert.True(t, r.SkipChar('Ł'))
+	ch32, err := r.GetCharAt(6)
+	assert.Nil(t, err)
+	assert.Equal(t, uint32('ź'), ch32)
+}
+
+func Test_SingleByteReader_GetChar_Failed(t *testing.T) {
+	doc := []byte("a\x81")
+	r, err := NewSingleByteReader(doc, 0, len(doc), common.FormatWin1252)
+	assert.Nil(t, err)
+
+	_, err = r.GetChar()
+	assert.Nil(t, err)
+	_, err = r.GetChar()
+	assert.EqualError(t, err, "unknown character encoding: byte 0x81 is not defined in code page")
+	assert.False(t, r.SkipChar(0x81))
+
+	r.SetOffset(len(doc))
+	_, err = r.GetChar()
+	assert.EqualError(t, err, "premature EOF reached: XML document incomplete")
+}
+
+func Test_DecodeSingleByte_Success(t *testing.T) {
+	testCases := []struct {
+		encoding common.FormatEncoding
+		b        byte
+		expected rune
+	}{
+		{encoding: common.FormatIso88591, b: 0xE9, expected: 'é'},
+		{encoding: common.FormatIso88593, b: 0xA1, expected: 'Ħ'},
+		{encoding: common.FormatIso88594, b: 0xA2, expected: 'ĸ'},
+		{encoding: common.FormatIso88595, b: 0xB0, expected: 'А'},
+		{encoding: common.FormatIso88596, b: 0xC7, expected: 'ا'},
+		{encoding: common.FormatIso88598, b: 0xE0, expected: 'א'},
+		{encoding: common.FormatIso88599, b: 0xF0, expected: 'ğ'},
+		{encoding: common.FormatIso885910, b: 0xA1, expected: 'Ą'},
+		{encoding: common.FormatIso885911, b: 0xA1, expected: 'ก'},
+		{encoding: common.FormatIso885913, b: 0xA1, expected: '”'},
+		{encoding: common.FormatIso885914, b: 0xA1, expected: 'Ḃ'},
+		{encoding: common.FormatWin1250, b: 0x8A, expected: 'Š'},
+		{encoding: common.FormatWin1253, b: 0xC1, expected: 'Α'},
+		{encoding: common.FormatWin1254, b: 0xD0, expected: 'Ğ'},
+		{encoding: common.FormatWin1255, b: 0xE0, expected: 'א'},
+		{encoding: common.FormatWin1256, b: 0xC7, expected: 'ا'},
+		{encoding: common.FormatWin1257, b: 0xC0, expected: 'Ą'},
+		{encoding: common.FormatWin1252, b: 'x', expected: 'x'},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(string(tc.expected), func(t *testing.T) {
+			ch, err := DecodeSingleByte(tc.encoding, tc.b)
+			assert.Nil(t, err)
+			assert.Equal(t, uint32(tc.expected), ch)
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package reader
+
+import (
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/erroring"
+)
+
+type Utf16LeReader struct {
+	xmlDoc    []byte
+	offset    int
+	endOffset int
+}
+
+func NewUtf16LeReader(xmlDoc []byte, offset, endOffset int) (*Utf16LeReader, error) {
+	if xmlDoc == nil {
+		return nil, erroring.NewInvalidArgumentError("xmlDoc", erroring.CannotBeNil, nil)
+	}
+	if offset < 0 {
+		return nil, erroring.NewInvalidArgumentError("offset", erroring.IndexOutOfRange, nil)
+	}
+	if endOffset < 0 || endOffset > len(xmlDoc) {
+		return nil, erroring.NewInvalidArgumentError("endOffset", erroring.IndexOutOfRange, nil)
+	}
+	return &Utf16LeReader{
+		xmlDoc:    xmlDoc,
+		offset:    offset,
+		endOffset: endOffset,
+	}, nil
+}
+
+func (r *Utf16LeReader) GetChar() (uint32, error) {
+	ch, length, err := r.decode(r.offset)
+	if err != nil {
+		return 0, err
+	}
+	r.offset += length
+	return ch, nil
+}
+
+func (r *Utf16LeReader) GetLongCharAt(offset int) (uint64, error) {
+	ch, length, err := r.decode(offset)
+	if err != nil {
+		return 0, err
+	}
+	if ch == '\r' {
+		if next, _, err := r.decode(offset + 2); err == nil && next == '\n' {
+			return (4 << 32) | '\n', nil
+		}
+		return (2 << 32) | '\n', nil
+	}
+	return (uint64(length) << 32) | uint64(ch), nil
+}
+
+func (r *Utf16LeReader) GetCharAt(offset int) (uint32, error) {
+	ch, _, err := r.decode(offset)
+	return ch, err
+}
+
+func (r *Utf16LeReader) SkipChar(ch uint32) bool {
+	next, length, err := r.decode(r.offset)
+	if err != nil || next != ch {
+		return false
+	}
+	r.offset += length
+	return true
+}
+
+func (r *Utf16LeReader) SkipCharSeq(seq string) bool {
+	for _, ch := range seq {
+		if !r.SkipChar(uint32(ch)) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Utf16LeReader) GetOffset() int {
+	return r.offset
+}
+
+func (r *Utf16LeReader) SetOffset(offset int) {
+	r.offset = offset
+}
+
+// decode function decodes a little-endian code unit or surrogate pair at the
+// offset and returns the character with its length in bytes
+func (r *Utf16LeReader) decode(offset int) (uint32, int, error) {
+	if offset < 0 || offset+1 >= r.endOffset {
+		return 0, 0, erroring.NewEOFError(erroring.XmlIncomplete)
+	}
+	high := uint32(r.xmlDoc[offset+1])<<8 | uint32(r.xmlDoc[offset])
+	if high < 0xD800 || high > 0xDFFF {
+		return high, 2, nil
+	}
+	if high > 0xDBFF {
+		return 0, 0, erroring.NewDecodingError("invalid UTF-16LE high surrogate")
+	}
+	if offset+3 >= r.endOffset {
+		return 0, 0, erroring.NewEOFError(erroring.XmlIncomplete)
+	}
+	low := uint32(r.xmlDoc[offset+3])<<8 | uint32(r.xmlDoc[offset+2])
+	if low < 0xDC00 || low > 0xDFFF {
+		return 0, 0, erroring.NewDecodingError("invalid UTF-16LE low surrogate")
+	}
+	return ((high - 0xD800) << 10) + (low - 0xDC00) + 0x10000, 4, nil
+}
@@ -0,0 +1,72 @@
+package reader
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// "<a>é𝄞</a>\r\n" encoded as UTF-16LE
+var utf16LeDoc = []byte{
+	'<', 0x00, 'a', 0x00, '>', 0x00, 0xE9, 0x00, 0x34, 0xD8, 0x1E, 0xDD,
+	'<', 0x00, '/', 0x00, 'a', 0x00, '>', 0x00, '\r', 0x00, '\n', 0x00,
+}
+
+func Test_Utf16LeReader_NewUtf16LeReader_InvalidArg(t *testing.T) {
+	r, err := NewUtf16LeReader(nil, 0, 0)
+	assert.Nil(t, r)
+	assert.EqualError(t, err, "invalid argument xmlDoc: cannot be nil")
+
+	r, err = NewUtf16LeReader(utf16LeDoc, 0, len(utf16LeDoc)+1)
+	assert.Nil(t, r)
+	assert.EqualError(t, err, "invalid argument endOffset: array index out of range")
+}
+
+func Test_Utf16LeReader_GetChar_Success(t *testing.T) {
+	r, err := NewUtf16LeReader(utf16LeDoc, 0, len(utf16LeDoc))
+	assert.Nil(t, err)
+
+	var chars []uint32
+	for i := 0; i < 10; i++ {
+		ch, err := r.GetChar()
+		assert.Nil(t, err)
+		chars = append(chars, ch)
+	}
+	assert.Equal(t, []uint32{'<', 'a', '>', 0xE9, 0x1D11E, '<', '/', 'a', '>', '\r'}, chars)
+	assert.Equal(t, 22, r.GetOffset())
+}
+
+func Test_Utf16LeReader_GetChar_Failed(t *testing.T) {
+	r, err := NewUtf16LeReader(utf16LeDoc, len(utf16LeDoc), len(utf16LeDoc))
+	assert.Nil(t, err)
+	_, err = r.GetChar()
+	assert.EqualError(t, err, "premature EOF reached: XML document incomplete")
+
+	r, err = NewUtf16LeReader([]byte{0x1E, 0xDD, 'a', 0x00}, 0, 4)
+	assert.Nil(t, err)
+	_, err = r.GetChar()
+	assert.EqualError(t, err, "unknown character encoding: invalid UTF-16LE high surrogate")
+}
+
+func Test_Utf16LeReader_GetLongCharAt_Success(t *testing.T) {
+	r, err := NewUtf16LeReader(utf16LeDoc, 0, len(utf16LeDoc))
+	assert.Nil(t, err)
+
+	ch, err := r.GetLongCharAt(8)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(4<<32|0x1D11E), ch)
+
+	ch, err = r.GetLongCharAt(20)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(4<<32|'\n'), ch)
+}
+
+func Test_Utf16LeReader_SkipCharSeq_Success(t *testing.T) {
+	r, err := NewUtf16LeReader(utf16LeDoc, 0, len(utf16LeDoc))
+	assert.Nil(t, err)
+
+	assert.True(t, r.SkipCharSeq("<a>é𝄞"))
+	assert.False(t, r.SkipChar('>'))
+	assert.True(t, r.SkipChar('<'))
+	assert.Equal(t, 14, r.GetOffset())
+}
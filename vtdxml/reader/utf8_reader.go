@@ -2,6 +2,7 @@ package reader
 
 import (
 	"unicode"
+	"unicode/utf8"
 
 	"github.com/alexZaicev/go-vtd-xml/vtdxml/erroring"
 )
@@ -10,6 +11,7 @@ type Utf8Reader struct {
 	xmlDoc    []byte
 	offset    int
 	endOffset int
+	decode    bool
 }
 
 func NewUtf8Reader(xmlDoc []byte, offset, endOffset int) (*Utf8Reader, error) {
@@ -29,11 +31,37 @@ func NewUtf8Reader(xmlDoc []byte, offset, endOffset int) (*Utf8Reader, error) {
 	}, nil
 }
 
+// NewDecodingUtf8Reader function creates UTF-8 reader returning characters
+// of multi-byte sequences rather than their bytes
+func NewDecodingUtf8Reader(xmlDoc []byte, offset, endOffset int) (*Utf8Reader, error) {
+	r, err := NewUtf8Reader(xmlDoc, offset, endOffset)
+	if err != nil {
+		return nil, err
+	}
+	r.decode = true
+	return r, nil
+}
+
+// SetDecoding function switches the reader between returning characters of
+// multi-byte sequences and returning their bytes
+func (r *Utf8Reader) SetDecoding(decode bool) {
+	r.decode = decode
+}
+
+// IsDecoding function returns true if the reader returns characters of
+// multi-byte sequences rather than their bytes
+func (r *Utf8Reader) IsDecoding() bool {
+	return r.decode
+}
+
 func (r *Utf8Reader) GetChar() (uint32, error) {
 	if r.offset >= r.endOffset {
 		return 0, erroring.NewEOFError(erroring.XmlIncomplete)
 	}
 	ch := r.xmlDoc[r.offset]
+	if r.decode && ch >= utf8.RuneSelf {
+		return r.decodeChar()
+	}
 	r.offset++
 	if !r.isUTF8(ch) {
 		return 0, erroring.NewParseError("invalid UTF-8 character", "", nil)
@@ -41,7 +69,17 @@ func (r *Utf8Reader) GetChar() (uint32, error) {
 	return uint32(ch), nil
 }
 
-func (r *Utf8Reader) GetLongCharAt(offset int32) (uint64, error) {
+// decodeChar function reads multi-byte sequence at the current offset
+func (r *Utf8Reader) decodeChar() (uint32, error) {
+	ch, size := utf8.DecodeRune(r.xmlDoc[r.offset:r.endOffset])
+	if ch == utf8.RuneError && size < 2 {
+		return 0, erroring.NewParseError("invalid UTF-8 character", "", nil)
+	}
+	r.offset += size
+	return uint32(ch), nil
+}
+
+func (r *Utf8Reader) GetLongCharAt(offset int) (uint64, error) {
 	ch := r.xmlDoc[offset]
 	if ch == byte('\r') && r.xmlDoc[offset+1] == byte('\n') {
 		return (2 << 32) | '\n', nil
@@ -49,11 +87,19 @@ func (r *Utf8Reader) GetLongCharAt(offset int32) (uint64, error) {
 	return (1 << 32) | uint64(ch), nil
 }
 
-func (r *Utf8Reader) GetCharAt(offset int32) (uint32, error) {
+func (r *Utf8Reader) GetCharAt(offset int) (uint32, error) {
 	return uint32(r.xmlDoc[offset]), nil
 }
 
 func (r *Utf8Reader) SkipChar(ch uint32) bool {
+	if r.decode && ch >= utf8.RuneSelf {
+		offset := r.offset
+		if next, err := r.decodeChar(); err == nil && next == ch {
+			return true
+		}
+		r.offset = offset
+		return false
+	}
 	if ch == uint32(r.xmlDoc[r.offset]) {
 		r.offset++
 		return true
@@ -118,3 +118,37 @@ func Test_Utf8Reader_SkipCharSeq_Success(t *testing.T) {
 
 	assert.True(t, r.SkipCharSeq("<?xml "))
 }
+
+func Test_Utf8Reader_Decoding(t *testing.T) {
+	doc := []byte("a\u0085é \xC3")
+	r, err := NewDecodingUtf8Reader(doc, 0, len(doc))
+	assert.Nil(t, err)
+	for _, expected := range []uint32{'a', 0x85, 'é'} {
+		ch, err := r.GetChar()
+		assert.Nil(t, err)
+		assert.Equal(t, expected, ch)
+	}
+	assert.False(t, r.SkipChar(0x85))
+	assert.Equal(t, 5, r.GetOffset())
+	assert.True(t, r.SkipChar(0x2028))
+	assert.Equal(t, 8, r.GetOffset())
+	_, err = r.GetChar()
+	assert.EqualError(t, err, "a parse error occurred: invalid UTF-8 character")
+
+	r, err = NewUtf8Reader(doc, 0, len(doc))
+	assert.Nil(t, err)
+	assert.True(t, r.SkipChar('a'))
+	ch, err := r.GetChar()
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(0xC2), ch)
+
+	r.SetOffset(1)
+	r.SetDecoding(true)
+	assert.True(t, r.IsDecoding())
+	ch, err = r.GetChar()
+	assert.Nil(t, err)
+	assert.Equal(t, uint32(0x85), ch)
+	ch, err = r.GetChar()
+	assert.Nil(t, err)
+	assert.Equal(t, uint32('é'), ch)
+}
@@ -0,0 +1,74 @@
+package validate
+
+import (
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/dtd"
+)
+
+// elementDecl is a parsed element type declaration
+type elementDecl struct {
+	spec  string
+	model *dtd.ContentModel
+}
+
+// attlist holds attribute definitions of an element type in declaration order
+type attlist struct {
+	defs   []*dtd.AttributeDef
+	byName map[string]*dtd.AttributeDef
+}
+
+// schema holds element and attribute list declarations of a DTD indexed by
+// element name
+type schema struct {
+	elements map[string]*elementDecl
+	attlists map[string]*attlist
+}
+
+// newSchema function parses element and attribute list declarations of the
+// DTD. As in XML 1.0, the first definition of an attribute is binding, so is
+// the first declaration of an element type.
+func newSchema(d *dtd.Dtd) (*schema, error) {
+	s := &schema{
+		elements: make(map[string]*elementDecl),
+		attlists: make(map[string]*attlist),
+	}
+	for _, decl := range d.Declarations {
+		switch decl.Type {
+		case dtd.DeclarationElement:
+			if _, ok := s.elements[decl.Name]; ok {
+				continue
+			}
+			model, err := dtd.ParseContentModel(decl.Content)
+			if err != nil {
+				return nil, err
+			}
+			s.elements[decl.Name] = &elementDecl{spec: decl.Content, model: model}
+		case dtd.DeclarationAttlist:
+			defs, err := dtd.ParseAttlist(decl.Content)
+			if err != nil {
+				return nil, err
+			}
+			list, ok := s.attlists[decl.Name]
+			if !ok {
+				list = &attlist{byName: make(map[string]*dtd.AttributeDef)}
+				s.attlists[decl.Name] = list
+			}
+			for _, def := range defs {
+				if _, ok := list.byName[def.Name]; ok {
+					continue
+				}
+				list.defs = append(list.defs, def)
+				list.byName[def.Name] = def
+			}
+		}
+	}
+	return s, nil
+}
+
+// attribute function returns definition of the element attribute, or nil if
+// the attribute is not declared
+func (s *schema) attribute(element, name string) *dtd.AttributeDef {
+	if list, ok := s.attlists[element]; ok {
+		return list.byName[name]
+	}
+	return nil
+}
@@ -0,0 +1,400 @@
+package validate
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/common"
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/dtd"
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/erroring"
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/navigation"
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/parser"
+)
+
+// Violation is a validity constraint the document does not meet. Index is
+// the VTD token the violation was found at, Line and Column locate the token
+// in the document.
+type Violation struct {
+	Index        int
+	Line, Column int
+	Msg          string
+}
+
+// String function returns the violation message with its location
+func (v Violation) String() string {
+	return fmt.Sprintf("%s (token %d, line %d, column %d)", v.Msg, v.Index, v.Line, v.Column)
+}
+
+// LoadDTD function reads external DTD subset from the local file
+func LoadDTD(path string) (*dtd.Dtd, error) {
+	dtdDoc, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parser.ParseDtd(dtdDoc)
+}
+
+// ValidateDTD function checks the parsed document against the DTD and
+// returns every violation found: undeclared elements and attributes, content
+// not matching element content models, missing required attributes, fixed
+// and enumerated attribute values, ID uniqueness and IDREF targets. When the
+// DTD is nil, the document DTD (internal subset) is used. The document is
+// not parsed again, the VTD token stream is walked instead, so comments and
+// processing instructions are not checked. Error is returned if the DTD
+// declarations are malformed or tokens cannot be read.
+func ValidateDTD(nav *navigation.VtdNav, d *dtd.Dtd) ([]Violation, error) {
+	if nav == nil {
+		return nil, erroring.NewInvalidArgumentError("nav", erroring.CannotBeNil, nil)
+	}
+	if d == nil {
+		if d = nav.GetDtd(); d == nil {
+			return nil, erroring.NewInvalidArgumentError("dtd", erroring.CannotBeNil, nil)
+		}
+	}
+	s, err := newSchema(d)
+	if err != nil {
+		return nil, err
+	}
+	v := &validator{
+		nav:     nav,
+		schema:  s,
+		dtd:     d,
+		xmlChar: common.NewXmlChar(),
+		ids:     make(map[string]bool),
+	}
+	v.rootName = d.Name
+	if docDtd := nav.GetDtd(); docDtd != nil {
+		v.rootName = docDtd.Name
+	}
+	if err := v.walk(); err != nil {
+		return nil, err
+	}
+	return v.violations, nil
+}
+
+// element is an open element whose content is being collected
+type element struct {
+	index      int
+	name       string
+	children   []string
+	childIndex []int
+	text       bool
+}
+
+// idRef is an IDREF value checked once all IDs are known
+type idRef struct {
+	index int
+	value string
+}
+
+type validator struct {
+	nav        *navigation.VtdNav
+	schema     *schema
+	dtd        *dtd.Dtd
+	xmlChar    *common.XmlChar
+	rootName   string
+	stack      []*element
+	ids        map[string]bool
+	idRefs     []idRef
+	violations []Violation
+}
+
+// walk function visits every token of the document in order
+func (v *validator) walk() error {
+	size := v.nav.GetVtdBufferSize()
+	for i := 0; i < size; i++ {
+		tokenType, err := v.nav.GetTokenType(i)
+		if err != nil {
+			return err
+		}
+		depth, err := v.nav.GetTokenDepth(i)
+		if err != nil {
+			return err
+		}
+		switch common.Token(tokenType) {
+		case common.TokenStartingTag:
+			if err := v.closeTo(int(depth)); err != nil {
+				return err
+			}
+			if i, err = v.startElement(i); err != nil {
+				return err
+			}
+		case common.TokenCharacterData, common.TokenCdataVal:
+			if err := v.closeTo(int(depth) + 1); err != nil {
+				return err
+			}
+			if err := v.characterData(i, common.Token(tokenType)); err != nil {
+				return err
+			}
+		}
+	}
+	if err := v.closeTo(0); err != nil {
+		return err
+	}
+	for _, ref := range v.idRefs {
+		if !v.ids[ref.value] {
+			if err := v.report(ref.index, "IDREF %q does not match any ID", ref.value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// startElement function checks the element starting at the index and its
+// attributes, and returns index of the last attribute token
+func (v *validator) startElement(index int) (int, error) {
+	name, err := v.nav.ToRawStringAtIndex(index)
+	if err != nil {
+		return 0, err
+	}
+	if len(v.stack) == 0 {
+		if v.rootName != "" && name != v.rootName {
+			if err := v.report(index, "document element %s does not match DOCTYPE %s", name, v.rootName); err != nil {
+				return 0, err
+			}
+		}
+	} else {
+		parent := v.stack[len(v.stack)-1]
+		parent.children = append(parent.children, name)
+		parent.childIndex = append(parent.childIndex, index)
+	}
+	v.stack = append(v.stack, &element{index: index, name: name})
+
+	if _, ok := v.schema.elements[name]; !ok {
+		if err := v.report(index, "element %s is not declared", name); err != nil {
+			return 0, err
+		}
+	}
+	return v.attributes(index, name)
+}
+
+// attributes function checks attributes of the element starting at the index
+// and returns index of the last attribute token
+func (v *validator) attributes(index int, element string) (int, error) {
+	seen := make(map[string]bool)
+	last := index
+	size := v.nav.GetVtdBufferSize()
+	for i := index + 1; i+1 < size; i += 2 {
+		tokenType, err := v.nav.GetTokenType(i)
+		if err != nil {
+			return 0, err
+		}
+		if common.Token(tokenType) != common.TokenAttrName && common.Token(tokenType) != common.TokenAttrNs {
+			break
+		}
+		last = i + 1
+		name, err := v.nav.ToRawStringAtIndex(i)
+		if err != nil {
+			return 0, err
+		}
+		seen[name] = true
+		def := v.schema.attribute(element, name)
+		if def == nil {
+			if common.Token(tokenType) == common.TokenAttrNs || name == "xmlns" || strings.HasPrefix(name, "xmlns:") {
+				continue
+			}
+			if err := v.report(i, "attribute %s is not declared for element %s", name, element); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		value, err := v.nav.ToStringAtIndex(i + 1)
+		if err != nil {
+			return 0, err
+		}
+		if err := v.attributeValue(i+1, def, value); err != nil {
+			return 0, err
+		}
+	}
+
+	if list, ok := v.schema.attlists[element]; ok {
+		for _, def := range list.defs {
+			if def.Default == dtd.DefaultRequired && !seen[def.Name] {
+				if err := v.report(index, "required attribute %s of element %s is missing", def.Name, element); err != nil {
+					return 0, err
+				}
+			}
+		}
+	}
+	return last, nil
+}
+
+// attributeValue function checks value of the attribute at the index against
+// its definition
+func (v *validator) attributeValue(index int, def *dtd.AttributeDef, value string) error {
+	if def.Type != dtd.AttrCdata {
+		// values of tokenized and enumerated types are normalized
+		value = strings.Join(strings.Fields(value), " ")
+	}
+	if def.Default == dtd.DefaultFixed && value != def.Value {
+		if err := v.report(index, "attribute %s must have fixed value %q", def.Name, def.Value); err != nil {
+			return err
+		}
+	}
+
+	switch def.Type {
+	case dtd.AttrEnumeration, dtd.AttrNotation:
+		for _, allowed := range def.Values {
+			if value == allowed {
+				return nil
+			}
+		}
+		return v.report(index, "value %q of attribute %s is not one of (%s)", value, def.Name, strings.Join(def.Values, "|"))
+	case dtd.AttrID:
+		if !v.isName(value) {
+			return v.report(index, "value %q of ID attribute %s is not a name", value, def.Name)
+		}
+		if v.ids[value] {
+			return v.report(index, "ID %q is not unique", value)
+		}
+		v.ids[value] = true
+	case dtd.AttrIDRef, dtd.AttrIDRefs:
+		refs := strings.Fields(value)
+		if len(refs) == 0 || (def.Type == dtd.AttrIDRef && len(refs) > 1) {
+			return v.report(index, "invalid value %q of %s attribute %s", value, idRefTypeName(def.Type), def.Name)
+		}
+		for _, ref := range refs {
+			if !v.isName(ref) {
+				return v.report(index, "invalid value %q of %s attribute %s", value, idRefTypeName(def.Type), def.Name)
+			}
+			v.idRefs = append(v.idRefs, idRef{index: index, value: ref})
+		}
+	case dtd.AttrEntity, dtd.AttrEntities:
+		names := strings.Fields(value)
+		if len(names) == 0 || (def.Type == dtd.AttrEntity && len(names) > 1) {
+			return v.report(index, "invalid entity value %q of attribute %s", value, def.Name)
+		}
+		for _, name := range names {
+			if e, ok := v.dtd.GetEntity(name); !ok || !e.IsUnparsed() {
+				return v.report(index, "value %q of attribute %s does not name an unparsed entity", name, def.Name)
+			}
+		}
+	case dtd.AttrNmToken, dtd.AttrNmTokens:
+		tokens := strings.Fields(value)
+		if len(tokens) == 0 || (def.Type == dtd.AttrNmToken && len(tokens) > 1) {
+			return v.report(index, "invalid name token value %q of attribute %s", value, def.Name)
+		}
+		for _, token := range tokens {
+			if !v.isNameToken(token) {
+				return v.report(index, "invalid name token value %q of attribute %s", value, def.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// characterData function records text at the index in the element it
+// belongs to. Whitespace is ignorable in element content, CDATA sections
+// are not.
+func (v *validator) characterData(index int, tokenType common.Token) error {
+	if len(v.stack) == 0 {
+		return nil
+	}
+	e := v.stack[len(v.stack)-1]
+	if e.text {
+		return nil
+	}
+	if tokenType == common.TokenCdataVal {
+		e.text = true
+		return nil
+	}
+	text, err := v.nav.ToRawStringAtIndex(index)
+	if err != nil {
+		return err
+	}
+	e.text = strings.TrimSpace(text) != ""
+	return nil
+}
+
+// closeTo function closes open elements until depth elements remain open,
+// checking content of each element closed
+func (v *validator) closeTo(depth int) error {
+	if depth < 0 {
+		depth = 0
+	}
+	for len(v.stack) > depth {
+		e := v.stack[len(v.stack)-1]
+		v.stack = v.stack[:len(v.stack)-1]
+		if err := v.content(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// content function checks content of the element against its content model
+func (v *validator) content(e *element) error {
+	decl, ok := v.schema.elements[e.name]
+	if !ok {
+		return nil
+	}
+	switch decl.model.Type {
+	case dtd.ContentEmpty:
+		if e.text || len(e.children) > 0 {
+			return v.report(e.index, "element %s must be empty", e.name)
+		}
+	case dtd.ContentMixed:
+		for i, child := range e.children {
+			if !decl.model.Match([]string{child}) {
+				if err := v.report(e.childIndex[i], "element %s is not allowed in %s", child, e.name); err != nil {
+					return err
+				}
+			}
+		}
+	case dtd.ContentChildren:
+		if e.text {
+			if err := v.report(e.index, "element %s cannot contain character data", e.name); err != nil {
+				return err
+			}
+		}
+		if !decl.model.Match(e.children) {
+			return v.report(e.index, "content of element %s does not match %s", e.name, decl.spec)
+		}
+	}
+	return nil
+}
+
+// report function records violation found at the token index
+func (v *validator) report(index int, format string, args ...interface{}) error {
+	line, column, err := v.nav.GetTokenPosition(index)
+	if err != nil {
+		return err
+	}
+	v.violations = append(v.violations, Violation{
+		Index:  index,
+		Line:   line,
+		Column: column,
+		Msg:    fmt.Sprintf(format, args...),
+	})
+	return nil
+}
+
+func (v *validator) isName(value string) bool {
+	for i, ch := range value {
+		if i == 0 && !v.xmlChar.IsNameStartChar(uint32(ch)) {
+			return false
+		}
+		if !v.xmlChar.IsNameChar(uint32(ch)) {
+			return false
+		}
+	}
+	return value != ""
+}
+
+func (v *validator) isNameToken(value string) bool {
+	for _, ch := range value {
+		if !v.xmlChar.IsNameChar(uint32(ch)) {
+			return false
+		}
+	}
+	return value != ""
+}
+
+func idRefTypeName(attrType dtd.AttributeType) string {
+	if attrType == dtd.AttrIDRefs {
+		return "IDREFS"
+	}
+	return "IDREF"
+}
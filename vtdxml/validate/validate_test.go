@@ -0,0 +1,130 @@
+package validate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/navigation"
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+const libraryDtd = `<!ELEMENT library (book+, note?)>
+<!ELEMENT book (title, author*)>
+<!ELEMENT title (#PCDATA | em)*>
+<!ELEMENT em (#PCDATA)>
+<!ELEMENT author (#PCDATA)>
+<!ELEMENT note EMPTY>
+<!ATTLIST library version CDATA #FIXED "2">
+<!ATTLIST book
+  id ID #REQUIRED
+  kind (novel | poetry) "novel"
+  sequel IDREF #IMPLIED
+  tags NMTOKENS #IMPLIED>
+<!ATTLIST note refs IDREFS #REQUIRED>`
+
+func parse(t *testing.T, doc string) *navigation.VtdNav {
+	p, err := parser.NewVtdParser(parser.WithXmlDoc([]byte(doc)))
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	if !assert.Nil(t, p.Parse()) {
+		t.FailNow()
+	}
+	nav, err := p.GetNav()
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	return nav
+}
+
+func Test_ValidateDTD_Valid(t *testing.T) {
+	doc := `<!DOCTYPE library [` + libraryDtd + `]>
+<library version="2">
+  <book id="b1" tags=" classic  long "><title>A <em>tale</em></title><author>X</author></book>
+  <book id="b2" kind=" poetry " sequel="b1"><title/></book>
+  <note refs="b1  b2"/>
+</library>`
+	violations, err := ValidateDTD(parse(t, doc), nil)
+	assert.Nil(t, err)
+	assert.Empty(t, violations)
+}
+
+func Test_ValidateDTD_Violations(t *testing.T) {
+	doc := `<!DOCTYPE library [` + libraryDtd + `]>
+<library version="3">
+  <book id="b1" kind="essay" color="red"><author>X</author><title>T</title></book>
+  <book id="b1" sequel="b9">text<title>T<author>Y</author></title></book>
+  <note>n</note>
+  <magazine/>
+</library>`
+	nav := parse(t, doc)
+	violations, err := ValidateDTD(nav, nil)
+	assert.Nil(t, err)
+
+	expected := []struct {
+		line int
+		msg  string
+	}{
+		{line: 14, msg: `attribute version must have fixed value "2"`},
+		{line: 15, msg: `value "essay" of attribute kind is not one of (novel|poetry)`},
+		{line: 15, msg: "attribute color is not declared for element book"},
+		{line: 15, msg: "content of element book does not match (title, author*)"},
+		{line: 16, msg: `ID "b1" is not unique`},
+		{line: 16, msg: "element author is not allowed in title"},
+		{line: 16, msg: "element book cannot contain character data"},
+		{line: 17, msg: "required attribute refs of element note is missing"},
+		{line: 17, msg: "element note must be empty"},
+		{line: 18, msg: "element magazine is not declared"},
+		{line: 14, msg: "content of element library does not match (book+, note?)"},
+		{line: 16, msg: `IDREF "b9" does not match any ID`},
+	}
+	if !assert.Len(t, violations, len(expected)) {
+		for _, v := range violations {
+			t.Log(v)
+		}
+		return
+	}
+	for i, e := range expected {
+		assert.Equal(t, e.msg, violations[i].Msg)
+		assert.Equal(t, e.line, violations[i].Line)
+		tokenLine, _, err := nav.GetTokenPosition(violations[i].Index)
+		assert.Nil(t, err)
+		assert.Equal(t, e.line, tokenLine)
+	}
+}
+
+func Test_ValidateDTD_ExternalDtd(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "library.dtd")
+	assert.Nil(t, os.WriteFile(path, []byte(libraryDtd), 0600))
+	d, err := LoadDTD(path)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	doc := `<?xml version="1.0"?>
+<!DOCTYPE catalog SYSTEM "library.dtd">
+<library><book id="b1"><title>T</title></book></library>`
+	violations, err := ValidateDTD(parse(t, doc), d)
+	assert.Nil(t, err)
+	if assert.Len(t, violations, 1) {
+		assert.Equal(t, "document element library does not match DOCTYPE catalog", violations[0].Msg)
+		assert.Equal(t, 3, violations[0].Line)
+		assert.Equal(t, 2, violations[0].Column)
+	}
+}
+
+func Test_ValidateDTD_Failed(t *testing.T) {
+	_, err := ValidateDTD(nil, nil)
+	assert.NotNil(t, err)
+
+	_, err = ValidateDTD(parse(t, "<a/>"), nil)
+	assert.NotNil(t, err)
+
+	_, err = ValidateDTD(parse(t, "<!DOCTYPE a [<!ELEMENT a (b,)>]><a/>"), nil)
+	assert.NotNil(t, err)
+
+	_, err = LoadDTD(filepath.Join(t.TempDir(), "missing.dtd"))
+	assert.NotNil(t, err)
+}
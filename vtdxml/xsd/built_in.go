@@ -0,0 +1,127 @@
+package xsd
+
+import (
+	"encoding/base64"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+const (
+	timeZone = `(Z|[+-]((0\d|1[0-3]):[0-5]\d|14:00))?`
+	year     = `-?\d{4,}`
+	month    = `(0[1-9]|1[0-2])`
+	day      = `(0[1-9]|[12]\d|3[01])`
+	clock    = `(([01]\d|2[0-3]):[0-5]\d:[0-5]\d(\.\d+)?|24:00:00(\.0+)?)`
+	ncName   = `[\p{L}_][\p{L}\p{N}._\-\x{B7}]*`
+)
+
+// primitive is the lexical space of a built-in type. Types derived from a
+// built-in type check values the way the nearest built-in type does.
+type primitive struct {
+	name    string
+	valid   func(string) bool
+	numeric bool
+}
+
+var (
+	anySimpleType = &SimpleType{
+		Name:      QName{Space: Namespace, Local: "anySimpleType"},
+		Facets:    Facets{WhiteSpace: WhiteSpacePreserve},
+		primitive: &primitive{name: "anySimpleType"},
+	}
+
+	anyType = &ComplexType{
+		Name:       QName{Space: Namespace, Local: "anyType"},
+		Mixed:      true,
+		anyContent: true,
+	}
+
+	builtInTypes = newBuiltInTypes()
+)
+
+// newBuiltInTypes function defines built-in simple types by local name
+func newBuiltInTypes() map[string]*SimpleType {
+	types := map[string]*SimpleType{"anySimpleType": anySimpleType}
+	define := func(name string, base *SimpleType, p *primitive, facets Facets) *SimpleType {
+		t := &SimpleType{Name: QName{Space: Namespace, Local: name}, Base: base, primitive: p, Facets: facets}
+		types[name] = t
+		return t
+	}
+	lexical := func(name, pattern string, numeric bool) *primitive {
+		re := regexp.MustCompile("^(?:" + pattern + ")$")
+		return &primitive{name: name, valid: re.MatchString, numeric: numeric}
+	}
+	collapse := Facets{WhiteSpace: WhiteSpaceCollapse}
+
+	str := define("string", anySimpleType, &primitive{name: "string"}, Facets{WhiteSpace: WhiteSpacePreserve})
+	normalized := define("normalizedString", str, nil, Facets{WhiteSpace: WhiteSpaceReplace})
+	token := define("token", normalized, nil, collapse)
+	define("language", token, lexical("language", `[a-zA-Z]{1,8}(-[a-zA-Z0-9]{1,8})*`, false), Facets{})
+	nmToken := define("NMTOKEN", token, lexical("NMTOKEN", `[\p{L}\p{N}._:\-\x{B7}]+`, false), Facets{})
+	name := define("Name", token, lexical("Name", `[\p{L}_:][\p{L}\p{N}._:\-\x{B7}]*`, false), Facets{})
+	ncn := define("NCName", name, lexical("NCName", ncName, false), Facets{})
+	define("ID", ncn, nil, Facets{})
+	idRef := define("IDREF", ncn, nil, Facets{})
+	entity := define("ENTITY", ncn, nil, Facets{})
+	one := 1
+	for listName, item := range map[string]*SimpleType{"NMTOKENS": nmToken, "IDREFS": idRef, "ENTITIES": entity} {
+		t := define(listName, nil, nil, Facets{MinLength: &one, WhiteSpace: WhiteSpaceCollapse})
+		t.Variety, t.ItemType = VarietyList, item
+	}
+
+	define("boolean", anySimpleType, lexical("boolean", `true|false|1|0`, false), collapse)
+	decimal := define("decimal", anySimpleType, lexical("decimal", `[+-]?(\d+(\.\d*)?|\.\d+)`, true), collapse)
+	zero := 0
+	integer := define("integer", decimal, lexical("integer", `[+-]?\d+`, true), Facets{FractionDigits: &zero})
+	between := func(min, max string) Facets {
+		f := Facets{}
+		if min != "" {
+			f.MinInclusive, _ = new(big.Rat).SetString(min)
+		}
+		if max != "" {
+			f.MaxInclusive, _ = new(big.Rat).SetString(max)
+		}
+		return f
+	}
+	long := define("long", integer, nil, between("-9223372036854775808", "9223372036854775807"))
+	intType := define("int", long, nil, between("-2147483648", "2147483647"))
+	short := define("short", intType, nil, between("-32768", "32767"))
+	define("byte", short, nil, between("-128", "127"))
+	nonNegative := define("nonNegativeInteger", integer, nil, between("0", ""))
+	define("positiveInteger", nonNegative, nil, between("1", ""))
+	unsignedLong := define("unsignedLong", nonNegative, nil, between("", "18446744073709551615"))
+	unsignedInt := define("unsignedInt", unsignedLong, nil, between("", "4294967295"))
+	unsignedShort := define("unsignedShort", unsignedInt, nil, between("", "65535"))
+	define("unsignedByte", unsignedShort, nil, between("", "255"))
+	nonPositive := define("nonPositiveInteger", integer, nil, between("", "0"))
+	define("negativeInteger", nonPositive, nil, between("", "-1"))
+
+	float := `[+-]?(\d+(\.\d*)?|\.\d+)([eE][+-]?\d+)?|[+-]?INF|NaN`
+	define("float", anySimpleType, lexical("float", float, true), collapse)
+	define("double", anySimpleType, lexical("double", float, true), collapse)
+
+	define("date", anySimpleType, lexical("date", year+"-"+month+"-"+day+timeZone, false), collapse)
+	define("time", anySimpleType, lexical("time", clock+timeZone, false), collapse)
+	define("dateTime", anySimpleType, lexical("dateTime", year+"-"+month+"-"+day+"T"+clock+timeZone, false), collapse)
+	define("gYear", anySimpleType, lexical("gYear", year+timeZone, false), collapse)
+	define("gYearMonth", anySimpleType, lexical("gYearMonth", year+"-"+month+timeZone, false), collapse)
+	define("gMonth", anySimpleType, lexical("gMonth", "--"+month+timeZone, false), collapse)
+	define("gDay", anySimpleType, lexical("gDay", "---"+day+timeZone, false), collapse)
+	define("gMonthDay", anySimpleType, lexical("gMonthDay", "--"+month+"-"+day+timeZone, false), collapse)
+	durationRe := regexp.MustCompile(`^-?P(\d+Y)?(\d+M)?(\d+D)?(T(\d+H)?(\d+M)?(\d+(\.\d+)?S)?)?$`)
+	define("duration", anySimpleType, &primitive{name: "duration", valid: func(value string) bool {
+		// at least one component must follow P and T
+		return durationRe.MatchString(value) && !strings.HasSuffix(value, "P") && !strings.HasSuffix(value, "T")
+	}}, collapse)
+
+	define("hexBinary", anySimpleType, lexical("hexBinary", `([0-9a-fA-F]{2})*`, false), collapse)
+	define("base64Binary", anySimpleType, &primitive{name: "base64Binary", valid: func(value string) bool {
+		_, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(value, " ", ""))
+		return err == nil
+	}}, collapse)
+	define("anyURI", anySimpleType, &primitive{name: "anyURI"}, collapse)
+	define("QName", anySimpleType, lexical("QName", "("+ncName+":)?"+ncName, false), collapse)
+	define("NOTATION", anySimpleType, lexical("NOTATION", "("+ncName+":)?"+ncName, false), collapse)
+	return types
+}
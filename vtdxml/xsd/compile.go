@@ -0,0 +1,982 @@
+package xsd
+
+import (
+	"math/big"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/erroring"
+)
+
+// schemaDocument holds settings of a loaded schema document. An included
+// document without target namespace takes the namespace of the including
+// document (chameleon include), so do unqualified references it makes.
+type schemaDocument struct {
+	path                                   string
+	targetNamespace                        string
+	chameleon                              bool
+	qualifiedElements, qualifiedAttributes bool
+}
+
+// resolve function resolves reference made by the node of the document
+func (d *schemaDocument) resolve(n *node, name string) (QName, error) {
+	qn, err := n.resolve(name)
+	if err != nil {
+		return QName{}, err
+	}
+	if d.chameleon && qn.Space == "" {
+		qn.Space = d.targetNamespace
+	}
+	return qn, nil
+}
+
+// component is a global schema component declared by the node
+type component struct {
+	doc  *schemaDocument
+	node *node
+}
+
+type compiler struct {
+	schema          *Schema
+	documents       map[string]*node
+	loaded          map[string]bool
+	order           []func() error
+	elements        map[QName]*component
+	simpleTypes     map[QName]*component
+	complexTypes    map[QName]*component
+	groups          map[QName]*component
+	attributeGroups map[QName]*component
+	attributes      map[QName]*component
+	groupParticles  map[QName]*Particle
+	attributeDecls  map[QName]*AttributeUse
+	compiling       map[*node]bool
+}
+
+// Compile function loads schema documents from local files, together with
+// the documents they include and import, and compiles their components.
+// Imports without schemaLocation are expected among the paths. Redefinition,
+// substitution groups, identity constraints and xsi:type are not supported.
+func Compile(paths ...string) (*Schema, error) {
+	if len(paths) == 0 {
+		return nil, erroring.NewInvalidArgumentError("paths", "cannot be empty", nil)
+	}
+	c := &compiler{
+		schema: &Schema{
+			elements:     make(map[QName]*ElementDecl),
+			simpleTypes:  make(map[QName]*SimpleType),
+			complexTypes: make(map[QName]*ComplexType),
+		},
+		documents:       make(map[string]*node),
+		loaded:          make(map[string]bool),
+		elements:        make(map[QName]*component),
+		simpleTypes:     make(map[QName]*component),
+		complexTypes:    make(map[QName]*component),
+		groups:          make(map[QName]*component),
+		attributeGroups: make(map[QName]*component),
+		attributes:      make(map[QName]*component),
+		groupParticles:  make(map[QName]*Particle),
+		attributeDecls:  make(map[QName]*AttributeUse),
+		compiling:       make(map[*node]bool),
+	}
+	for _, path := range paths {
+		if err := c.load(path, nil); err != nil {
+			return nil, err
+		}
+	}
+	for _, compile := range c.order {
+		if err := compile(); err != nil {
+			return nil, err
+		}
+	}
+	return c.schema, nil
+}
+
+// load function loads schema document and registers its global components.
+// Includer is the including document, or nil for imported and top level
+// documents.
+func (c *compiler) load(path string, includer *schemaDocument) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return erroring.NewSchemaError(path, err)
+	}
+	root, ok := c.documents[path]
+	if !ok {
+		if root, err = loadDocument(path); err != nil {
+			return err
+		}
+		c.documents[path] = root
+	}
+	if root.name != (QName{Space: Namespace, Local: "schema"}) {
+		return root.errorf("root element must be schema, found %s", root.name)
+	}
+
+	doc := &schemaDocument{
+		path:                path,
+		targetNamespace:     root.attr("targetNamespace"),
+		qualifiedElements:   root.attr("elementFormDefault") == "qualified",
+		qualifiedAttributes: root.attr("attributeFormDefault") == "qualified",
+	}
+	if includer != nil {
+		if doc.targetNamespace == "" {
+			doc.targetNamespace, doc.chameleon = includer.targetNamespace, includer.targetNamespace != ""
+		} else if doc.targetNamespace != includer.targetNamespace {
+			return root.errorf("included schema namespace %s does not match %s", doc.targetNamespace, includer.targetNamespace)
+		}
+	}
+	key := path + "#" + doc.targetNamespace
+	if c.loaded[key] {
+		return nil
+	}
+	c.loaded[key] = true
+
+	for _, child := range root.children {
+		child := child
+		if child.name.Space != Namespace {
+			return child.errorf("unexpected %s", child.name)
+		}
+		switch child.name.Local {
+		case "include", "import":
+			location := child.attr("schemaLocation")
+			if location == "" {
+				if child.name.Local == "include" {
+					return child.errorf("include must have schemaLocation")
+				}
+				continue
+			}
+			if !filepath.IsAbs(location) {
+				location = filepath.Join(filepath.Dir(path), location)
+			}
+			if child.name.Local == "include" {
+				err = c.load(location, doc)
+			} else {
+				err = c.load(location, nil)
+			}
+			if err != nil {
+				return err
+			}
+		case "annotation", "notation":
+		case "element":
+			err = c.register(c.elements, doc, child, func(name QName) error {
+				_, err := c.globalElement(name, child)
+				return err
+			})
+		case "simpleType":
+			err = c.register(c.simpleTypes, doc, child, func(name QName) error {
+				_, err := c.simpleType(name, child)
+				return err
+			})
+		case "complexType":
+			err = c.register(c.complexTypes, doc, child, func(name QName) error {
+				_, err := c.complexType(name, child, false)
+				return err
+			})
+		case "group":
+			err = c.register(c.groups, doc, child, func(name QName) error {
+				_, err := c.groupParticle(name, child)
+				return err
+			})
+		case "attributeGroup":
+			err = c.register(c.attributeGroups, doc, child, func(name QName) error {
+				return c.attributeUses(doc, child, &ComplexType{})
+			})
+		case "attribute":
+			err = c.register(c.attributes, doc, child, func(name QName) error {
+				_, err := c.globalAttribute(name, child)
+				return err
+			})
+		default:
+			return child.errorf("%s is not supported", child.name.Local)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// register function adds named global component and schedules its
+// compilation
+func (c *compiler) register(components map[QName]*component, doc *schemaDocument, n *node, compile func(QName) error) error {
+	name := QName{Space: doc.targetNamespace, Local: n.attr("name")}
+	if name.Local == "" {
+		return n.errorf("global %s must have a name", n.name.Local)
+	}
+	if _, ok := components[name]; ok {
+		return n.errorf("duplicate %s %s", n.name.Local, name)
+	}
+	components[name] = &component{doc: doc, node: n}
+	c.order = append(c.order, func() error {
+		return compile(name)
+	})
+	return nil
+}
+
+// enter function marks the node as being compiled, compiling it again means
+// circular definition
+func (c *compiler) enter(n *node) error {
+	if c.compiling[n] {
+		return n.errorf("circular definition of %s", n.attr("name"))
+	}
+	c.compiling[n] = true
+	return nil
+}
+
+func (c *compiler) leave(n *node) {
+	delete(c.compiling, n)
+}
+
+// globalElement function returns global element declaration, references
+// made by content models of the element get the declaration being compiled
+func (c *compiler) globalElement(name QName, at *node) (*ElementDecl, error) {
+	if e, ok := c.schema.elements[name]; ok {
+		return e, nil
+	}
+	comp, ok := c.elements[name]
+	if !ok {
+		return nil, at.errorf("unknown element %s", name)
+	}
+	e := &ElementDecl{Name: name}
+	c.schema.elements[name] = e
+	if err := c.elementType(comp.doc, comp.node, e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// localElement function returns element declaration or reference of a
+// content model
+func (c *compiler) localElement(doc *schemaDocument, n *node) (*ElementDecl, error) {
+	if n.hasAttr("ref") {
+		ref, err := doc.resolve(n, n.attr("ref"))
+		if err != nil {
+			return nil, err
+		}
+		return c.globalElement(ref, n)
+	}
+	e := &ElementDecl{Name: QName{Local: n.attr("name")}}
+	if e.Name.Local == "" {
+		return nil, n.errorf("element must have a name or ref")
+	}
+	if form := n.attr("form"); form == "qualified" || (form == "" && doc.qualifiedElements) {
+		e.Name.Space = doc.targetNamespace
+	}
+	if err := c.elementType(doc, n, e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// elementType function sets type and value constraints of the element
+// declaration. Elements without type are of xs:anyType.
+func (c *compiler) elementType(doc *schemaDocument, n *node, e *ElementDecl) error {
+	e.Nillable = n.attr("nillable") == "true"
+	e.Fixed, e.Default = optionalAttr(n, "fixed"), optionalAttr(n, "default")
+	if n.hasAttr("type") {
+		ref, err := doc.resolve(n, n.attr("type"))
+		if err != nil {
+			return err
+		}
+		e.Simple, e.Complex, err = c.typeDefinition(ref, n, false)
+		return err
+	}
+	for _, child := range n.children {
+		var err error
+		switch child.name.Local {
+		case "simpleType":
+			e.Simple, err = c.compileSimpleType(doc, child, QName{})
+		case "complexType":
+			e.Complex, err = c.compileComplexType(doc, child, QName{})
+		default:
+			continue
+		}
+		return err
+	}
+	e.Complex = anyType
+	return nil
+}
+
+// typeDefinition function returns simple or complex type with the name.
+// Derivation is true if the type is referenced as a base type, which cannot
+// be the type being compiled.
+func (c *compiler) typeDefinition(name QName, at *node, derivation bool) (*SimpleType, *ComplexType, error) {
+	if name == anyType.Name {
+		return nil, anyType, nil
+	}
+	if _, ok := c.complexTypes[name]; ok {
+		t, err := c.complexType(name, at, derivation)
+		return nil, t, err
+	}
+	t, err := c.simpleType(name, at)
+	return t, nil, err
+}
+
+// simpleType function returns simple type with the name
+func (c *compiler) simpleType(name QName, at *node) (*SimpleType, error) {
+	if name.Space == Namespace {
+		if t, ok := builtInTypes[name.Local]; ok {
+			return t, nil
+		}
+		return nil, at.errorf("unknown type %s", name)
+	}
+	comp, ok := c.simpleTypes[name]
+	if !ok {
+		return nil, at.errorf("unknown type %s", name)
+	}
+	if c.compiling[comp.node] {
+		return nil, comp.node.errorf("circular definition of %s", name)
+	}
+	if t, ok := c.schema.simpleTypes[name]; ok {
+		return t, nil
+	}
+	return c.compileSimpleType(comp.doc, comp.node, name)
+}
+
+// complexType function returns complex type with the name
+func (c *compiler) complexType(name QName, at *node, derivation bool) (*ComplexType, error) {
+	comp, ok := c.complexTypes[name]
+	if !ok {
+		return nil, at.errorf("unknown type %s", name)
+	}
+	if derivation && c.compiling[comp.node] {
+		return nil, comp.node.errorf("circular definition of %s", name)
+	}
+	if t, ok := c.schema.complexTypes[name]; ok {
+		return t, nil
+	}
+	return c.compileComplexType(comp.doc, comp.node, name)
+}
+
+// compileSimpleType function compiles simple type definition, anonymous
+// types have empty name
+func (c *compiler) compileSimpleType(doc *schemaDocument, n *node, name QName) (*SimpleType, error) {
+	t := &SimpleType{Name: name}
+	if name.Local != "" {
+		c.schema.simpleTypes[name] = t
+	}
+	if err := c.enter(n); err != nil {
+		return nil, err
+	}
+	defer c.leave(n)
+
+	for _, child := range n.children {
+		switch child.name.Local {
+		case "annotation":
+		case "restriction":
+			base, err := c.simpleBase(doc, child, "base")
+			if err != nil {
+				return nil, err
+			}
+			t.Base, t.Variety = base, base.Variety
+			if err := c.facets(doc, child, &t.Facets, base); err != nil {
+				return nil, err
+			}
+		case "list":
+			item, err := c.simpleBase(doc, child, "itemType")
+			if err != nil {
+				return nil, err
+			}
+			t.Variety, t.ItemType = VarietyList, item
+			t.Facets.WhiteSpace = WhiteSpaceCollapse
+		case "union":
+			t.Variety = VarietyUnion
+			for _, member := range strings.Fields(child.attr("memberTypes")) {
+				ref, err := doc.resolve(child, member)
+				if err != nil {
+					return nil, err
+				}
+				mt, err := c.simpleType(ref, child)
+				if err != nil {
+					return nil, err
+				}
+				t.MemberTypes = append(t.MemberTypes, mt)
+			}
+			for _, inline := range child.children {
+				if inline.name.Local != "simpleType" {
+					continue
+				}
+				mt, err := c.compileSimpleType(doc, inline, QName{})
+				if err != nil {
+					return nil, err
+				}
+				t.MemberTypes = append(t.MemberTypes, mt)
+			}
+			if len(t.MemberTypes) == 0 {
+				return nil, child.errorf("union must have member types")
+			}
+		default:
+			return nil, child.errorf("unexpected %s in simpleType", child.name.Local)
+		}
+	}
+	if t.Base == nil && t.ItemType == nil && t.MemberTypes == nil {
+		return nil, n.errorf("simpleType must have restriction, list or union")
+	}
+	return t, nil
+}
+
+// simpleBase function returns simple type referenced by the attribute of
+// the node or defined inline by its simpleType child
+func (c *compiler) simpleBase(doc *schemaDocument, n *node, attr string) (*SimpleType, error) {
+	if n.hasAttr(attr) {
+		ref, err := doc.resolve(n, n.attr(attr))
+		if err != nil {
+			return nil, err
+		}
+		return c.simpleType(ref, n)
+	}
+	for _, child := range n.children {
+		if child.name.Local == "simpleType" {
+			return c.compileSimpleType(doc, child, QName{})
+		}
+	}
+	return nil, n.errorf("%s must have %s or simpleType", n.name.Local, attr)
+}
+
+// facets function reads constraining facets of the restriction of the base
+// type. Range facets of types that are not numeric are ignored.
+func (c *compiler) facets(doc *schemaDocument, n *node, f *Facets, base *SimpleType) error {
+	numeric := false
+	if p := base.primitiveType(); p != nil && base.Variety == VarietyAtomic {
+		numeric = p.numeric
+	}
+	for _, child := range n.children {
+		value := child.attr("value")
+		var err error
+		switch child.name.Local {
+		case "annotation", "simpleType", "attribute", "attributeGroup", "anyAttribute":
+		case "length":
+			f.Length, err = facetInt(child)
+		case "minLength":
+			f.MinLength, err = facetInt(child)
+		case "maxLength":
+			f.MaxLength, err = facetInt(child)
+		case "totalDigits":
+			f.TotalDigits, err = facetInt(child)
+		case "fractionDigits":
+			f.FractionDigits, err = facetInt(child)
+		case "pattern":
+			var re *regexp.Regexp
+			if re, err = compilePattern(child.attrs["value"]); err != nil {
+				return child.errorf("invalid pattern %s: %s", child.attrs["value"], err)
+			}
+			f.Patterns = append(f.Patterns, re)
+		case "enumeration":
+			f.Enumeration = append(f.Enumeration, base.normalize(child.attrs["value"]))
+		case "whiteSpace":
+			switch value {
+			case "preserve":
+				f.WhiteSpace = WhiteSpacePreserve
+			case "replace":
+				f.WhiteSpace = WhiteSpaceReplace
+			case "collapse":
+				f.WhiteSpace = WhiteSpaceCollapse
+			default:
+				return child.errorf("invalid whiteSpace %s", value)
+			}
+		case "minInclusive", "maxInclusive", "minExclusive", "maxExclusive":
+			if !numeric {
+				continue
+			}
+			r, ok := new(big.Rat).SetString(value)
+			if !ok {
+				return child.errorf("invalid %s %s", child.name.Local, value)
+			}
+			switch child.name.Local {
+			case "minInclusive":
+				f.MinInclusive = r
+			case "maxInclusive":
+				f.MaxInclusive = r
+			case "minExclusive":
+				f.MinExclusive = r
+			default:
+				f.MaxExclusive = r
+			}
+		default:
+			return child.errorf("facet %s is not supported", child.name.Local)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func facetInt(n *node) (*int, error) {
+	v, err := strconv.Atoi(n.attr("value"))
+	if err != nil || v < 0 {
+		return nil, n.errorf("invalid %s %s", n.name.Local, n.attr("value"))
+	}
+	return &v, nil
+}
+
+// compileComplexType function compiles complex type definition, anonymous
+// types have empty name
+func (c *compiler) compileComplexType(doc *schemaDocument, n *node, name QName) (*ComplexType, error) {
+	t := &ComplexType{Name: name, Mixed: n.attr("mixed") == "true"}
+	if name.Local != "" {
+		c.schema.complexTypes[name] = t
+	}
+	if err := c.enter(n); err != nil {
+		return nil, err
+	}
+	defer c.leave(n)
+
+	for _, child := range n.children {
+		var err error
+		switch child.name.Local {
+		case "annotation":
+		case "sequence", "choice", "all", "group":
+			t.Particle, err = c.particle(doc, child)
+		case "attribute", "attributeGroup", "anyAttribute":
+			err = c.attributeUses(doc, child, t)
+		case "simpleContent":
+			err = c.simpleContent(doc, child, t)
+		case "complexContent":
+			err = c.complexContent(doc, child, t)
+		default:
+			err = child.errorf("unexpected %s in complexType", child.name.Local)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// derivation function returns derivation element of simple or complex
+// content and the base type it references
+func (c *compiler) derivation(doc *schemaDocument, n *node) (*node, *SimpleType, *ComplexType, error) {
+	for _, child := range n.children {
+		if child.name.Local != "extension" && child.name.Local != "restriction" {
+			continue
+		}
+		ref, err := doc.resolve(child, child.attr("base"))
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		simple, complex, err := c.typeDefinition(ref, child, true)
+		return child, simple, complex, err
+	}
+	return nil, nil, nil, n.errorf("%s must have extension or restriction", n.name.Local)
+}
+
+// simpleContent function sets simple content of the complex type
+func (c *compiler) simpleContent(doc *schemaDocument, n *node, t *ComplexType) error {
+	d, simple, complex, err := c.derivation(doc, n)
+	if err != nil {
+		return err
+	}
+	if complex != nil {
+		if complex.Simple == nil {
+			return d.errorf("base type %s does not have simple content", complex.Name)
+		}
+		simple = complex.Simple
+		t.Attributes = append([]*AttributeUse(nil), complex.Attributes...)
+		t.AnyAttribute = complex.AnyAttribute
+	}
+	t.Simple = simple
+	if d.name.Local == "restriction" {
+		if complex == nil {
+			return d.errorf("simple content restriction must have complex base type")
+		}
+		for _, child := range d.children {
+			if child.name.Local == "simpleType" {
+				if simple, err = c.compileSimpleType(doc, child, QName{}); err != nil {
+					return err
+				}
+			}
+		}
+		t.Simple = &SimpleType{Base: simple, Variety: simple.Variety}
+		if err := c.facets(doc, d, &t.Simple.Facets, simple); err != nil {
+			return err
+		}
+	}
+	return c.derivedAttributes(doc, d, t)
+}
+
+// complexContent function sets content of the complex type derived from
+// another complex type. Extension appends its particle to the base content
+// model, restriction replaces the content model.
+func (c *compiler) complexContent(doc *schemaDocument, n *node, t *ComplexType) error {
+	d, _, base, err := c.derivation(doc, n)
+	if err != nil {
+		return err
+	}
+	if base == nil {
+		return d.errorf("base type of complex content must be complex")
+	}
+	if n.hasAttr("mixed") {
+		t.Mixed = n.attr("mixed") == "true"
+	}
+	t.Attributes = append([]*AttributeUse(nil), base.Attributes...)
+	t.AnyAttribute = base.AnyAttribute
+
+	var particle *Particle
+	for _, child := range d.children {
+		switch child.name.Local {
+		case "sequence", "choice", "all", "group":
+			if particle, err = c.particle(doc, child); err != nil {
+				return err
+			}
+		}
+	}
+	t.Particle = particle
+	if d.name.Local == "extension" && base != anyType {
+		t.Mixed = t.Mixed || base.Mixed
+		switch {
+		case base.Particle == nil:
+		case particle == nil:
+			t.Particle = base.Particle
+		default:
+			t.Particle = &Particle{
+				Kind:      ParticleSequence,
+				MinOccurs: 1,
+				MaxOccurs: 1,
+				Children:  []*Particle{base.Particle, particle},
+			}
+		}
+	}
+	return c.derivedAttributes(doc, d, t)
+}
+
+// derivedAttributes function applies attribute declarations of the
+// derivation element to the complex type
+func (c *compiler) derivedAttributes(doc *schemaDocument, d *node, t *ComplexType) error {
+	for _, child := range d.children {
+		switch child.name.Local {
+		case "attribute", "attributeGroup", "anyAttribute":
+			if err := c.attributeUses(doc, child, t); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// particle function compiles particle of a content model. Particles with
+// maxOccurs of zero are returned as nil.
+func (c *compiler) particle(doc *schemaDocument, n *node) (*Particle, error) {
+	p := &Particle{MinOccurs: 1, MaxOccurs: 1}
+	if n.hasAttr("minOccurs") {
+		v, err := strconv.Atoi(n.attr("minOccurs"))
+		if err != nil || v < 0 {
+			return nil, n.errorf("invalid minOccurs %s", n.attr("minOccurs"))
+		}
+		p.MinOccurs = v
+	}
+	if max := n.attr("maxOccurs"); max == "unbounded" {
+		p.MaxOccurs = Unbounded
+	} else if max != "" {
+		v, err := strconv.Atoi(max)
+		if err != nil || v < 0 {
+			return nil, n.errorf("invalid maxOccurs %s", max)
+		}
+		p.MaxOccurs = v
+	}
+	if p.MaxOccurs == 0 {
+		return nil, nil
+	}
+	if p.MaxOccurs != Unbounded && p.MinOccurs > p.MaxOccurs {
+		return nil, n.errorf("minOccurs is greater than maxOccurs")
+	}
+
+	var err error
+	switch n.name.Local {
+	case "element":
+		p.Kind = ParticleElement
+		p.Element, err = c.localElement(doc, n)
+	case "any":
+		p.Kind = ParticleAny
+		p.Wildcard = wildcard(doc, n)
+	case "sequence", "choice", "all":
+		p.Kind = map[string]ParticleKind{
+			"sequence": ParticleSequence,
+			"choice":   ParticleChoice,
+			"all":      ParticleAll,
+		}[n.name.Local]
+		for _, child := range n.children {
+			if child.name.Local == "annotation" {
+				continue
+			}
+			cp, err := c.particle(doc, child)
+			if err != nil {
+				return nil, err
+			}
+			if cp != nil {
+				p.Children = append(p.Children, cp)
+			}
+		}
+	case "group":
+		var ref QName
+		if ref, err = doc.resolve(n, n.attr("ref")); err != nil {
+			return nil, err
+		}
+		var group *Particle
+		if group, err = c.groupParticle(ref, n); err != nil {
+			return nil, err
+		}
+		repeated := *group
+		repeated.MinOccurs, repeated.MaxOccurs = p.MinOccurs, p.MaxOccurs
+		return &repeated, nil
+	default:
+		err = n.errorf("unexpected %s in content model", n.name.Local)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// groupParticle function returns model group of the named group definition
+func (c *compiler) groupParticle(name QName, at *node) (*Particle, error) {
+	if p, ok := c.groupParticles[name]; ok {
+		return p, nil
+	}
+	comp, ok := c.groups[name]
+	if !ok {
+		return nil, at.errorf("unknown group %s", name)
+	}
+	if err := c.enter(comp.node); err != nil {
+		return nil, err
+	}
+	defer c.leave(comp.node)
+	for _, child := range comp.node.children {
+		switch child.name.Local {
+		case "sequence", "choice", "all":
+			p, err := c.particle(comp.doc, child)
+			if err != nil {
+				return nil, err
+			}
+			if p == nil {
+				p = &Particle{Kind: ParticleSequence, MinOccurs: 1, MaxOccurs: 1}
+			}
+			c.groupParticles[name] = p
+			return p, nil
+		}
+	}
+	return nil, comp.node.errorf("group %s must have sequence, choice or all", name)
+}
+
+// attributeUses function adds attribute, attribute group or attribute
+// wildcard declared by the node to the complex type. A declaration replaces
+// the one of the base type with the same name.
+func (c *compiler) attributeUses(doc *schemaDocument, n *node, t *ComplexType) error {
+	switch n.name.Local {
+	case "anyAttribute":
+		t.AnyAttribute = wildcard(doc, n)
+	case "attributeGroup":
+		if !n.hasAttr("ref") {
+			// definition of a global group
+			for _, child := range n.children {
+				if err := c.attributeUses(doc, child, t); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		ref, err := doc.resolve(n, n.attr("ref"))
+		if err != nil {
+			return err
+		}
+		comp, ok := c.attributeGroups[ref]
+		if !ok {
+			return n.errorf("unknown attribute group %s", ref)
+		}
+		if err := c.enter(comp.node); err != nil {
+			return err
+		}
+		defer c.leave(comp.node)
+		for _, child := range comp.node.children {
+			if err := c.attributeUses(comp.doc, child, t); err != nil {
+				return err
+			}
+		}
+	case "attribute":
+		use, err := c.attributeUse(doc, n)
+		if err != nil {
+			return err
+		}
+		for i, a := range t.Attributes {
+			if a.Name == use.Name {
+				t.Attributes[i] = use
+				return nil
+			}
+		}
+		t.Attributes = append(t.Attributes, use)
+	}
+	return nil
+}
+
+// attributeUse function compiles local attribute declaration or reference
+func (c *compiler) attributeUse(doc *schemaDocument, n *node) (*AttributeUse, error) {
+	use := &AttributeUse{}
+	if n.hasAttr("ref") {
+		ref, err := doc.resolve(n, n.attr("ref"))
+		if err != nil {
+			return nil, err
+		}
+		global, err := c.globalAttribute(ref, n)
+		if err != nil {
+			return nil, err
+		}
+		*use = *global
+	} else {
+		use.Name = QName{Local: n.attr("name")}
+		if use.Name.Local == "" {
+			return nil, n.errorf("attribute must have a name or ref")
+		}
+		if form := n.attr("form"); form == "qualified" || (form == "" && doc.qualifiedAttributes) {
+			use.Name.Space = doc.targetNamespace
+		}
+		if err := c.attributeType(doc, n, use); err != nil {
+			return nil, err
+		}
+	}
+	switch n.attr("use") {
+	case "required":
+		use.Required = true
+	case "prohibited":
+		use.Prohibited = true
+	}
+	if v := optionalAttr(n, "fixed"); v != nil {
+		use.Fixed = v
+	}
+	if v := optionalAttr(n, "default"); v != nil {
+		use.Default = v
+	}
+	return use, nil
+}
+
+// globalAttribute function returns global attribute declaration
+func (c *compiler) globalAttribute(name QName, at *node) (*AttributeUse, error) {
+	if a, ok := c.attributeDecls[name]; ok {
+		return a, nil
+	}
+	comp, ok := c.attributes[name]
+	if !ok {
+		return nil, at.errorf("unknown attribute %s", name)
+	}
+	a := &AttributeUse{Name: name}
+	if err := c.attributeType(comp.doc, comp.node, a); err != nil {
+		return nil, err
+	}
+	a.Fixed, a.Default = optionalAttr(comp.node, "fixed"), optionalAttr(comp.node, "default")
+	c.attributeDecls[name] = a
+	return a, nil
+}
+
+// attributeType function sets type of the attribute, xs:anySimpleType if
+// the declaration does not define one
+func (c *compiler) attributeType(doc *schemaDocument, n *node, a *AttributeUse) error {
+	a.Type = anySimpleType
+	if n.hasAttr("type") {
+		ref, err := doc.resolve(n, n.attr("type"))
+		if err != nil {
+			return err
+		}
+		if a.Type, err = c.simpleType(ref, n); err != nil {
+			return err
+		}
+	}
+	for _, child := range n.children {
+		switch child.name.Local {
+		case "annotation":
+		case "simpleType":
+			t, err := c.compileSimpleType(doc, child, QName{})
+			if err != nil {
+				return err
+			}
+			a.Type = t
+		default:
+			return child.errorf("unexpected %s in attribute", child.name.Local)
+		}
+	}
+	return nil
+}
+
+// wildcard function reads namespace constraint of any or anyAttribute
+func wildcard(doc *schemaDocument, n *node) *Wildcard {
+	w := &Wildcard{
+		Namespaces:      strings.Fields(n.attr("namespace")),
+		TargetNamespace: doc.targetNamespace,
+		ProcessContents: n.attr("processContents"),
+	}
+	if len(w.Namespaces) == 0 {
+		w.Namespaces = []string{"##any"}
+	}
+	if w.ProcessContents == "" {
+		w.ProcessContents = "strict"
+	}
+	return w
+}
+
+func optionalAttr(n *node, name string) *string {
+	if v, ok := n.attrs[name]; ok {
+		return &v
+	}
+	return nil
+}
+
+// compilePattern function translates schema regular expression into an
+// anchored Go regular expression. Schema expressions are implicitly
+// anchored and ^ and $ are ordinary characters in them. Multi-character
+// escapes \i and \c are expanded, character class subtraction is not
+// supported.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	const nameStart, nameChar = `\p{L}_:`, `\p{L}\p{N}._:\-\x{B7}`
+	var sb strings.Builder
+	inClass := false
+	runes := []rune(pattern)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '\\' && i+1 < len(runes):
+			i++
+			var class string
+			negated := false
+			switch runes[i] {
+			case 'i':
+				class = nameStart
+			case 'I':
+				class, negated = nameStart, true
+			case 'c':
+				class = nameChar
+			case 'C':
+				class, negated = nameChar, true
+			default:
+				sb.WriteRune('\\')
+				sb.WriteRune(runes[i])
+				continue
+			}
+			switch {
+			case inClass && negated:
+				return nil, erroring.NewSchemaError("negated multi-character escape in character class", nil)
+			case inClass:
+				sb.WriteString(class)
+			case negated:
+				sb.WriteString("[^" + class + "]")
+			default:
+				sb.WriteString("[" + class + "]")
+			}
+		case r == '[' && inClass:
+			return nil, erroring.NewSchemaError("character class subtraction is not supported", nil)
+		case r == '[':
+			inClass = true
+			sb.WriteRune(r)
+			if i+1 < len(runes) && runes[i+1] == '^' {
+				sb.WriteRune('^')
+				i++
+			}
+		case r == ']' && inClass:
+			inClass = false
+			sb.WriteRune(r)
+		case (r == '^' || r == '$') && !inClass:
+			sb.WriteRune('\\')
+			sb.WriteRune(r)
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return regexp.Compile("^(?:" + sb.String() + ")$")
+}
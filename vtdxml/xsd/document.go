@@ -0,0 +1,202 @@
+package xsd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/common"
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/erroring"
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/navigation"
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/parser"
+)
+
+// node is an element of a schema document. Attribute names are kept as
+// written, since schema attributes are unqualified, namespaces holds the
+// prefix bindings in scope.
+type node struct {
+	name       QName
+	attrs      map[string]string
+	children   []*node
+	namespaces map[string]string
+	path       string
+	line       int
+}
+
+// attr function returns value of the attribute with surrounding whitespace
+// removed, or an empty string if it is not set
+func (n *node) attr(name string) string {
+	return strings.TrimSpace(n.attrs[name])
+}
+
+// hasAttr function returns true if the attribute is set
+func (n *node) hasAttr(name string) bool {
+	_, ok := n.attrs[name]
+	return ok
+}
+
+// errorf function returns SchemaError located at the node
+func (n *node) errorf(format string, args ...interface{}) error {
+	return erroring.NewSchemaError(fmt.Sprintf("%s:%d: %s", n.path, n.line, fmt.Sprintf(format, args...)), nil)
+}
+
+// resolve function resolves prefixed name against namespaces in scope of
+// the node
+func (n *node) resolve(name string) (QName, error) {
+	prefix, local := splitName(strings.TrimSpace(name))
+	space, ok := n.namespaces[prefix]
+	if !ok && prefix != "" {
+		return QName{}, n.errorf("undeclared namespace prefix %s", prefix)
+	}
+	return QName{Space: space, Local: local}, nil
+}
+
+// loadDocument function parses the schema file into a tree of nodes
+func loadDocument(path string) (*node, error) {
+	nav, err := parser.ParseFile(path)
+	if err != nil {
+		return nil, erroring.NewSchemaError(fmt.Sprintf("failed to parse %s", path), err)
+	}
+	b := &treeBuilder{nav: nav, path: path}
+	if err := b.build(); err != nil {
+		return nil, err
+	}
+	return b.root, nil
+}
+
+// treeBuilder builds node tree from the VTD tokens of a parsed document
+type treeBuilder struct {
+	nav   *navigation.VtdNav
+	path  string
+	root  *node
+	stack []*node
+}
+
+func (b *treeBuilder) build() error {
+	size := b.nav.GetVtdBufferSize()
+	for i := 0; i < size; i++ {
+		tokenType, err := b.nav.GetTokenType(i)
+		if err != nil {
+			return err
+		}
+		if common.Token(tokenType) != common.TokenStartingTag {
+			continue
+		}
+		depth, err := b.nav.GetTokenDepth(i)
+		if err != nil {
+			return err
+		}
+		if i, err = b.element(i, int(depth)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// element function adds element starting at the index to the tree and
+// returns index of its last attribute token
+func (b *treeBuilder) element(index, depth int) (int, error) {
+	b.stack = b.stack[:depth]
+	namespaces := map[string]string{"xml": xmlNamespace}
+	if depth > 0 {
+		namespaces = b.stack[depth-1].namespaces
+	}
+	line, _, err := b.nav.GetTokenPosition(index)
+	if err != nil {
+		return 0, err
+	}
+	n := &node{attrs: make(map[string]string), namespaces: namespaces, path: b.path, line: line}
+
+	attrs, last, err := readAttributes(b.nav, index)
+	if err != nil {
+		return 0, err
+	}
+	copied := false
+	for _, a := range attrs {
+		if prefix, ok := namespacePrefix(a.name); ok {
+			if !copied {
+				n.namespaces, copied = copyNamespaces(namespaces), true
+			}
+			n.namespaces[prefix] = a.value
+			continue
+		}
+		n.attrs[a.name] = a.value
+	}
+
+	raw, err := b.nav.ToRawStringAtIndex(index)
+	if err != nil {
+		return 0, err
+	}
+	if n.name, err = n.resolve(raw); err != nil {
+		return 0, err
+	}
+	if depth == 0 {
+		b.root = n
+	} else {
+		parent := b.stack[depth-1]
+		parent.children = append(parent.children, n)
+	}
+	b.stack = append(b.stack, n)
+	return last, nil
+}
+
+// attribute is an attribute of an element in a parsed document
+type attribute struct {
+	index       int
+	name, value string
+}
+
+// readAttributes function reads attributes of the element starting at the
+// index, values are returned with entity references resolved. Index of the
+// last attribute token is returned as well.
+func readAttributes(nav *navigation.VtdNav, index int) ([]attribute, int, error) {
+	var attrs []attribute
+	last := index
+	size := nav.GetVtdBufferSize()
+	for i := index + 1; i+1 < size; i += 2 {
+		tokenType, err := nav.GetTokenType(i)
+		if err != nil {
+			return nil, 0, err
+		}
+		if common.Token(tokenType) != common.TokenAttrName && common.Token(tokenType) != common.TokenAttrNs {
+			break
+		}
+		name, err := nav.ToRawStringAtIndex(i)
+		if err != nil {
+			return nil, 0, err
+		}
+		value, err := nav.ToStringAtIndex(i + 1)
+		if err != nil {
+			return nil, 0, err
+		}
+		attrs = append(attrs, attribute{index: i, name: name, value: value})
+		last = i + 1
+	}
+	return attrs, last, nil
+}
+
+// namespacePrefix function returns the prefix declared by xmlns attribute,
+// the default namespace is declared by empty prefix
+func namespacePrefix(name string) (string, bool) {
+	if name == "xmlns" {
+		return "", true
+	}
+	if strings.HasPrefix(name, "xmlns:") {
+		return name[len("xmlns:"):], true
+	}
+	return "", false
+}
+
+func copyNamespaces(namespaces map[string]string) map[string]string {
+	res := make(map[string]string, len(namespaces)+1)
+	for prefix, space := range namespaces {
+		res[prefix] = space
+	}
+	return res
+}
+
+func splitName(name string) (string, string) {
+	if i := strings.IndexByte(name, ':'); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return "", name
+}
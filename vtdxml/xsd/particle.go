@@ -0,0 +1,246 @@
+package xsd
+
+import (
+	"fmt"
+	"strings"
+)
+
+type ParticleKind int
+
+const (
+	ParticleElement ParticleKind = iota
+	ParticleSequence
+	ParticleChoice
+	ParticleAll
+	ParticleAny
+)
+
+// Particle is a node of complex type content model: an element declaration,
+// a wildcard or a model group of particles, occurring between MinOccurs and
+// MaxOccurs times. MaxOccurs is Unbounded for maxOccurs="unbounded".
+type Particle struct {
+	Kind                 ParticleKind
+	MinOccurs, MaxOccurs int
+	Element              *ElementDecl
+	Wildcard             *Wildcard
+	Children             []*Particle
+}
+
+// Wildcard is an element or attribute wildcard. Namespaces holds namespace
+// constraint tokens, e.g. ##any, ##other or namespace names, resolved
+// against TargetNamespace of the schema document.
+type Wildcard struct {
+	Namespaces      []string
+	TargetNamespace string
+	ProcessContents string
+}
+
+// Allows function returns true if the namespace satisfies the wildcard
+// namespace constraint
+func (w *Wildcard) Allows(space string) bool {
+	for _, token := range w.Namespaces {
+		switch token {
+		case "##any":
+			return true
+		case "##other":
+			if space != w.TargetNamespace && space != "" {
+				return true
+			}
+		case "##targetNamespace":
+			if space == w.TargetNamespace {
+				return true
+			}
+		case "##local":
+			if space == "" {
+				return true
+			}
+		default:
+			if space == token {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Match function returns true if the sequence of element names conforms to
+// the particle
+func (p *Particle) Match(names []QName) bool {
+	starts := make([]bool, len(names)+1)
+	starts[0] = true
+	return p.match(names, starts)[len(names)]
+}
+
+// match function returns positions in names the particle may end at when
+// started at any of the start positions. A particle repeated more than
+// len(names)+1 times beyond its minimum must match empty sequence at least
+// once, so iterations are bounded by that count.
+func (p *Particle) match(names []QName, starts []bool) []bool {
+	current := starts
+	for i := 0; i < p.MinOccurs; i++ {
+		if current = p.matchOnce(names, current); !anyPosition(current) {
+			return current
+		}
+	}
+	ends := append([]bool(nil), current...)
+	for i := p.MinOccurs; p.MaxOccurs == Unbounded || i < p.MaxOccurs; i++ {
+		if i > p.MinOccurs+len(names) {
+			break
+		}
+		current = p.matchOnce(names, current)
+		grown := false
+		for j, ok := range current {
+			if ok && !ends[j] {
+				ends[j], grown = true, true
+			}
+		}
+		// with unbounded repetition nothing new can be reached once ends stop
+		// growing, bounded repetition may need to reach the same positions again
+		if !grown && (p.MaxOccurs == Unbounded || !anyPosition(current)) {
+			break
+		}
+	}
+	return ends
+}
+
+// matchOnce function matches the particle ignoring its occurrence bounds
+func (p *Particle) matchOnce(names []QName, starts []bool) []bool {
+	ends := make([]bool, len(starts))
+	switch p.Kind {
+	case ParticleElement, ParticleAny:
+		for i := 0; i < len(names); i++ {
+			if starts[i] && p.accepts(names[i]) {
+				ends[i+1] = true
+			}
+		}
+	case ParticleChoice:
+		for _, child := range p.Children {
+			for i, ok := range child.match(names, starts) {
+				ends[i] = ends[i] || ok
+			}
+		}
+	case ParticleSequence:
+		ends = starts
+		for _, child := range p.Children {
+			ends = child.match(names, ends)
+		}
+	case ParticleAll:
+		for i, ok := range starts {
+			if ok {
+				p.matchAll(names, i, ends)
+			}
+		}
+	}
+	return ends
+}
+
+// matchAll function marks positions all group started at the position may
+// end at. Each child of the group occurs at most once, in any order.
+func (p *Particle) matchAll(names []QName, start int, ends []bool) {
+	seen := make([]bool, len(p.Children))
+	for i := start; ; i++ {
+		complete := true
+		for j, child := range p.Children {
+			if !seen[j] && child.MinOccurs > 0 {
+				complete = false
+			}
+		}
+		if complete {
+			ends[i] = true
+		}
+		if i == len(names) {
+			return
+		}
+		found := false
+		for j, child := range p.Children {
+			if !seen[j] && child.accepts(names[i]) {
+				seen[j], found = true, true
+				break
+			}
+		}
+		if !found {
+			return
+		}
+	}
+}
+
+// accepts function returns true if element or wildcard particle accepts the
+// element name
+func (p *Particle) accepts(name QName) bool {
+	if p.Kind == ParticleAny {
+		return p.Wildcard.Allows(name.Space)
+	}
+	return p.Kind == ParticleElement && p.Element.Name == name
+}
+
+// collectElements function indexes element declarations of the particle
+func (p *Particle) collectElements(elements map[QName]*ElementDecl) {
+	if p.Kind == ParticleElement {
+		if _, ok := elements[p.Element.Name]; !ok {
+			elements[p.Element.Name] = p.Element
+		}
+	}
+	for _, child := range p.Children {
+		child.collectElements(elements)
+	}
+}
+
+// wildcard function returns the first wildcard of the particle allowing the
+// namespace, or nil if there is none
+func (p *Particle) wildcard(space string) *Wildcard {
+	if p.Kind == ParticleAny && p.Wildcard.Allows(space) {
+		return p.Wildcard
+	}
+	for _, child := range p.Children {
+		if w := child.wildcard(space); w != nil {
+			return w
+		}
+	}
+	return nil
+}
+
+// String function describes the particle in DTD-like notation, e.g.
+// (a, (b | c)+, d?)
+func (p *Particle) String() string {
+	var s string
+	switch p.Kind {
+	case ParticleElement:
+		s = p.Element.Name.Local
+	case ParticleAny:
+		s = "any"
+	default:
+		separator := ", "
+		if p.Kind == ParticleChoice {
+			separator = " | "
+		} else if p.Kind == ParticleAll {
+			separator = " & "
+		}
+		parts := make([]string, len(p.Children))
+		for i, child := range p.Children {
+			parts[i] = child.String()
+		}
+		s = "(" + strings.Join(parts, separator) + ")"
+	}
+	switch {
+	case p.MinOccurs == 1 && p.MaxOccurs == 1:
+		return s
+	case p.MinOccurs == 0 && p.MaxOccurs == 1:
+		return s + "?"
+	case p.MinOccurs == 0 && p.MaxOccurs == Unbounded:
+		return s + "*"
+	case p.MinOccurs == 1 && p.MaxOccurs == Unbounded:
+		return s + "+"
+	case p.MaxOccurs == Unbounded:
+		return fmt.Sprintf("%s{%d,}", s, p.MinOccurs)
+	}
+	return fmt.Sprintf("%s{%d,%d}", s, p.MinOccurs, p.MaxOccurs)
+}
+
+func anyPosition(positions []bool) bool {
+	for _, ok := range positions {
+		if ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package xsd
+
+import (
+	"fmt"
+)
+
+const (
+	// Namespace is the namespace of XML Schema definitions
+	Namespace = "http://www.w3.org/2001/XMLSchema"
+	// InstanceNamespace is the namespace of xsi attributes in instance documents
+	InstanceNamespace = "http://www.w3.org/2001/XMLSchema-instance"
+
+	xmlNamespace = "http://www.w3.org/XML/1998/namespace"
+
+	// Unbounded is the maximum number of occurrences of a particle with
+	// maxOccurs="unbounded"
+	Unbounded = -1
+)
+
+// QName is a name qualified by namespace, Space is empty for names in no
+// namespace
+type QName struct {
+	Space, Local string
+}
+
+// String function returns the name in {namespace}local notation
+func (n QName) String() string {
+	if n.Space == "" {
+		return n.Local
+	}
+	return fmt.Sprintf("{%s}%s", n.Space, n.Local)
+}
+
+// ElementDecl is an element declaration. Exactly one of Simple and Complex
+// types is set.
+type ElementDecl struct {
+	Name           QName
+	Simple         *SimpleType
+	Complex        *ComplexType
+	Fixed, Default *string
+	Nillable       bool
+}
+
+// AttributeUse is an attribute declared by complex type
+type AttributeUse struct {
+	Name                 QName
+	Type                 *SimpleType
+	Required, Prohibited bool
+	Fixed, Default       *string
+}
+
+// ComplexType is a complex type definition. Elements of a type with simple
+// content hold text of the Simple type, otherwise the content is described
+// by the Particle, or is empty if there is none.
+type ComplexType struct {
+	Name         QName
+	Mixed        bool
+	Particle     *Particle
+	Simple       *SimpleType
+	Attributes   []*AttributeUse
+	AnyAttribute *Wildcard
+	// anyContent is set for xs:anyType, which allows any content and attributes
+	anyContent bool
+	elements   map[QName]*ElementDecl
+}
+
+// attribute function returns attribute use with the name, or nil if the
+// type does not declare it
+func (t *ComplexType) attribute(name QName) *AttributeUse {
+	for _, a := range t.Attributes {
+		if a.Name == name {
+			return a
+		}
+	}
+	return nil
+}
+
+// element function returns declaration of element the content model of the
+// type contains. Element Declarations Consistent constraint guarantees that
+// same names are declared alike within one content model.
+func (t *ComplexType) element(name QName) *ElementDecl {
+	if t.elements == nil {
+		t.elements = make(map[QName]*ElementDecl)
+		if t.Particle != nil {
+			t.Particle.collectElements(t.elements)
+		}
+	}
+	return t.elements[name]
+}
+
+// Schema is a set of compiled schema components indexed by qualified name
+type Schema struct {
+	elements     map[QName]*ElementDecl
+	simpleTypes  map[QName]*SimpleType
+	complexTypes map[QName]*ComplexType
+}
+
+// GetElement function returns global element declaration
+func (s *Schema) GetElement(name QName) (*ElementDecl, bool) {
+	e, ok := s.elements[name]
+	return e, ok
+}
+
+// GetSimpleType function returns global simple type definition, built-in
+// types are defined in Namespace
+func (s *Schema) GetSimpleType(name QName) (*SimpleType, bool) {
+	if name.Space == Namespace {
+		t, ok := builtInTypes[name.Local]
+		return t, ok
+	}
+	t, ok := s.simpleTypes[name]
+	return t, ok
+}
+
+// GetComplexType function returns global complex type definition
+func (s *Schema) GetComplexType(name QName) (*ComplexType, bool) {
+	if name == anyType.Name {
+		return anyType, true
+	}
+	t, ok := s.complexTypes[name]
+	return t, ok
+}
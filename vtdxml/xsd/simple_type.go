@@ -0,0 +1,264 @@
+package xsd
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+type Variety int
+
+const (
+	VarietyAtomic Variety = iota
+	VarietyList
+	VarietyUnion
+)
+
+type WhiteSpace int
+
+const (
+	// WhiteSpaceUnset inherits whitespace handling of the base type
+	WhiteSpaceUnset WhiteSpace = iota
+	WhiteSpacePreserve
+	WhiteSpaceReplace
+	WhiteSpaceCollapse
+)
+
+// Facets constrain values of a simple type derived by restriction. Patterns
+// of one derivation step are alternatives, a value must match one of them.
+// Range facets apply to numeric types only.
+type Facets struct {
+	Length, MinLength, MaxLength *int
+	Patterns                     []*regexp.Regexp
+	Enumeration                  []string
+	TotalDigits, FractionDigits  *int
+	MinInclusive, MaxInclusive   *big.Rat
+	MinExclusive, MaxExclusive   *big.Rat
+	WhiteSpace                   WhiteSpace
+}
+
+// SimpleType is a simple type definition. Atomic types restrict their Base
+// type with Facets, the built-in primitive types check the lexical form of
+// values. List types hold whitespace separated items of ItemType, union
+// values are valid for one of MemberTypes.
+type SimpleType struct {
+	Name        QName
+	Base        *SimpleType
+	Variety     Variety
+	ItemType    *SimpleType
+	MemberTypes []*SimpleType
+	Facets      Facets
+	primitive   *primitive
+}
+
+// Validate function checks the value against the type and returns the
+// reason it is not valid, or an empty string if the value is valid
+func (t *SimpleType) Validate(value string) string {
+	value = t.normalize(value)
+	switch t.Variety {
+	case VarietyList:
+		item := t.itemType()
+		items := strings.Fields(value)
+		for _, v := range items {
+			if reason := item.Validate(v); reason != "" {
+				return reason
+			}
+		}
+		return t.checkFacets(value, len(items))
+	case VarietyUnion:
+		for _, member := range t.memberTypes() {
+			if member.Validate(value) == "" {
+				return t.checkFacets(value, utf8.RuneCountInString(value))
+			}
+		}
+		return "value does not match any member type of the union"
+	}
+	p := t.primitiveType()
+	if p != nil && p.valid != nil && !p.valid(value) {
+		return fmt.Sprintf("value is not a valid %s", p.name)
+	}
+	return t.checkFacets(value, t.length(value))
+}
+
+func (t *SimpleType) itemType() *SimpleType {
+	for ; t != nil; t = t.Base {
+		if t.ItemType != nil {
+			return t.ItemType
+		}
+	}
+	return anySimpleType
+}
+
+func (t *SimpleType) memberTypes() []*SimpleType {
+	for ; t != nil; t = t.Base {
+		if t.MemberTypes != nil {
+			return t.MemberTypes
+		}
+	}
+	return nil
+}
+
+func (t *SimpleType) primitiveType() *primitive {
+	for ; t != nil; t = t.Base {
+		if t.primitive != nil {
+			return t.primitive
+		}
+	}
+	return nil
+}
+
+// normalize function applies whitespace facet of the type to the value
+func (t *SimpleType) normalize(value string) string {
+	ws := WhiteSpaceCollapse
+	for s := t; s != nil; s = s.Base {
+		if s.Facets.WhiteSpace != WhiteSpaceUnset {
+			ws = s.Facets.WhiteSpace
+			break
+		}
+	}
+	switch ws {
+	case WhiteSpaceReplace:
+		return strings.Map(replaceWhiteSpace, value)
+	case WhiteSpaceCollapse:
+		return strings.Join(strings.Fields(value), " ")
+	}
+	return value
+}
+
+func replaceWhiteSpace(r rune) rune {
+	if r == '\t' || r == '\n' || r == '\r' {
+		return ' '
+	}
+	return r
+}
+
+// length function returns length of the value as length facets measure it:
+// octets for binary types and characters otherwise
+func (t *SimpleType) length(value string) int {
+	if p := t.primitiveType(); p != nil {
+		switch p.name {
+		case "hexBinary":
+			return len(value) / 2
+		case "base64Binary":
+			if b, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(value, " ", "")); err == nil {
+				return len(b)
+			}
+		}
+	}
+	return utf8.RuneCountInString(value)
+}
+
+// checkFacets function checks the value against facets of the type and of
+// every type it is derived from
+func (t *SimpleType) checkFacets(value string, length int) string {
+	numeric := false
+	if p := t.primitiveType(); p != nil {
+		numeric = p.numeric
+	}
+	for s := t; s != nil; s = s.Base {
+		if reason := s.Facets.check(value, length, numeric); reason != "" {
+			return reason
+		}
+	}
+	return ""
+}
+
+// check function checks the value of the length against the facets
+func (f *Facets) check(value string, length int, numeric bool) string {
+	if f.Length != nil && length != *f.Length {
+		return fmt.Sprintf("length %d is not %d", length, *f.Length)
+	}
+	if f.MinLength != nil && length < *f.MinLength {
+		return fmt.Sprintf("length %d is less than %d", length, *f.MinLength)
+	}
+	if f.MaxLength != nil && length > *f.MaxLength {
+		return fmt.Sprintf("length %d is greater than %d", length, *f.MaxLength)
+	}
+	if len(f.Patterns) > 0 {
+		matched := false
+		for _, pattern := range f.Patterns {
+			if pattern.MatchString(value) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Sprintf("value does not match pattern %s", patternSource(f.Patterns[0]))
+		}
+	}
+	if len(f.Enumeration) > 0 && !f.enumerates(value, numeric) {
+		return fmt.Sprintf("value is not one of %s", strings.Join(f.Enumeration, ", "))
+	}
+	if !numeric {
+		return ""
+	}
+	if f.TotalDigits != nil || f.FractionDigits != nil {
+		total, fraction := countDigits(value)
+		if f.TotalDigits != nil && total > *f.TotalDigits {
+			return fmt.Sprintf("value has more than %d digits", *f.TotalDigits)
+		}
+		if f.FractionDigits != nil && fraction > *f.FractionDigits {
+			return fmt.Sprintf("value has more than %d fraction digits", *f.FractionDigits)
+		}
+	}
+	if f.MinInclusive == nil && f.MaxInclusive == nil && f.MinExclusive == nil && f.MaxExclusive == nil {
+		return ""
+	}
+	v, ok := new(big.Rat).SetString(value)
+	if !ok {
+		return ""
+	}
+	switch {
+	case f.MinInclusive != nil && v.Cmp(f.MinInclusive) < 0:
+		return fmt.Sprintf("value is less than %s", f.MinInclusive.RatString())
+	case f.MaxInclusive != nil && v.Cmp(f.MaxInclusive) > 0:
+		return fmt.Sprintf("value is greater than %s", f.MaxInclusive.RatString())
+	case f.MinExclusive != nil && v.Cmp(f.MinExclusive) <= 0:
+		return fmt.Sprintf("value must be greater than %s", f.MinExclusive.RatString())
+	case f.MaxExclusive != nil && v.Cmp(f.MaxExclusive) >= 0:
+		return fmt.Sprintf("value must be less than %s", f.MaxExclusive.RatString())
+	}
+	return ""
+}
+
+// enumerates function returns true if the value is enumerated, numeric
+// values are compared in the value space
+func (f *Facets) enumerates(value string, numeric bool) bool {
+	for _, e := range f.Enumeration {
+		if e == value {
+			return true
+		}
+		if numeric {
+			a, okA := new(big.Rat).SetString(e)
+			b, okB := new(big.Rat).SetString(value)
+			if okA && okB && a.Cmp(b) == 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// countDigits function returns the number of significant digits of decimal
+// value and the number of its fraction digits, leading zeros of the integer
+// part and trailing zeros of the fraction part are not significant
+func countDigits(value string) (int, int) {
+	value = strings.TrimLeft(value, "+-")
+	integer, fraction := value, ""
+	if i := strings.IndexByte(value, '.'); i >= 0 {
+		integer, fraction = value[:i], value[i+1:]
+	}
+	integer = strings.TrimLeft(integer, "0")
+	fraction = strings.TrimRight(fraction, "0")
+	return len(integer) + len(fraction), len(fraction)
+}
+
+// patternSource function returns the schema pattern the expression was
+// compiled from
+func patternSource(re *regexp.Regexp) string {
+	s := re.String()
+	return strings.TrimSuffix(strings.TrimPrefix(s, "^(?:"), ")$")
+}
@@ -0,0 +1,121 @@
+package xsd
+
+import (
+	"math/big"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SimpleType_BuiltIn(t *testing.T) {
+	testCases := []struct {
+		typeName string
+		valid    []string
+		invalid  []string
+	}{
+		{typeName: "boolean", valid: []string{"true", " 0 "}, invalid: []string{"yes", "TRUE"}},
+		{typeName: "decimal", valid: []string{"1", "-1.50", ".5", "+3."}, invalid: []string{"1e3", "", "1,5"}},
+		{typeName: "integer", valid: []string{"-12", "007"}, invalid: []string{"1.0"}},
+		{typeName: "byte", valid: []string{"-128", "127"}, invalid: []string{"128"}},
+		{typeName: "unsignedInt", valid: []string{"4294967295"}, invalid: []string{"-1", "4294967296"}},
+		{typeName: "positiveInteger", valid: []string{"1"}, invalid: []string{"0"}},
+		{typeName: "double", valid: []string{"1.5E-3", "INF", "NaN"}, invalid: []string{"inf", "1.5E"}},
+		{typeName: "date", valid: []string{"2019-10-08", "2019-10-08Z", "2019-10-08+02:00"}, invalid: []string{"2019-13-08", "08.10.2019"}},
+		{typeName: "dateTime", valid: []string{"2019-10-08T18:02:00.001+00:00"}, invalid: []string{"2019-10-08 18:02:00"}},
+		{typeName: "duration", valid: []string{"P1Y2M", "PT1.5S", "-P3D"}, invalid: []string{"P", "P1DT", "1D"}},
+		{typeName: "hexBinary", valid: []string{"0aFF", ""}, invalid: []string{"abc"}},
+		{typeName: "base64Binary", valid: []string{"SGVsbG8="}, invalid: []string{"SGVsbG8"}},
+		{typeName: "NCName", valid: []string{"a-b.c"}, invalid: []string{"a:b", "1a"}},
+		{typeName: "NMTOKENS", valid: []string{"a b  c"}, invalid: []string{"", "a $"}},
+		{typeName: "language", valid: []string{"en-GB"}, invalid: []string{"toolongtag"}},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.typeName, func(t *testing.T) {
+			st, ok := builtInTypes[tc.typeName]
+			if !assert.True(t, ok) {
+				t.FailNow()
+			}
+			for _, v := range tc.valid {
+				assert.Empty(t, st.Validate(v), v)
+			}
+			for _, v := range tc.invalid {
+				assert.NotEmpty(t, st.Validate(v), v)
+			}
+		})
+	}
+}
+
+func Test_SimpleType_Facets(t *testing.T) {
+	two, three := 2, 3
+	pattern, err := compilePattern(`[A-Z]{3}`)
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	min, _ := new(big.Rat).SetString("0")
+	amount := &SimpleType{
+		Base:   builtInTypes["decimal"],
+		Facets: Facets{TotalDigits: &three, FractionDigits: &two, MinExclusive: min},
+	}
+	testCases := []struct {
+		name   string
+		st     *SimpleType
+		value  string
+		reason string
+	}{
+		{name: "pattern", st: &SimpleType{Base: builtInTypes["string"], Facets: Facets{Patterns: []*regexp.Regexp{pattern}}}, value: "EUR"},
+		{name: "pattern mismatch", st: &SimpleType{Base: builtInTypes["string"], Facets: Facets{Patterns: []*regexp.Regexp{pattern}}}, value: "EURO", reason: "value does not match pattern [A-Z]{3}"},
+		{name: "length", st: &SimpleType{Base: builtInTypes["string"], Facets: Facets{Length: &two}}, value: "abc", reason: "length 3 is not 2"},
+		{name: "max length", st: &SimpleType{Base: builtInTypes["token"], Facets: Facets{MaxLength: &two}}, value: "  ab  "},
+		{name: "hex length", st: &SimpleType{Base: builtInTypes["hexBinary"], Facets: Facets{MinLength: &two}}, value: "0a", reason: "length 1 is less than 2"},
+		{name: "enumeration", st: &SimpleType{Base: builtInTypes["string"], Facets: Facets{Enumeration: []string{"A", "B"}}}, value: "C", reason: "value is not one of A, B"},
+		{name: "numeric enumeration", st: &SimpleType{Base: builtInTypes["decimal"], Facets: Facets{Enumeration: []string{"1.0"}}}, value: "1"},
+		{name: "digits", st: amount, value: "1.20"},
+		{name: "total digits", st: amount, value: "12.34", reason: "value has more than 3 digits"},
+		{name: "fraction digits", st: amount, value: "0.123", reason: "value has more than 2 fraction digits"},
+		{name: "min exclusive", st: amount, value: "0.00", reason: "value must be greater than 0"},
+		{name: "lexical", st: amount, value: "abc", reason: "value is not a valid decimal"},
+		{name: "base facets", st: &SimpleType{Base: amount}, value: "0.001", reason: "value has more than 2 fraction digits"},
+		{name: "union", st: &SimpleType{Variety: VarietyUnion, MemberTypes: []*SimpleType{builtInTypes["date"], builtInTypes["boolean"]}}, value: "1"},
+		{name: "union mismatch", st: &SimpleType{Variety: VarietyUnion, MemberTypes: []*SimpleType{builtInTypes["date"], builtInTypes["boolean"]}}, value: "2", reason: "value does not match any member type of the union"},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.reason, tc.st.Validate(tc.value))
+		})
+	}
+}
+
+func Test_CompilePattern(t *testing.T) {
+	testCases := []struct {
+		pattern string
+		valid   []string
+		invalid []string
+	}{
+		{pattern: `[A-Z]{6}[A-Z2-9][A-NP-Z0-9]([A-Z0-9]{3})?`, valid: []string{"COBADEFFXXX", "COBADEFF"}, invalid: []string{"COBADEFFXXXX", "cobadeff"}},
+		{pattern: `\i\c*`, valid: []string{"a1", "_x.y"}, invalid: []string{"1a"}},
+		{pattern: `a$b^`, valid: []string{"a$b^"}, invalid: []string{"ab"}},
+		{pattern: `[$^a]+`, valid: []string{"$^a"}, invalid: []string{"b"}},
+		{pattern: `a|b`, valid: []string{"a", "b"}, invalid: []string{"ab"}},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.pattern, func(t *testing.T) {
+			re, err := compilePattern(tc.pattern)
+			if !assert.Nil(t, err) {
+				t.FailNow()
+			}
+			for _, v := range tc.valid {
+				assert.True(t, re.MatchString(v), v)
+			}
+			for _, v := range tc.invalid {
+				assert.False(t, re.MatchString(v), v)
+			}
+		})
+	}
+
+	_, err := compilePattern(`[a-z-[aeiou]]`)
+	assert.NotNil(t, err)
+}
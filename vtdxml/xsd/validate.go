@@ -0,0 +1,429 @@
+package xsd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/common"
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/erroring"
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/navigation"
+)
+
+// Violation is a schema constraint the document does not meet. Path locates
+// the element or attribute in XPath-like notation, e.g.
+// /Document/Stmt/Ntry[2]/Amt/@Ccy, with positions given for elements that
+// have siblings of the same name. Index is the VTD token the violation was
+// found at, Line and Column locate the token in the document.
+type Violation struct {
+	Path         string
+	Index        int
+	Line, Column int
+	Msg          string
+}
+
+// String function returns the violation message with its location
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s (line %d, column %d)", v.Path, v.Msg, v.Line, v.Column)
+}
+
+// Validate function checks the parsed document against the schema and
+// returns every violation found, in document order. The VTD token stream
+// is walked, the document is not parsed again. Namespaces are resolved from
+// the xmlns attributes, so the document does not need to be parsed with
+// namespace awareness. Error is returned if tokens cannot be read.
+func (s *Schema) Validate(nav *navigation.VtdNav) ([]Violation, error) {
+	if nav == nil {
+		return nil, erroring.NewInvalidArgumentError("nav", erroring.CannotBeNil, nil)
+	}
+	v := &validator{schema: s, nav: nav}
+	if err := v.walk(); err != nil {
+		return nil, err
+	}
+	violations := make([]Violation, len(v.violations))
+	for i, p := range v.violations {
+		violations[i] = Violation{Path: p.path.String() + p.suffix, Index: p.index, Line: p.line, Column: p.column, Msg: p.msg}
+	}
+	sort.SliceStable(violations, func(i, j int) bool {
+		return violations[i].Index < violations[j].Index
+	})
+	return violations, nil
+}
+
+// pathNode is an element on the path of a violation. Positions are known
+// once all siblings have been seen, so paths are built after the walk.
+type pathNode struct {
+	parent   *pathNode
+	name     string
+	position int
+	counts   map[string]int
+}
+
+// String function returns XPath-like location of the element
+func (n *pathNode) String() string {
+	if n == nil {
+		return ""
+	}
+	step := n.name
+	if n.parent != nil && n.parent.counts[n.name] > 1 {
+		step = fmt.Sprintf("%s[%d]", n.name, n.position)
+	}
+	return n.parent.String() + "/" + step
+}
+
+// child function returns path node of the next child element with the name
+func (n *pathNode) child(name string) *pathNode {
+	if n == nil {
+		return &pathNode{name: name, position: 1, counts: make(map[string]int)}
+	}
+	n.counts[name]++
+	return &pathNode{parent: n, name: name, position: n.counts[name], counts: make(map[string]int)}
+}
+
+// frame is an open element being validated. Elements of complex types
+// collect names of their children, elements of simple types collect text.
+// Children of skipped elements are not validated.
+type frame struct {
+	index      int
+	name       QName
+	path       *pathNode
+	namespaces map[string]string
+	decl       *ElementDecl
+	complex    *ComplexType
+	simple     *SimpleType
+	skip, lax  bool
+	nilled     bool
+	children   []QName
+	text       strings.Builder
+	hasText    bool
+}
+
+// pendingViolation is a violation whose path is not known yet
+type pendingViolation struct {
+	path                *pathNode
+	suffix              string
+	index, line, column int
+	msg                 string
+}
+
+type validator struct {
+	schema     *Schema
+	nav        *navigation.VtdNav
+	stack      []*frame
+	violations []pendingViolation
+}
+
+// walk function visits every token of the document in order
+func (v *validator) walk() error {
+	size := v.nav.GetVtdBufferSize()
+	for i := 0; i < size; i++ {
+		tokenType, err := v.nav.GetTokenType(i)
+		if err != nil {
+			return err
+		}
+		depth, err := v.nav.GetTokenDepth(i)
+		if err != nil {
+			return err
+		}
+		switch common.Token(tokenType) {
+		case common.TokenStartingTag:
+			if err := v.closeTo(int(depth)); err != nil {
+				return err
+			}
+			if i, err = v.startElement(i); err != nil {
+				return err
+			}
+		case common.TokenCharacterData, common.TokenCdataVal:
+			if err := v.closeTo(int(depth) + 1); err != nil {
+				return err
+			}
+			if err := v.characterData(i, common.Token(tokenType)); err != nil {
+				return err
+			}
+		}
+	}
+	return v.closeTo(0)
+}
+
+// startElement function opens the element starting at the index, checks it
+// is allowed by the parent and validates its attributes. Index of the last
+// attribute token is returned.
+func (v *validator) startElement(index int) (int, error) {
+	var parent *frame
+	namespaces := map[string]string{"xml": xmlNamespace}
+	if len(v.stack) > 0 {
+		parent = v.stack[len(v.stack)-1]
+		namespaces = parent.namespaces
+	}
+	attrs, last, err := readAttributes(v.nav, index)
+	if err != nil {
+		return 0, err
+	}
+	copied := false
+	for _, a := range attrs {
+		if prefix, ok := namespacePrefix(a.name); ok {
+			if !copied {
+				namespaces, copied = copyNamespaces(namespaces), true
+			}
+			namespaces[prefix] = a.value
+		}
+	}
+	raw, err := v.nav.ToRawStringAtIndex(index)
+	if err != nil {
+		return 0, err
+	}
+	f := &frame{index: index, namespaces: namespaces}
+	prefix, local := splitName(raw)
+	f.name = QName{Space: namespaces[prefix], Local: local}
+	if parent == nil {
+		f.path = (*pathNode)(nil).child(raw)
+	} else {
+		f.path = parent.path.child(raw)
+	}
+	v.stack = append(v.stack, f)
+
+	if err := v.declare(f, parent); err != nil {
+		return 0, err
+	}
+	if f.skip {
+		return last, nil
+	}
+	return last, v.attributes(f, attrs)
+}
+
+// declare function finds declaration of the element the frame holds
+func (v *validator) declare(f *frame, parent *frame) error {
+	switch {
+	case parent == nil:
+		decl, ok := v.schema.GetElement(f.name)
+		if !ok {
+			f.skip = true
+			return v.report(f.path, "", f.index, "no declaration found for element %s", f.name)
+		}
+		f.decl = decl
+	case parent.skip && !parent.lax:
+		f.skip = true
+		return nil
+	case parent.simple != nil:
+		// reported once the parent is closed
+		parent.children = append(parent.children, f.name)
+		f.skip = true
+		return nil
+	case parent.lax || parent.complex.anyContent:
+		decl, ok := v.schema.GetElement(f.name)
+		if !ok {
+			f.skip, f.lax = true, true
+			return nil
+		}
+		f.decl = decl
+	default:
+		if decl := parent.complex.element(f.name); decl != nil {
+			f.decl = decl
+			break
+		}
+		var w *Wildcard
+		if parent.complex.Particle != nil {
+			w = parent.complex.Particle.wildcard(f.name.Space)
+		}
+		if w == nil {
+			f.skip = true
+			return v.report(f.path, "", f.index, "element %s is not allowed in %s", f.name, parent.name)
+		}
+		parent.children = append(parent.children, f.name)
+		decl, ok := v.schema.GetElement(f.name)
+		switch {
+		case w.ProcessContents == "skip":
+			f.skip = true
+		case !ok && w.ProcessContents == "strict":
+			f.skip = true
+			return v.report(f.path, "", f.index, "no declaration found for element %s", f.name)
+		case !ok:
+			f.skip, f.lax = true, true
+		default:
+			f.decl = decl
+		}
+		return nil
+	}
+	if parent != nil {
+		parent.children = append(parent.children, f.name)
+	}
+	f.simple, f.complex = f.decl.Simple, f.decl.Complex
+	if f.complex != nil && f.complex.Simple != nil {
+		f.simple = f.complex.Simple
+	}
+	return nil
+}
+
+// attributes function validates attributes of the element
+func (v *validator) attributes(f *frame, attrs []attribute) error {
+	seen := make(map[QName]bool)
+	for _, a := range attrs {
+		if _, ok := namespacePrefix(a.name); ok {
+			continue
+		}
+		prefix, local := splitName(a.name)
+		name := QName{Local: local}
+		if prefix != "" {
+			name.Space = f.namespaces[prefix]
+		}
+		if name.Space == InstanceNamespace {
+			if local == "nil" && strings.TrimSpace(a.value) == "true" {
+				if f.decl == nil || !f.decl.Nillable {
+					if err := v.report(f.path, "/@"+a.name, a.index, "element %s is not nillable", f.name); err != nil {
+						return err
+					}
+				} else {
+					f.nilled = true
+				}
+			}
+			continue
+		}
+		seen[name] = true
+		var use *AttributeUse
+		if f.complex != nil {
+			if f.complex.anyContent {
+				continue
+			}
+			use = f.complex.attribute(name)
+		}
+		if use == nil || use.Prohibited {
+			if use == nil && f.complex != nil && f.complex.AnyAttribute != nil && f.complex.AnyAttribute.Allows(name.Space) {
+				continue
+			}
+			if err := v.report(f.path, "/@"+a.name, a.index, "attribute %s is not allowed", name); err != nil {
+				return err
+			}
+			continue
+		}
+		if reason := use.Type.Validate(a.value); reason != "" {
+			if err := v.report(f.path, "/@"+a.name, a.index+1, "invalid value %q of attribute %s: %s", a.value, name, reason); err != nil {
+				return err
+			}
+			continue
+		}
+		if use.Fixed != nil && use.Type.normalize(a.value) != use.Type.normalize(*use.Fixed) {
+			if err := v.report(f.path, "/@"+a.name, a.index+1, "attribute %s must have fixed value %q", name, *use.Fixed); err != nil {
+				return err
+			}
+		}
+	}
+	if f.complex == nil {
+		return nil
+	}
+	for _, use := range f.complex.Attributes {
+		if use.Required && !seen[use.Name] {
+			if err := v.report(f.path, "", f.index, "required attribute %s is missing", use.Name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// characterData function adds text at the index to the element it belongs to
+func (v *validator) characterData(index int, tokenType common.Token) error {
+	if len(v.stack) == 0 {
+		return nil
+	}
+	f := v.stack[len(v.stack)-1]
+	if f.skip {
+		return nil
+	}
+	var text string
+	var err error
+	if tokenType == common.TokenCdataVal {
+		text, err = v.nav.ToRawStringAtIndex(index)
+	} else {
+		text, err = v.nav.ToStringAtIndex(index)
+	}
+	if err != nil {
+		return err
+	}
+	f.text.WriteString(text)
+	if tokenType == common.TokenCdataVal || strings.TrimSpace(text) != "" {
+		f.hasText = true
+	}
+	return nil
+}
+
+// closeTo function closes open elements until depth elements remain open,
+// checking content of each element closed
+func (v *validator) closeTo(depth int) error {
+	if depth < 0 {
+		depth = 0
+	}
+	for len(v.stack) > depth {
+		f := v.stack[len(v.stack)-1]
+		v.stack = v.stack[:len(v.stack)-1]
+		if err := v.content(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// content function checks content of the element against its type
+func (v *validator) content(f *frame) error {
+	if f.skip {
+		return nil
+	}
+	if f.nilled {
+		if f.hasText || len(f.children) > 0 {
+			return v.report(f.path, "", f.index, "element %s is nil and must be empty", f.name)
+		}
+		return nil
+	}
+	if f.simple != nil {
+		if len(f.children) > 0 {
+			return v.report(f.path, "", f.index, "element %s cannot have child elements", f.name)
+		}
+		value := f.text.String()
+		if value == "" && f.decl.Default != nil {
+			value = *f.decl.Default
+		}
+		if reason := f.simple.Validate(value); reason != "" {
+			return v.report(f.path, "", f.index, "invalid value %q of element %s: %s", strings.TrimSpace(value), f.name, reason)
+		}
+		if f.decl.Fixed != nil && f.simple.normalize(value) != f.simple.normalize(*f.decl.Fixed) {
+			return v.report(f.path, "", f.index, "element %s must have fixed value %q", f.name, *f.decl.Fixed)
+		}
+		return nil
+	}
+
+	t := f.complex
+	if t.anyContent {
+		return nil
+	}
+	if f.hasText && !t.Mixed {
+		if err := v.report(f.path, "", f.index, "element %s cannot contain character data", f.name); err != nil {
+			return err
+		}
+	}
+	if t.Particle == nil {
+		if len(f.children) > 0 {
+			return v.report(f.path, "", f.index, "element %s must be empty", f.name)
+		}
+		return nil
+	}
+	if !t.Particle.Match(f.children) {
+		return v.report(f.path, "", f.index, "content of element %s does not match %s", f.name, t.Particle)
+	}
+	return nil
+}
+
+// report function records violation found at the token index
+func (v *validator) report(path *pathNode, suffix string, index int, format string, args ...interface{}) error {
+	line, column, err := v.nav.GetTokenPosition(index)
+	if err != nil {
+		return err
+	}
+	v.violations = append(v.violations, pendingViolation{
+		path:   path,
+		suffix: suffix,
+		index:  index,
+		line:   line,
+		column: column,
+		msg:    fmt.Sprintf(format, args...),
+	})
+	return nil
+}
@@ -0,0 +1,301 @@
+package xsd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/navigation"
+	"github.com/alexZaicev/go-vtd-xml/vtdxml/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	camt053 = "camt.053.001.08"
+	camt004 = "camt.004.001.08"
+)
+
+func compileTestSchema(t *testing.T, name string) *Schema {
+	schema, err := Compile(filepath.Join("..", "..", "testdata", "xsd", name+".xsd"))
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	return schema
+}
+
+func parseTestDoc(t *testing.T, doc []byte) *navigation.VtdNav {
+	p, err := parser.NewVtdParser(parser.WithXmlDoc(doc))
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	if !assert.Nil(t, p.Parse()) {
+		t.FailNow()
+	}
+	nav, err := p.GetNav()
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	return nav
+}
+
+func readGolden(t *testing.T, name string) string {
+	doc, err := os.ReadFile(filepath.Join("..", "..", "testdata", "xml_valid", name+".golden"))
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	return string(doc)
+}
+
+func writeSchema(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	if !assert.Nil(t, os.WriteFile(path, []byte(content), 0o600)) {
+		t.FailNow()
+	}
+	return path
+}
+
+func Test_Validate_Golden(t *testing.T) {
+	for _, name := range []string{camt053, camt004} {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			schema := compileTestSchema(t, name)
+			violations, err := schema.Validate(parseTestDoc(t, []byte(readGolden(t, name))))
+			assert.Nil(t, err)
+			assert.Empty(t, violations)
+		})
+	}
+}
+
+func Test_Validate_Violations(t *testing.T) {
+	testCases := []struct {
+		name     string
+		schema   string
+		old, new string
+		path     string
+		msg      string
+	}{
+		{
+			name:   "pattern",
+			schema: camt053,
+			old:    "<AnyBIC>COBADEBB120</AnyBIC>",
+			new:    "<AnyBIC>cobadebb</AnyBIC>",
+			path:   "/Document/BkToCstmrStmt/Stmt/Acct/Ownr/Id/OrgId/AnyBIC",
+			msg:    `invalid value "cobadebb" of element {urn:iso:std:iso:20022:tech:xsd:camt.053.001.08}AnyBIC: value does not match pattern`,
+		},
+		{
+			name:   "enumeration",
+			schema: camt053,
+			old:    "<CdtDbtInd>CRDT</CdtDbtInd>",
+			new:    "<CdtDbtInd>CREDIT</CdtDbtInd>",
+			path:   "/Document/BkToCstmrStmt/Stmt/Bal[1]/CdtDbtInd",
+			msg:    `invalid value "CREDIT" of element {urn:iso:std:iso:20022:tech:xsd:camt.053.001.08}CdtDbtInd: value is not one of CRDT, DBIT`,
+		},
+		{
+			name:   "fraction digits",
+			schema: camt053,
+			old:    `<Amt Ccy="EUR">23500.00</Amt>`,
+			new:    `<Amt Ccy="EUR">23500.000001</Amt>`,
+			path:   "/Document/BkToCstmrStmt/Stmt/Ntry[1]/Amt",
+			msg:    `value has more than 5 fraction digits`,
+		},
+		{
+			name:   "attribute pattern",
+			schema: camt053,
+			old:    `<Amt Ccy="EUR">23500.00</Amt>`,
+			new:    `<Amt Ccy="euro">23500.00</Amt>`,
+			path:   "/Document/BkToCstmrStmt/Stmt/Ntry[1]/Amt/@Ccy",
+			msg:    `invalid value "euro" of attribute Ccy`,
+		},
+		{
+			name:   "missing attribute",
+			schema: camt053,
+			old:    `<Amt Ccy="EUR">23500.00</Amt>`,
+			new:    `<Amt>23500.00</Amt>`,
+			path:   "/Document/BkToCstmrStmt/Stmt/Ntry[1]/Amt",
+			msg:    "required attribute Ccy is missing",
+		},
+		{
+			name:   "unexpected element",
+			schema: camt053,
+			old:    "<NtryRef>RTGS-p008b021</NtryRef>",
+			new:    "<NtryRef>RTGS-p008b021</NtryRef><Color>red</Color>",
+			path:   "/Document/BkToCstmrStmt/Stmt/Ntry[1]/Color",
+			msg:    "element {urn:iso:std:iso:20022:tech:xsd:camt.053.001.08}Color is not allowed in",
+		},
+		{
+			name:   "missing element",
+			schema: camt004,
+			old:    "<MsgId>NONREF</MsgId>",
+			new:    "",
+			path:   "/Document/RtrAcct/MsgHdr",
+			msg:    "content of element {urn:iso:std:iso:20022:tech:xsd:camt.004.001.08}MsgHdr does not match",
+		},
+		{
+			name:   "undeclared root",
+			schema: camt004,
+			old:    "urn:iso:std:iso:20022:tech:xsd:camt.004.001.08",
+			new:    "urn:example",
+			path:   "/Document",
+			msg:    "no declaration found for element {urn:example}Document",
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			golden := readGolden(t, tc.schema)
+			if !assert.Contains(t, golden, tc.old) {
+				t.FailNow()
+			}
+			doc := strings.Replace(golden, tc.old, tc.new, 1)
+			schema := compileTestSchema(t, tc.schema)
+			violations, err := schema.Validate(parseTestDoc(t, []byte(doc)))
+			assert.Nil(t, err)
+			if assert.Len(t, violations, 1) {
+				assert.Equal(t, tc.path, violations[0].Path)
+				assert.Contains(t, violations[0].Msg, tc.msg)
+				assert.Greater(t, violations[0].Line, 0)
+			}
+		})
+	}
+}
+
+func Test_Validate_ContentModels(t *testing.T) {
+	schema := `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" xmlns="urn:t" targetNamespace="urn:t" elementFormDefault="qualified">
+  <xs:element name="root">
+    <xs:complexType>
+      <xs:choice maxOccurs="unbounded">
+        <xs:element name="seq" type="Seq"/>
+        <xs:element name="all" type="All"/>
+        <xs:element name="list" type="Codes"/>
+      </xs:choice>
+    </xs:complexType>
+  </xs:element>
+  <xs:complexType name="Seq">
+    <xs:sequence>
+      <xs:element name="a" type="xs:int" minOccurs="2" maxOccurs="3"/>
+      <xs:element name="b" type="xs:string" minOccurs="0"/>
+    </xs:sequence>
+    <xs:attribute name="id" type="xs:ID"/>
+  </xs:complexType>
+  <xs:complexType name="All">
+    <xs:all>
+      <xs:element name="x" type="xs:boolean"/>
+      <xs:element name="y" type="xs:date" minOccurs="0"/>
+    </xs:all>
+  </xs:complexType>
+  <xs:simpleType name="Codes">
+    <xs:list>
+      <xs:simpleType>
+        <xs:restriction base="xs:string">
+          <xs:length value="2"/>
+        </xs:restriction>
+      </xs:simpleType>
+    </xs:list>
+  </xs:simpleType>
+</xs:schema>`
+	path := writeSchema(t, t.TempDir(), "test.xsd", schema)
+	s, err := Compile(path)
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	testCases := []struct {
+		name  string
+		body  string
+		paths []string
+	}{
+		{
+			name: "valid",
+			body: `<seq id="s1"><a>1</a><a> 2 </a><b/></seq><all><y>2020-01-31</y><x>true</x></all><list>AB CD</list>`,
+		},
+		{
+			name:  "too few occurrences",
+			body:  `<seq><a>1</a></seq>`,
+			paths: []string{"/t:root/t:seq"},
+		},
+		{
+			name:  "too many occurrences",
+			body:  `<seq><a>1</a><a>2</a><a>3</a><a>4</a></seq>`,
+			paths: []string{"/t:root/t:seq"},
+		},
+		{
+			name:  "all repeated",
+			body:  `<all><x>1</x><x>0</x></all>`,
+			paths: []string{"/t:root/t:all"},
+		},
+		{
+			name:  "simple values",
+			body:  `<seq id="1"><a>x</a><a>2</a></seq><list>ABC</list><seq><a>1</a><a>2</a></seq>`,
+			paths: []string{"/t:root/t:seq[1]/@id", "/t:root/t:seq[1]/t:a[1]", "/t:root/t:list"},
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			doc := `<t:root xmlns:t="urn:t">` + strings.ReplaceAll(strings.ReplaceAll(tc.body, "<", "<t:"), "<t:/", "</t:") + `</t:root>`
+			violations, err := s.Validate(parseTestDoc(t, []byte(doc)))
+			assert.Nil(t, err)
+			var paths []string
+			for _, v := range violations {
+				paths = append(paths, v.Path)
+			}
+			assert.Equal(t, tc.paths, paths)
+		})
+	}
+}
+
+func Test_Compile_Failure(t *testing.T) {
+	const header = `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">`
+	testCases := []struct {
+		name   string
+		schema string
+		msg    string
+	}{
+		{
+			name:   "not a schema",
+			schema: `<schema/>`,
+			msg:    "root element must be schema",
+		},
+		{
+			name:   "unknown type",
+			schema: header + `<xs:element name="a" type="B"/></xs:schema>`,
+			msg:    "unknown",
+		},
+		{
+			name:   "duplicate element",
+			schema: header + `<xs:element name="a"/><xs:element name="a"/></xs:schema>`,
+			msg:    "duplicate element a",
+		},
+		{
+			name:   "redefine",
+			schema: header + `<xs:redefine schemaLocation="b.xsd"/></xs:schema>`,
+			msg:    "redefine is not supported",
+		},
+		{
+			name:   "circular type",
+			schema: header + `<xs:simpleType name="a"><xs:restriction base="b"/></xs:simpleType><xs:simpleType name="b"><xs:restriction base="a"/></xs:simpleType></xs:schema>`,
+			msg:    "circular definition",
+		},
+		{
+			name:   "missing include",
+			schema: header + `<xs:include schemaLocation="missing.xsd"/></xs:schema>`,
+			msg:    "missing.xsd",
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			path := writeSchema(t, t.TempDir(), "test.xsd", tc.schema)
+			s, err := Compile(path)
+			assert.Nil(t, s)
+			if assert.NotNil(t, err) {
+				assert.Contains(t, err.Error(), tc.msg)
+			}
+		})
+	}
+
+	s, err := Compile()
+	assert.Nil(t, s)
+	assert.NotNil(t, err)
+}